@@ -1,19 +1,65 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	LogLevel   slog.Level `env:"LOG_LEVEL"`
-	AdminToken string     `env:"ADMIN_TOKEN"`
 	Addr       string     `env:"ADDR"`
 	TokenSalt  string     `env:"TOKEN_SALT"`
 	MaxSizFile int64      `env:"MAX_SIZE_FILE"`
+
+	AdminLogin    string `env:"ADMIN_LOGIN"`
+	AdminPassword string `env:"ADMIN_PASSWORD"`
+
+	CacheMaxSize int64         `env:"CACHE_MAX_SIZE"`
+	CacheTTL     time.Duration `env:"CACHE_TTL"`
+
+	ListCacheMaxSize int64         `env:"LIST_CACHE_MAX_SIZE"`
+	ListCacheTTL     time.Duration `env:"LIST_CACHE_TTL"`
+
+	DiskCacheDir     string `env:"DISK_CACHE_DIR"`
+	DiskCacheMaxSize int64  `env:"DISK_CACHE_MAX_SIZE"`
+
+	FetchTimeout time.Duration `env:"STORAGE_FETCH_TIMEOUT"`
+
+	PublicMaxAge   time.Duration `env:"CACHE_PUBLIC_MAX_AGE"`
+	PrivateMaxAge  time.Duration `env:"CACHE_PRIVATE_MAX_AGE"`
+	PrivateNoStore bool          `env:"CACHE_PRIVATE_NO_STORE"`
+
+	PurgeTargets []string `env:"PURGE_TARGETS"`
+	PurgeMethod  string   `env:"PURGE_METHOD"`
+	PurgeRetries int      `env:"PURGE_RETRIES"`
+
+	RevocationSyncInterval time.Duration `env:"REVOCATION_SYNC_INTERVAL"`
+	StorageCleanupInterval time.Duration `env:"STORAGE_CLEANUP_INTERVAL"`
+
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL"`
+
+	PasswordMemory  int `env:"PASSWORD_MEMORY"`
+	PasswordTime    int `env:"PASSWORD_TIME"`
+	PasswordThreads int `env:"PASSWORD_THREADS"`
+
+	LockoutStore       string        `env:"LOCKOUT_STORE"`
+	LockoutThreshold   int           `env:"LOCKOUT_THRESHOLD"`
+	LockoutIPThreshold int           `env:"LOCKOUT_IP_THRESHOLD"`
+	LockoutBaseDelay   time.Duration `env:"LOCKOUT_BASE_DELAY"`
+	LockoutMaxDelay    time.Duration `env:"LOCKOUT_MAX_DELAY"`
+	LockoutWindow      time.Duration `env:"LOCKOUT_WINDOW"`
+
+	JWTKeysDir     string    `env:"JWT_KEYS_DIR"`
+	JWTActiveKID   string    `env:"JWT_ACTIVE_KID"`
+	JWTLegacyUntil time.Time `env:"JWT_LEGACY_UNTIL"`
 }
 
 func New() *Config {
@@ -32,7 +78,6 @@ func (c *Config) Parse() error {
 		return err
 	}
 	c.LogLevel = slog.Level(logLevel)
-	c.AdminToken = os.Getenv("ADMIN_TOKEN")
 	c.Addr = os.Getenv("ADDR")
 	c.TokenSalt = os.Getenv("TOKEN_SALT")
 	maxSize := os.Getenv("MAX_SIZE_FILE")
@@ -41,5 +86,200 @@ func (c *Config) Parse() error {
 		return err
 	}
 	c.MaxSizFile = int64(maxSizeInt) << 20
+
+	// первый администратор, создается при запуске, если в базе еще нет ни одного
+	c.AdminLogin = os.Getenv("ADMIN_LOGIN")
+	c.AdminPassword = os.Getenv("ADMIN_PASSWORD")
+
+	// кеш документов
+	cacheMaxSize, err := getEnvInt("CACHE_MAX_SIZE", 64)
+	if err != nil {
+		return err
+	}
+	c.CacheMaxSize = int64(cacheMaxSize) << 20
+	c.CacheTTL, err = getEnvDuration("CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	// кеш списков документов
+	listCacheMaxSize, err := getEnvInt("LIST_CACHE_MAX_SIZE", 16)
+	if err != nil {
+		return err
+	}
+	c.ListCacheMaxSize = int64(listCacheMaxSize) << 20
+	c.ListCacheTTL, err = getEnvDuration("LIST_CACHE_TTL", 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// кеш файлов на диске
+	c.DiskCacheDir = os.Getenv("DISK_CACHE_DIR")
+	diskCacheMaxSize, err := getEnvInt("DISK_CACHE_MAX_SIZE", 1024)
+	if err != nil {
+		return err
+	}
+	c.DiskCacheMaxSize = int64(diskCacheMaxSize) << 20
+
+	// общая загрузка файла из MinIO не зависит от отмены запросов, поэтому ограничена своим сроком
+	c.FetchTimeout, err = getEnvPositiveDuration("STORAGE_FETCH_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// Cache-Control для публичных и приватных документов
+	c.PublicMaxAge, err = getEnvDuration("CACHE_PUBLIC_MAX_AGE", time.Hour)
+	if err != nil {
+		return err
+	}
+	c.PrivateMaxAge, err = getEnvDuration("CACHE_PRIVATE_MAX_AGE", 0)
+	if err != nil {
+		return err
+	}
+	c.PrivateNoStore, err = getEnvBool("CACHE_PRIVATE_NO_STORE", false)
+	if err != nil {
+		return err
+	}
+
+	// сброс кеша внешних прокси
+	c.PurgeTargets = getEnvList("PURGE_TARGETS")
+	c.PurgeMethod = os.Getenv("PURGE_METHOD")
+	c.PurgeRetries, err = getEnvInt("PURGE_RETRIES", 3)
+	if err != nil {
+		return err
+	}
+
+	// синхронизация отозванных токенов между экземплярами
+	c.RevocationSyncInterval, err = getEnvPositiveDuration("REVOCATION_SYNC_INTERVAL", 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// удаление файлов удаленных учетных записей из MinIO
+	c.StorageCleanupInterval, err = getEnvPositiveDuration("STORAGE_CLEANUP_INTERVAL", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	// время жизни токенов
+	c.AccessTokenTTL, err = getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+	c.RefreshTokenTTL, err = getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	// параметры argon2id, память в МБ; нули роняют argon2 на каждом входе, лишнее переполняет uint32 и uint8
+	c.PasswordMemory, err = getEnvIntRange("PASSWORD_MEMORY", 64, 1, math.MaxUint32>>10)
+	if err != nil {
+		return err
+	}
+	c.PasswordTime, err = getEnvIntRange("PASSWORD_TIME", 3, 1, math.MaxUint32)
+	if err != nil {
+		return err
+	}
+	c.PasswordThreads, err = getEnvIntRange("PASSWORD_THREADS", 2, 1, math.MaxUint8)
+	if err != nil {
+		return err
+	}
+
+	// защита от перебора паролей
+	c.LockoutStore = os.Getenv("LOCKOUT_STORE")
+	c.LockoutThreshold, err = getEnvInt("LOCKOUT_THRESHOLD", 5)
+	if err != nil {
+		return err
+	}
+	c.LockoutIPThreshold, err = getEnvInt("LOCKOUT_IP_THRESHOLD", 20)
+	if err != nil {
+		return err
+	}
+	c.LockoutBaseDelay, err = getEnvDuration("LOCKOUT_BASE_DELAY", 30*time.Second)
+	if err != nil {
+		return err
+	}
+	c.LockoutMaxDelay, err = getEnvDuration("LOCKOUT_MAX_DELAY", time.Hour)
+	if err != nil {
+		return err
+	}
+	c.LockoutWindow, err = getEnvPositiveDuration("LOCKOUT_WINDOW", time.Hour)
+	if err != nil {
+		return err
+	}
+
+	// ключи подписи токенов, без каталога - HS256 с TOKEN_SALT
+	c.JWTKeysDir = os.Getenv("JWT_KEYS_DIR")
+	c.JWTActiveKID = os.Getenv("JWT_ACTIVE_KID")
+	// старые токены HS256 при переходе на ключи принимаются только до этого момента (RFC 3339)
+	if value := os.Getenv("JWT_LEGACY_UNTIL"); value != "" {
+		c.JWTLegacyUntil, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// getEnvList - читает список значений через запятую
+func getEnvList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// getEnvInt - читает число из окружения, если переменная не задана - возвращает значение по умолчанию
+func getEnvInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getEnvIntRange - как getEnvInt, но значение вне [lo, hi] - ошибка
+func getEnvIntRange(key string, def, lo, hi int) (int, error) {
+	value, err := getEnvInt(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if value < lo || value > hi {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", key, lo, hi, value)
+	}
+	return value, nil
+}
+
+// getEnvBool - читает флаг из окружения, если переменная не задана - возвращает значение по умолчанию
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// getEnvDuration - читает длительность из окружения, если переменная не задана - возвращает значение по умолчанию
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// getEnvPositiveDuration - как getEnvDuration, но для периодов и сроков: ноль и отрицательное значение - ошибка
+func getEnvPositiveDuration(key string, def time.Duration) (time.Duration, error) {
+	value, err := getEnvDuration(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, value)
+	}
+	return value, nil
+}
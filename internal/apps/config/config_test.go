@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -23,3 +25,76 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+// withEnvFile - Parse читает .env из текущего каталога, тестам хватает обязательных полей
+func withEnvFile(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("LOG_LEVEL=\"0\"\nMAX_SIZE_FILE=\"50\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+}
+
+func TestConfig_Parse_PasswordParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "success_defaults", env: map[string]string{}},
+		{name: "success_max_threads", env: map[string]string{"PASSWORD_THREADS": "255"}},
+		{name: "error_zero_time", env: map[string]string{"PASSWORD_TIME": "0"}, wantErr: true},
+		{name: "error_zero_threads", env: map[string]string{"PASSWORD_THREADS": "0"}, wantErr: true},
+		{name: "error_threads_overflow", env: map[string]string{"PASSWORD_THREADS": "256"}, wantErr: true},
+		{name: "error_zero_memory", env: map[string]string{"PASSWORD_MEMORY": "0"}, wantErr: true},
+		{name: "error_negative_memory", env: map[string]string{"PASSWORD_MEMORY": "-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnvFile(t)
+			for _, key := range []string{"PASSWORD_MEMORY", "PASSWORD_TIME", "PASSWORD_THREADS"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			c := New()
+			err := c.Parse()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (c.PasswordMemory < 1 || c.PasswordTime < 1 || c.PasswordThreads < 1) {
+				t.Errorf("Parse() password params = %d, %d, %d", c.PasswordMemory, c.PasswordTime, c.PasswordThreads)
+			}
+		})
+	}
+}
+
+func TestConfig_Parse_Intervals(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{name: "success_lockout_window", key: "LOCKOUT_WINDOW", value: "10m"},
+		{name: "error_zero_lockout_window", key: "LOCKOUT_WINDOW", value: "0", wantErr: true},
+		{name: "error_negative_revocation_sync", key: "REVOCATION_SYNC_INTERVAL", value: "-1s", wantErr: true},
+		{name: "error_zero_storage_cleanup", key: "STORAGE_CLEANUP_INTERVAL", value: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnvFile(t)
+			for _, key := range []string{"LOCKOUT_WINDOW", "REVOCATION_SYNC_INTERVAL", "STORAGE_CLEANUP_INTERVAL"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv(tt.key, tt.value)
+
+			err := New().Parse()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,13 +2,22 @@ package apps
 
 import (
 	"caching_web_server/internal/apps/config"
+	"caching_web_server/internal/cache"
 	handlerAuth "caching_web_server/internal/handler/auth"
 	"caching_web_server/internal/handler/docs/delete"
 	"caching_web_server/internal/handler/docs/get"
+	"caching_web_server/internal/handler/docs/grants"
 	"caching_web_server/internal/handler/docs/post"
+	"caching_web_server/internal/handler/docs/update"
+	"caching_web_server/internal/handler/groups"
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/lockout"
 	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/purge"
 	serviceAuth "caching_web_server/internal/service/auth"
 	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/disk"
 	"caching_web_server/internal/storage/pq"
 	"caching_web_server/internal/storage/s3"
 	"context"
@@ -39,6 +48,9 @@ func (r *Run) Run() error {
 		Level: cfg.LogLevel,
 	}))
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// инициализация репозитория
 	repoPsql, err := pq.NewStorage(log)
 	if err != nil {
@@ -46,6 +58,13 @@ func (r *Run) Run() error {
 		return err
 	}
 
+	// инициализация слушателя изменений от других экземпляров
+	listener, err := pq.NewListener(log)
+	if err != nil {
+		log.Error("Run", "failed to init listener", err)
+		return err
+	}
+
 	// инициализация Minio
 	repoMinio, err := s3.NewMinioStorage(log)
 	if err != nil {
@@ -53,29 +72,125 @@ func (r *Run) Run() error {
 		return err
 	}
 
+	// инициализация кеша файлов на диске
+	var files s3.FileStorage = repoMinio
+	if cfg.DiskCacheDir != "" {
+		diskCache, err := disk.NewCache(repoMinio, cfg.DiskCacheDir, cfg.DiskCacheMaxSize, log)
+		if err != nil {
+			log.Error("Run", "failed to init disk cache", err)
+			return err
+		}
+		files = diskCache
+	}
+
+	// инициализация кеша документов
+	docsCache := cache.NewLRU(cfg.CacheMaxSize, cfg.CacheTTL, models.DocumentContent.Size)
+	listCache := cache.NewLRU(cfg.ListCacheMaxSize, cfg.ListCacheTTL, models.DocsListSize)
+
+	// инициализация сброса кеша внешних прокси
+	purger := purge.NewPurger(cfg.PurgeTargets, cfg.PurgeMethod, cfg.PurgeRetries, log)
+	go purger.Run(ctx)
+
 	// инициализация сервиса
-	service := serviceAuth.NewService(repoPsql, log, cfg.TokenSalt)
-	serviceDocs := docs.NewService(repoPsql, repoMinio, log)
+	keys, err := serviceAuth.LoadKeySet(cfg.JWTKeysDir, cfg.JWTActiveKID, cfg.TokenSalt, cfg.JWTLegacyUntil)
+	if err != nil {
+		log.Error("Run", "failed to load signing keys", err)
+		return err
+	}
+	service := serviceAuth.NewService(repoPsql, log, keys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, serviceAuth.PasswordParams{
+		Memory:  uint32(cfg.PasswordMemory) << 10,
+		Time:    uint32(cfg.PasswordTime),
+		Threads: uint8(cfg.PasswordThreads),
+	})
+	go service.Run(ctx, cfg.RevocationSyncInterval)
+	if cfg.AdminLogin != "" {
+		if err := service.BootstrapAdmin(ctx, cfg.AdminLogin, cfg.AdminPassword); err != nil {
+			log.Error("Run", "failed to bootstrap admin", err)
+			return err
+		}
+	}
+	serviceDocs := docs.NewService(repoPsql, s3.NewCoalescingStorage(files, cfg.FetchTimeout), docsCache, listCache, purger, log)
+
+	listener.Subscribe(serviceDocs.HandleChange)
+	go listener.Run(ctx)
+	go serviceDocs.RunCleanup(ctx, cfg.StorageCleanupInterval)
 
 	// инициализация middleware
 	middlewareAuth := middleware.NewMiddleware(service, log)
 
+	// инициализация защиты от перебора паролей
+	var attempts lockout.Store = lockout.NewMemoryStore()
+	if cfg.LockoutStore == "postgres" {
+		attempts = pq.NewAttemptStore(repoPsql)
+	}
+	limiter := lockout.NewLimiter(attempts, lockout.Policy{
+		Threshold:   cfg.LockoutThreshold,
+		IPThreshold: cfg.LockoutIPThreshold,
+		BaseDelay:   cfg.LockoutBaseDelay,
+		MaxDelay:    cfg.LockoutMaxDelay,
+		Window:      cfg.LockoutWindow,
+	}, log)
+	go limiter.Run(ctx, cfg.LockoutWindow)
+
 	// регистрация ручек
-	handler := handlerAuth.NewHandler(service, log, cfg.AdminToken)
+	handler := handlerAuth.NewHandler(service, log, limiter)
 	handlerPostDocs := post.NewHandler(serviceDocs, log, cfg.MaxSizFile)
-	handlerGetDocs := get.NewHandler(serviceDocs, log)
+	handlerGetDocs := get.NewHandler(serviceDocs, log, helper.CachePolicy{
+		PublicMaxAge:   cfg.PublicMaxAge,
+		PrivateMaxAge:  cfg.PrivateMaxAge,
+		PrivateNoStore: cfg.PrivateNoStore,
+	})
 	handlerDeleteDocs := delete.NewHandler(serviceDocs, log)
+	handlerUpdateDocs := update.NewHandler(serviceDocs, log, cfg.MaxSizFile)
+	handlerGrants := grants.NewHandler(serviceDocs, log)
+	handlerGroups := groups.NewHandler(serviceDocs, log)
 
 	// запуск сервера
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/register", handler.Register)
+	mux.HandleFunc("/api/register", middlewareAuth.Authorize(middlewareAuth.RequireSession(middlewareAuth.RequireRole(handler.Register, models.RoleAdmin))))
 	mux.HandleFunc("/api/auth", handler.Auth)
+	mux.HandleFunc("/api/auth/refresh", handler.Refresh)
+	mux.HandleFunc("/api/auth/2fa", handler.AuthMFA)
+	mux.HandleFunc("/api/auth/unlock", middlewareAuth.Authorize(middlewareAuth.RequireSession(middlewareAuth.RequireRole(handler.Unlock, models.RoleAdmin))))
+	mux.HandleFunc("/api/users/{login}/role", middlewareAuth.Authorize(middlewareAuth.RequireSession(middlewareAuth.RequireRole(handler.SetRole, models.RoleAdmin))))
+	mux.HandleFunc("/api/users/{login}/2fa", middlewareAuth.Authorize(middlewareAuth.RequireSession(middlewareAuth.RequireRole(handler.ResetTOTP, models.RoleAdmin))))
+	mux.HandleFunc("/.well-known/jwks.json", handler.JWKS)
+
+	// API-ключами управляют только после входа по паролю
+	mux.HandleFunc("/api/keys", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.CreateAPIKey))(w, r)
+		case http.MethodGet:
+			middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.ListAPIKeys))(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/keys/{id}", middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.RevokeAPIKey)))
+
+	// своей учетной записью тоже управляют только после входа по паролю
+	mux.HandleFunc("/api/me", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.Me))(w, r)
+		case http.MethodDelete:
+			middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.DeleteAccount))(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/me/password", middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.ChangePassword)))
+	mux.HandleFunc("/api/me/2fa", middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.EnrollTOTP)))
+	mux.HandleFunc("/api/me/2fa/confirm", middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.ConfirmTOTP)))
+
 	mux.HandleFunc("/api/docs", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			middlewareAuth.Authorize(handlerPostDocs.SaveDocument)(w, r)
+			// read-only пользователи документы только читают
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerPostDocs.SaveDocument, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
 		case http.MethodGet:
-			middlewareAuth.Authorize(handlerGetDocs.GetDocuments)(w, r)
+			middlewareAuth.Authorize(middlewareAuth.RequireScope(handlerGetDocs.GetDocuments, models.ScopeRead))(w, r)
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -84,23 +199,60 @@ func (r *Run) Run() error {
 	mux.HandleFunc("/api/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodDelete:
-			middlewareAuth.Authorize(handlerDeleteDocs.DeleteData)(w, r)
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerDeleteDocs.DeleteData, models.ScopeDelete), models.RoleAdmin, models.RoleUser))(w, r)
+		case http.MethodPut:
+			// менять документ могут владелец и пользователи с правом write, id при этом сохраняется
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerUpdateDocs.UpdateDocument, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		case http.MethodPatch:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerUpdateDocs.PatchDocument, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		case http.MethodGet:
+			// публичные документы доступны и без авторизации
+			middlewareAuth.OptionalAuthorize(middlewareAuth.RequireScope(handlerGetDocs.GetDocument, models.ScopeRead))(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// доступом к документу управляют владелец и пользователи с правом manage, документ без доступа отвечает 404
+	mux.HandleFunc("/api/docs/{id}/grants", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			middlewareAuth.Authorize(middlewareAuth.RequireScope(handlerGrants.GetGrants, models.ScopeRead))(w, r)
+		case http.MethodPost:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerGrants.AddGrants, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		case http.MethodDelete:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerGrants.DeleteGrants, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// состав группы меняет только владелец, чужая группа отвечает 404
+	mux.HandleFunc("/api/groups", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
 		case http.MethodGet:
-			middlewareAuth.Authorize(handlerGetDocs.GetDocument)(w, r)
+			middlewareAuth.Authorize(middlewareAuth.RequireScope(handlerGroups.GetGroups, models.ScopeRead))(w, r)
+		case http.MethodPost:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerGroups.CreateGroup, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/groups/{name}/members", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerGroups.AddMembers, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
+		case http.MethodDelete:
+			middlewareAuth.Authorize(middlewareAuth.RequireRole(middlewareAuth.RequireScope(handlerGroups.DeleteMembers, models.ScopeWrite), models.RoleAdmin, models.RoleUser))(w, r)
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
-	mux.HandleFunc("/api/auth/{token}", middlewareAuth.Authorize(handler.Logout))
+	mux.HandleFunc("/api/auth/{token}", middlewareAuth.Authorize(middlewareAuth.RequireSession(handler.Logout)))
 
 	server := &http.Server{
 		Addr:    cfg.Addr,
 		Handler: mux,
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	go func() {
 		log.Info("Server started", "addr", cfg.Addr)
 		if err := server.ListenAndServe(); err != nil {
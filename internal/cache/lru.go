@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU - потокобезопасный LRU-кеш, ограниченный суммарным размером значений в байтах
+type LRU[V any] struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	sizeOf   func(V) int64
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	size    int64
+	expires time.Time
+}
+
+// NewLRU - конструктор
+// maxBytes <= 0 отключает кеш, ttl <= 0 - записи живут до вытеснения
+func NewLRU[V any](maxBytes int64, ttl time.Duration, sizeOf func(V) int64) *LRU[V] {
+	return &LRU[V]{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		sizeOf:   sizeOf,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get - возвращает значение по ключу
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := el.Value.(*entry[V])
+	if c.ttl > 0 && c.now().After(e.expires) {
+		c.removeElement(el)
+		return zero, false
+	}
+
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Set - сохраняет значение, вытесняя самые старые записи при превышении лимита
+func (c *LRU[V]) Set(key string, value V) {
+	size := c.sizeOf(value)
+	if c.maxBytes <= 0 || size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	e := &entry[V]{
+		key:     key,
+		value:   value,
+		size:    size,
+		expires: c.now().Add(c.ttl),
+	}
+	c.items[key] = c.ll.PushFront(e)
+	c.size += size
+
+	for c.size > c.maxBytes {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Delete - удаляет значение по ключу
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeletePrefix - удаляет все значения, ключ которых начинается с prefix
+func (c *LRU[V]) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+}
+
+// Purge - очищает кеш
+func (c *LRU[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.size = 0
+}
+
+// Len - количество записей
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Size - суммарный размер записей в байтах
+func (c *LRU[V]) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+func (c *LRU[V]) removeElement(el *list.Element) {
+	e := el.Value.(*entry[V])
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.size -= e.size
+}
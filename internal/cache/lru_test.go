@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func sizeOfString(s string) int64 {
+	return int64(len(s))
+}
+
+func TestLRU_GetSet(t *testing.T) {
+	c := NewLRU[string](10, 0, sizeOfString)
+
+	c.Set("a", "1234")
+	got, ok := c.Get("a")
+	if !ok || got != "1234" {
+		t.Errorf("Get() = %v, %v, want %v, %v", got, ok, "1234", true)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Get() found missing key")
+	}
+}
+
+func TestLRU_Evict(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  func(c *LRU[string])
+		wantKeys []string
+		wantMiss []string
+		wantSize int64
+	}{
+		{
+			name: "evict_oldest",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("b", "1234")
+				c.Set("c", "1234")
+			},
+			wantKeys: []string{"b", "c"},
+			wantMiss: []string{"a"},
+			wantSize: 8,
+		},
+		{
+			name: "evict_least_recently_used",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("b", "1234")
+				c.Get("a")
+				c.Set("c", "1234")
+			},
+			wantKeys: []string{"a", "c"},
+			wantMiss: []string{"b"},
+			wantSize: 8,
+		},
+		{
+			name: "skip_too_large",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("b", "12345678901")
+			},
+			wantKeys: []string{"a"},
+			wantMiss: []string{"b"},
+			wantSize: 4,
+		},
+		{
+			name: "replace_value",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("a", "12")
+			},
+			wantKeys: []string{"a"},
+			wantSize: 2,
+		},
+		{
+			name: "delete",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("b", "1234")
+				c.Delete("a")
+			},
+			wantKeys: []string{"b"},
+			wantMiss: []string{"a"},
+			wantSize: 4,
+		},
+		{
+			name: "delete_prefix",
+			actions: func(c *LRU[string]) {
+				c.Set("a:1", "12")
+				c.Set("a:2", "12")
+				c.Set("b:1", "12")
+				c.DeletePrefix("a:")
+			},
+			wantKeys: []string{"b:1"},
+			wantMiss: []string{"a:1", "a:2"},
+			wantSize: 2,
+		},
+		{
+			name: "purge",
+			actions: func(c *LRU[string]) {
+				c.Set("a", "1234")
+				c.Set("b", "1234")
+				c.Purge()
+			},
+			wantMiss: []string{"a", "b"},
+			wantSize: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRU[string](10, 0, sizeOfString)
+			tt.actions(c)
+
+			for _, key := range tt.wantKeys {
+				if _, ok := c.Get(key); !ok {
+					t.Errorf("Get(%q) missing", key)
+				}
+			}
+			for _, key := range tt.wantMiss {
+				if _, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) found, want miss", key)
+				}
+			}
+			if c.Size() != tt.wantSize {
+				t.Errorf("Size() = %v, want %v", c.Size(), tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestLRU_TTL(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string](10, time.Minute, sizeOfString)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", "1234")
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("Get() missing before ttl")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get() found after ttl")
+	}
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Errorf("expired entry not removed: len %v, size %v", c.Len(), c.Size())
+	}
+}
+
+func TestLRU_Disabled(t *testing.T) {
+	c := NewLRU[string](0, 0, sizeOfString)
+
+	c.Set("a", "1")
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get() found in disabled cache")
+	}
+}
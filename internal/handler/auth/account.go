@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Me - ручка профиля текущего пользователя
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.log.Error("Me", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("Me", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	account, err := h.service.Account(r.Context(), login)
+	if err != nil {
+		h.log.Error("Me", "failed to get account", err)
+		if errors.Is(err, pq.ErrUserNotFound) {
+			helper.FailResponse(w, http.StatusNotFound, "user not found")
+			return
+		}
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get account")
+		return
+	}
+
+	helper.OkDataResponse(w, account)
+}
+
+// ChangePassword - ручка смены пароля, старые токены отзываются, в ответе новая пара
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("ChangePassword", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("ChangePassword", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	var req struct {
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("ChangePassword", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	// подбор текущего пароля по украденному токену ограничен так же, как вход
+	ip := clientIP(r)
+	if wait := h.limiter.Check(r.Context(), login, ip); wait > 0 {
+		h.log.Error("ChangePassword", "error", "too many attempts", "login", login, "ip", ip)
+		tooManyAttempts(w, wait)
+		return
+	}
+
+	tokens, err := h.service.ChangePassword(r.Context(), login, req.OldPassword, req.NewPassword)
+	if err != nil {
+		h.log.Error("ChangePassword", "failed to change password", err)
+		switch {
+		case errors.Is(err, auth.ErrorCurrentPassword):
+			if wait := h.limiter.Fail(r.Context(), login, ip); wait > 0 {
+				tooManyAttempts(w, wait)
+				return
+			}
+			helper.FailResponse(w, http.StatusForbidden, "invalid current password")
+		case errors.Is(err, auth.ErrorPassword):
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusBadRequest, "invalid new password")
+		case errors.Is(err, pq.ErrUserNotFound):
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusNotFound, "user not found")
+		default:
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to change password")
+		}
+		return
+	}
+	h.limiter.Success(r.Context(), login, ip)
+
+	setTokenCookie(w, tokens)
+
+	helper.OkResponse(w, tokens)
+}
+
+// DeleteAccount - ручка удаления своей учетной записи, требует пароль
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.log.Error("DeleteAccount", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("DeleteAccount", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("DeleteAccount", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	ip := clientIP(r)
+	if wait := h.limiter.Check(r.Context(), login, ip); wait > 0 {
+		h.log.Error("DeleteAccount", "error", "too many attempts", "login", login, "ip", ip)
+		tooManyAttempts(w, wait)
+		return
+	}
+
+	err := h.service.DeleteAccount(r.Context(), login, req.Password)
+	if err != nil {
+		h.log.Error("DeleteAccount", "failed to delete account", err)
+		switch {
+		case errors.Is(err, auth.ErrorCurrentPassword):
+			if wait := h.limiter.Fail(r.Context(), login, ip); wait > 0 {
+				tooManyAttempts(w, wait)
+				return
+			}
+			helper.FailResponse(w, http.StatusForbidden, "invalid password")
+		case errors.Is(err, pq.ErrUserNotFound):
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusNotFound, "user not found")
+		default:
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to delete account")
+		}
+		return
+	}
+	h.limiter.Success(r.Context(), login, ip)
+
+	clearTokenCookie(w)
+
+	h.log.Info("DeleteAccount", "status", "account deleted")
+	helper.OkResponse(w, map[string]string{"message": "account deleted"})
+}
@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"bytes"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestHandler_Me(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_me",
+			method: http.MethodGet,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().Account(gomock.Any(), "test").Return(models.Account{Login: "test", Role: models.RoleUser}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodPost,
+			login:    "test",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_login",
+			method:   http.MethodGet,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "err_not_found",
+			method: http.MethodGet,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().Account(gomock.Any(), "test").Return(models.Account{}, pq.ErrUserNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "err_account",
+			method: http.MethodGet,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().Account(gomock.Any(), "test").Return(models.Account{}, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/me", nil)
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			h.Me(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.Me() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name       string
+		method     string
+		login      string
+		body       string
+		mockUp     func(m *Mockservice, l *Mocklimiter)
+		wantCode   int
+		wantCookie bool
+	}{
+		{
+			name:   "success_change_password",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"old","new_password":"NewDocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().ChangePassword(gomock.Any(), "test", "old", "NewDocumenT1@").
+					Return(models.Tokens{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil)
+				l.EXPECT().Success(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode:   http.StatusOK,
+			wantCookie: true,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			login:    "test",
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_login",
+			method:   http.MethodPost,
+			body:     `{}`,
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			login:    "test",
+			body:     `{`,
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_current_password",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"wrong","new_password":"NewDocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().ChangePassword(gomock.Any(), "test", "wrong", "NewDocumenT1@").
+					Return(models.Tokens{}, auth.ErrorCurrentPassword)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "err_locked",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"wrong","new_password":"NewDocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_current_password_locks",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"wrong","new_password":"NewDocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().ChangePassword(gomock.Any(), "test", "wrong", "NewDocumenT1@").
+					Return(models.Tokens{}, auth.ErrorCurrentPassword)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_new_password",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"old","new_password":"weak"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().ChangePassword(gomock.Any(), "test", "old", "weak").
+					Return(models.Tokens{}, auth.ErrorPassword)
+				l.EXPECT().Release(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_change_password",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"old_password":"old","new_password":"NewDocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().ChangePassword(gomock.Any(), "test", "old", "NewDocumenT1@").
+					Return(models.Tokens{}, fmt.Errorf("storage error"))
+				l.EXPECT().Release(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			mockLimiter := NewMocklimiter(ctrl)
+			tt.mockUp(mockService, mockLimiter)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+				limiter: mockLimiter,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/me/password", bytes.NewBufferString(tt.body))
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			h.ChangePassword(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.ChangePassword() = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := len(w.Result().Cookies()) > 0; got != tt.wantCookie {
+				t.Errorf("Handler.ChangePassword() cookie set = %v, want %v", got, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteAccount(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		body     string
+		mockUp   func(m *Mockservice, l *Mocklimiter)
+		wantCode int
+	}{
+		{
+			name:   "success_delete_account",
+			method: http.MethodDelete,
+			login:  "test",
+			body:   `{"password":"DocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().DeleteAccount(gomock.Any(), "test", "DocumenT1@").Return(nil)
+				l.EXPECT().Success(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodPost,
+			login:    "test",
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_login",
+			method:   http.MethodDelete,
+			body:     `{}`,
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodDelete,
+			login:    "test",
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_password",
+			method: http.MethodDelete,
+			login:  "test",
+			body:   `{"password":"wrong"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().DeleteAccount(gomock.Any(), "test", "wrong").Return(auth.ErrorCurrentPassword)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "err_password_locks",
+			method: http.MethodDelete,
+			login:  "test",
+			body:   `{"password":"wrong"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().DeleteAccount(gomock.Any(), "test", "wrong").Return(auth.ErrorCurrentPassword)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_locked",
+			method: http.MethodDelete,
+			login:  "test",
+			body:   `{"password":"DocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_delete_account",
+			method: http.MethodDelete,
+			login:  "test",
+			body:   `{"password":"DocumenT1@"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().DeleteAccount(gomock.Any(), "test", "DocumenT1@").Return(fmt.Errorf("storage error"))
+				l.EXPECT().Release(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			mockLimiter := NewMocklimiter(ctrl)
+			tt.mockUp(mockService, mockLimiter)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+				limiter: mockLimiter,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/me", bytes.NewBufferString(tt.body))
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			h.DeleteAccount(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.DeleteAccount() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey - ручка создания API-ключа, сам ключ возвращается только в этом ответе
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("CreateAPIKey", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("CreateAPIKey", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+	role, _ := r.Context().Value(middleware.NameRole).(string)
+
+	var req struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("CreateAPIKey", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(r.Context(), models.Identity{Login: login, Role: role}, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		h.log.Error("CreateAPIKey", "failed to create api key", err)
+		switch {
+		case errors.Is(err, auth.ErrorAPIKey), errors.Is(err, auth.ErrorScope):
+			helper.FailResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, pq.ErrAPIKeyExists):
+			helper.FailResponse(w, http.StatusConflict, "api key with this name already exists")
+		default:
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to create api key")
+		}
+		return
+	}
+
+	helper.OkDataResponse(w, key)
+}
+
+// ListAPIKeys - ручка списка API-ключей пользователя
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.log.Error("ListAPIKeys", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("ListAPIKeys", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(r.Context(), login)
+	if err != nil {
+		h.log.Error("ListAPIKeys", "failed to get api keys", err)
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get api keys")
+		return
+	}
+
+	helper.OkDataResponse(w, keys)
+}
+
+// RevokeAPIKey - ручка отзыва API-ключа: /api/keys/{id}
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.log.Error("RevokeAPIKey", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("RevokeAPIKey", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.log.Error("RevokeAPIKey", "failed to parse api key id", err)
+		helper.FailResponse(w, http.StatusBadRequest, "invalid api key id")
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), login, id); err != nil {
+		h.log.Error("RevokeAPIKey", "failed to revoke api key", err)
+		if errors.Is(err, pq.ErrAPIKeyNotFound) {
+			helper.FailResponse(w, http.StatusNotFound, "api key not found")
+			return
+		}
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to revoke api key")
+		return
+	}
+
+	helper.OkResponse(w, map[string]any{"id": id})
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"bytes"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func TestHandler_CreateAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	owner := models.Identity{Login: "test", Role: models.RoleUser}
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		body     string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_create",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"name":"ci","scopes":["read","write"],"expires_at":"2030-01-01T00:00:00Z"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().CreateAPIKey(gomock.Any(), owner, "ci", []string{"read", "write"}, gomock.Not(gomock.Nil())).
+					Return(models.APIKey{Name: "ci", Key: auth.APIKeyPrefix + "secret"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			login:    "test",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_login",
+			method:   http.MethodPost,
+			body:     `{"name":"ci"}`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			login:    "test",
+			body:     `{`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_scope",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"name":"ci","scopes":["admin"]}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().CreateAPIKey(gomock.Any(), owner, "ci", []string{"admin"}, gomock.Nil()).
+					Return(models.APIKey{}, fmt.Errorf("%w: admin", auth.ErrorScope))
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_exists",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"name":"ci","scopes":["read"]}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().CreateAPIKey(gomock.Any(), owner, "ci", []string{"read"}, gomock.Nil()).
+					Return(models.APIKey{}, pq.ErrAPIKeyExists)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "err_create",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"name":"ci","scopes":["read"]}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().CreateAPIKey(gomock.Any(), owner, "ci", []string{"read"}, gomock.Nil()).
+					Return(models.APIKey{}, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/keys", bytes.NewBufferString(tt.body))
+			if tt.login != "" {
+				ctx := context.WithValue(r.Context(), middleware.NameLogin, tt.login)
+				ctx = context.WithValue(ctx, middleware.NameRole, models.RoleUser)
+				r = r.WithContext(ctx)
+			}
+			h.CreateAPIKey(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.CreateAPIKey() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_ListAPIKeys(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_list",
+			method: http.MethodGet,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ListAPIKeys(gomock.Any(), "test").Return([]models.APIKey{{Name: "ci"}}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodPost,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "err_list",
+			method: http.MethodGet,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ListAPIKeys(gomock.Any(), "test").Return(nil, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/keys", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+			h.ListAPIKeys(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.ListAPIKeys() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_RevokeAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		method   string
+		id       string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_revoke",
+			method: http.MethodDelete,
+			id:     id.String(),
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RevokeAPIKey(gomock.Any(), "test", id).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			id:       id.String(),
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_id",
+			method:   http.MethodDelete,
+			id:       "bad",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_not_found",
+			method: http.MethodDelete,
+			id:     id.String(),
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RevokeAPIKey(gomock.Any(), "test", id).Return(pq.ErrAPIKeyNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "err_revoke",
+			method: http.MethodDelete,
+			id:     id.String(),
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RevokeAPIKey(gomock.Any(), "test", id).Return(fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/keys/"+tt.id, nil)
+			r.SetPathValue("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+			h.RevokeAPIKey(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.RevokeAPIKey() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -2,36 +2,70 @@ package auth
 
 import (
 	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
 	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 //go:generate mockgen -source=handler.go -destination=handler_mock.go -package=auth
 type service interface {
-	RegisterUser(ctx context.Context, login, password string) error
-	AuthUser(ctx context.Context, login, password string) (string, error)
+	RegisterUser(ctx context.Context, login, password, role string) error
+	AuthUser(ctx context.Context, login, password string) (models.Tokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (models.Tokens, error)
+	RevokeToken(ctx context.Context, login, token string) error
+	RevokeRefreshToken(ctx context.Context, login, refreshToken string) error
+	SetRole(ctx context.Context, login, role string) error
+	CreateAPIKey(ctx context.Context, owner models.Identity, name string, scopes []string, expiresAt *time.Time) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context, login string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, login string, id uuid.UUID) error
+	JWKS() auth.JWKS
+	Account(ctx context.Context, login string) (models.Account, error)
+	ChangePassword(ctx context.Context, login, currentPassword, newPassword string) (models.Tokens, error)
+	DeleteAccount(ctx context.Context, login, password string) error
+	MFALogin(mfaToken string) (string, error)
+	AuthMFA(ctx context.Context, mfaToken, code string) (models.Tokens, error)
+	EnrollTOTP(ctx context.Context, login string) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, login, code string) ([]string, error)
+	ResetTOTP(ctx context.Context, login string) error
+}
+
+type limiter interface {
+	Check(ctx context.Context, login, ip string) time.Duration
+	Fail(ctx context.Context, login, ip string) time.Duration
+	Success(ctx context.Context, login, ip string)
+	Release(ctx context.Context, login, ip string)
+	Unlock(ctx context.Context, login, ip string) error
 }
 
 type Handler struct {
-	service    service
-	log        *slog.Logger
-	adminToken string
+	service service
+	log     *slog.Logger
+	limiter limiter
 }
 
 // NewHandler - конструктор
-func NewHandler(service service, log *slog.Logger, adminToken string) *Handler {
+func NewHandler(service service, log *slog.Logger, limiter limiter) *Handler {
 	return &Handler{
-		service:    service,
-		log:        log,
-		adminToken: adminToken,
+		service: service,
+		log:     log,
+		limiter: limiter,
 	}
 }
 
-// Register - ручка регистрации пользователя
+// Register - ручка регистрации пользователя, доступна администратору
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.log.Error("Register", "error", "invalid method")
@@ -40,9 +74,9 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Token    string `json:"token"`
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		Role     string `json:"role"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,15 +85,13 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Token != h.adminToken {
-		h.log.Error("Register", "error", "failed to invalid token")
-		helper.FailResponse(w, http.StatusUnauthorized, "invalid token")
-		return
-	}
-
-	err := h.service.RegisterUser(r.Context(), req.Login, req.Password)
+	err := h.service.RegisterUser(r.Context(), req.Login, req.Password, req.Role)
 	if err != nil {
 		h.log.Error("Register", "failed to register user", err)
+		if errors.Is(err, auth.ErrorLogin) || errors.Is(err, auth.ErrorPassword) || errors.Is(err, auth.ErrorRole) {
+			helper.FailResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helper.FailResponse(w, http.StatusInternalServerError, "failed to register user")
 		return
 	}
@@ -92,55 +124,293 @@ func (h *Handler) Auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.AuthUser(r.Context(), req.Login, req.Password)
+	ip := clientIP(r)
+	if wait := h.limiter.Check(r.Context(), req.Login, ip); wait > 0 {
+		h.log.Error("Auth", "error", "too many attempts", "login", req.Login, "ip", ip)
+		tooManyAttempts(w, wait)
+		return
+	}
+
+	tokens, err := h.service.AuthUser(r.Context(), req.Login, req.Password)
 	if err != nil {
 		h.log.Error("Auth", "error", err)
+		if errors.Is(err, auth.ErrorPassword) {
+			if wait := h.limiter.Fail(r.Context(), req.Login, ip); wait > 0 {
+				tooManyAttempts(w, wait)
+				return
+			}
+			helper.FailResponse(w, http.StatusUnauthorized, "invalid login or password")
+			return
+		}
+		h.limiter.Release(r.Context(), req.Login, ip)
 		helper.FailResponse(w, http.StatusInternalServerError, "failed to auth user")
 		return
 	}
 
-	setTokenCookie(w, token)
+	// пароль верный, но вход завершится только после кода: попытку не засчитываем, но и счетчик неудач не сбрасываем
+	if tokens.MFAToken != "" {
+		h.limiter.Release(r.Context(), req.Login, ip)
+		h.log.Info("Auth", "status", "two-factor code required", "login", req.Login)
+		helper.OkResponse(w, tokens)
+		return
+	}
+	h.limiter.Success(r.Context(), req.Login, ip)
+
+	setTokenCookie(w, tokens)
+
+	helper.OkResponse(w, tokens)
 
-	helper.OkResponse(w, map[string]any{auth.NameCookie: token})
+}
+
+// Unlock - ручка снятия блокировки входа с логина и адреса, доступна администратору
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("Unlock", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	var req struct {
+		Login string `json:"login"`
+		IP    string `json:"ip"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Unlock", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	if req.Login == "" && req.IP == "" {
+		h.log.Error("Unlock", "error", "empty login and ip")
+		helper.FailResponse(w, http.StatusBadRequest, "empty login and ip")
+		return
+	}
+
+	if err := h.limiter.Unlock(r.Context(), req.Login, req.IP); err != nil {
+		h.log.Error("Unlock", "failed to unlock", err)
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to unlock")
+		return
+	}
 
+	helper.OkResponse(w, map[string]any{"login": req.Login, "ip": req.IP})
 }
 
-func setTokenCookie(w http.ResponseWriter, token string) {
+// SetRole - ручка смены роли пользователя, доступна администратору
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.log.Error("SetRole", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	// /api/users/{login}/role
+	login := r.PathValue("login")
+	if login == "" {
+		h.log.Error("SetRole", "error", "empty login")
+		helper.FailResponse(w, http.StatusBadRequest, "empty login")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("SetRole", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	if err := h.service.SetRole(r.Context(), login, req.Role); err != nil {
+		h.log.Error("SetRole", "failed to set role", err)
+		switch {
+		case errors.Is(err, auth.ErrorRole):
+			helper.FailResponse(w, http.StatusBadRequest, "invalid role")
+		case errors.Is(err, pq.ErrUserNotFound):
+			helper.FailResponse(w, http.StatusNotFound, "user not found")
+		default:
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to set role")
+		}
+		return
+	}
+
+	helper.OkResponse(w, map[string]any{"login": login, "role": req.Role})
+}
+
+// tooManyAttempts - 429 с Retry-After в целых секундах
+func tooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	helper.FailResponse(w, http.StatusTooManyRequests, "too many attempts")
+}
+
+// clientIP - адрес клиента по соединению, X-Forwarded-For не доверяем: его подделывает сам клиент
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Refresh - ручка обмена refresh-токена на новую пару токенов
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("Refresh", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Refresh", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	tokens, err := h.service.RefreshTokens(r.Context(), req.RefreshToken)
+	if err != nil {
+		h.log.Error("Refresh", "failed to refresh tokens", err)
+		if errors.Is(err, auth.ErrorRefreshToken) {
+			helper.FailResponse(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to refresh tokens")
+		return
+	}
+
+	setTokenCookie(w, tokens)
+
+	helper.OkResponse(w, tokens)
+}
+
+// setTokenCookie - cookie живет столько же, сколько access-токен
+func setTokenCookie(w http.ResponseWriter, tokens models.Tokens) {
 	cookie := &http.Cookie{
 		Name:     auth.NameCookie,
-		Value:    token,
+		Value:    tokens.AccessToken,
 		Path:     "/",
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
-		Expires:  time.Now().Add(24 * time.Hour),
+		Expires:  time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second),
 	}
 	http.SetCookie(w, cookie)
 }
 
-// Logout - ручка выхода
+// clearTokenCookie - удаляет cookie с токеном
+// клиенты с заголовком Bearer cookie не держат, лишний Set-Cookie им не мешает
+func clearTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.NameCookie,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Logout - ручка выхода, в теле можно передать {"refresh_token"}: его семья отзывается и сессию больше не обновить
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		h.log.Error("Logout", "error", "invalid method")
 		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
 		return
 	}
-	cookie, err := r.Cookie(auth.NameCookie)
+	current, err := middleware.Token(r)
 	if err != nil {
-		h.log.Error("Logout", "error", "missing or invalid Authorization header")
+		h.log.Error("Logout", "error", err.Error())
 		helper.FailResponse(w, http.StatusUnauthorized, "missing or invalid Authorization header")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     cookie.Name,
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0),
-		MaxAge:   -1,
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-	})
+	login, ok := r.Context().Value("login").(string)
+	if !ok {
+		h.log.Error("Logout", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	// /api/auth/{token}, без токена в пути отзываем текущий
+	token := r.PathValue("token")
+	if token == "" {
+		token = current
+	}
+
+	// тело необязательное, клиенты без refresh-токена шлют пустой DELETE
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error("Logout", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	if req.RefreshToken != "" {
+		err = h.service.RevokeRefreshToken(r.Context(), login, req.RefreshToken)
+		if err != nil {
+			h.log.Error("Logout", "failed to revoke refresh token", err)
+			if errors.Is(err, auth.ErrorRefreshToken) {
+				helper.FailResponse(w, http.StatusBadRequest, "invalid refresh token")
+				return
+			}
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to revoke refresh token")
+			return
+		}
+	}
+
+	err = h.service.RevokeToken(r.Context(), login, token)
+	if err != nil {
+		h.log.Error("Logout", "failed to revoke token", err)
+		switch {
+		case errors.Is(err, auth.ErrorForbidden):
+			helper.FailResponse(w, http.StatusForbidden, "token belongs to another user")
+		case errors.Is(err, auth.ErrorToken):
+			helper.FailResponse(w, http.StatusBadRequest, "invalid token")
+		default:
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to revoke token")
+		}
+		return
+	}
+
+	// отозван другой токен пользователя, текущая сессия остается
+	if token != current {
+		h.log.Info("Logout", "status", "token revoked")
+		helper.OkResponse(w, map[string]string{"message": "token revoked"})
+		return
+	}
+
+	clearTokenCookie(w)
 
 	h.log.Info("Logout", "status", "cookie deleted")
 	helper.OkResponse(w, map[string]string{"message": "logged out"})
 }
+
+// JWKS - ручка с открытыми ключами для проверки токенов другими сервисами
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.log.Error("JWKS", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	data, err := json.Marshal(h.service.JWKS())
+	if err != nil {
+		h.log.Error("JWKS", "failed to encode keys", err)
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to encode keys")
+		return
+	}
+
+	// набор меняется только при ротации, проверяющим можно держать его в кеше
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		h.log.Error("JWKS", "failed to write keys", err)
+	}
+}
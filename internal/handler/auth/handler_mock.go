@@ -5,10 +5,14 @@
 package auth
 
 import (
+	models "caching_web_server/internal/models"
+	auth "caching_web_server/internal/service/auth"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // Mockservice is a mock of service interface.
@@ -34,11 +38,41 @@ func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
 	return m.recorder
 }
 
+// Account mocks base method.
+func (m *Mockservice) Account(ctx context.Context, login string) (models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Account", ctx, login)
+	ret0, _ := ret[0].(models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Account indicates an expected call of Account.
+func (mr *MockserviceMockRecorder) Account(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Account", reflect.TypeOf((*Mockservice)(nil).Account), ctx, login)
+}
+
+// AuthMFA mocks base method.
+func (m *Mockservice) AuthMFA(ctx context.Context, mfaToken, code string) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthMFA", ctx, mfaToken, code)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthMFA indicates an expected call of AuthMFA.
+func (mr *MockserviceMockRecorder) AuthMFA(ctx, mfaToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthMFA", reflect.TypeOf((*Mockservice)(nil).AuthMFA), ctx, mfaToken, code)
+}
+
 // AuthUser mocks base method.
-func (m *Mockservice) AuthUser(ctx context.Context, login, password string) (string, error) {
+func (m *Mockservice) AuthUser(ctx context.Context, login, password string) (models.Tokens, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "AuthUser", ctx, login, password)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(models.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -49,16 +83,308 @@ func (mr *MockserviceMockRecorder) AuthUser(ctx, login, password interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthUser", reflect.TypeOf((*Mockservice)(nil).AuthUser), ctx, login, password)
 }
 
+// ChangePassword mocks base method.
+func (m *Mockservice) ChangePassword(ctx context.Context, login, currentPassword, newPassword string) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, login, currentPassword, newPassword)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockserviceMockRecorder) ChangePassword(ctx, login, currentPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*Mockservice)(nil).ChangePassword), ctx, login, currentPassword, newPassword)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *Mockservice) ConfirmTOTP(ctx context.Context, login, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, login, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockserviceMockRecorder) ConfirmTOTP(ctx, login, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*Mockservice)(nil).ConfirmTOTP), ctx, login, code)
+}
+
+// CreateAPIKey mocks base method.
+func (m *Mockservice) CreateAPIKey(ctx context.Context, owner models.Identity, name string, scopes []string, expiresAt *time.Time) (models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, owner, name, scopes, expiresAt)
+	ret0, _ := ret[0].(models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockserviceMockRecorder) CreateAPIKey(ctx, owner, name, scopes, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*Mockservice)(nil).CreateAPIKey), ctx, owner, name, scopes, expiresAt)
+}
+
+// DeleteAccount mocks base method.
+func (m *Mockservice) DeleteAccount(ctx context.Context, login, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, login, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockserviceMockRecorder) DeleteAccount(ctx, login, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*Mockservice)(nil).DeleteAccount), ctx, login, password)
+}
+
+// EnrollTOTP mocks base method.
+func (m *Mockservice) EnrollTOTP(ctx context.Context, login string) (models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", ctx, login)
+	ret0, _ := ret[0].(models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockserviceMockRecorder) EnrollTOTP(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*Mockservice)(nil).EnrollTOTP), ctx, login)
+}
+
+// JWKS mocks base method.
+func (m *Mockservice) JWKS() auth.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(auth.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockserviceMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*Mockservice)(nil).JWKS))
+}
+
+// ListAPIKeys mocks base method.
+func (m *Mockservice) ListAPIKeys(ctx context.Context, login string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, login)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockserviceMockRecorder) ListAPIKeys(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*Mockservice)(nil).ListAPIKeys), ctx, login)
+}
+
+// MFALogin mocks base method.
+func (m *Mockservice) MFALogin(mfaToken string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MFALogin", mfaToken)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MFALogin indicates an expected call of MFALogin.
+func (mr *MockserviceMockRecorder) MFALogin(mfaToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MFALogin", reflect.TypeOf((*Mockservice)(nil).MFALogin), mfaToken)
+}
+
+// RefreshTokens mocks base method.
+func (m *Mockservice) RefreshTokens(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, refreshToken)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockserviceMockRecorder) RefreshTokens(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*Mockservice)(nil).RefreshTokens), ctx, refreshToken)
+}
+
 // RegisterUser mocks base method.
-func (m *Mockservice) RegisterUser(ctx context.Context, login, password string) error {
+func (m *Mockservice) RegisterUser(ctx context.Context, login, password, role string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterUser", ctx, login, password)
+	ret := m.ctrl.Call(m, "RegisterUser", ctx, login, password, role)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RegisterUser indicates an expected call of RegisterUser.
-func (mr *MockserviceMockRecorder) RegisterUser(ctx, login, password interface{}) *gomock.Call {
+func (mr *MockserviceMockRecorder) RegisterUser(ctx, login, password, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*Mockservice)(nil).RegisterUser), ctx, login, password, role)
+}
+
+// ResetTOTP mocks base method.
+func (m *Mockservice) ResetTOTP(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTOTP", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTOTP indicates an expected call of ResetTOTP.
+func (mr *MockserviceMockRecorder) ResetTOTP(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTOTP", reflect.TypeOf((*Mockservice)(nil).ResetTOTP), ctx, login)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *Mockservice) RevokeAPIKey(ctx context.Context, login string, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, login, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockserviceMockRecorder) RevokeAPIKey(ctx, login, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*Mockservice)(nil).RevokeAPIKey), ctx, login, id)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *Mockservice) RevokeRefreshToken(ctx context.Context, login, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, login, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockserviceMockRecorder) RevokeRefreshToken(ctx, login, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*Mockservice)(nil).RevokeRefreshToken), ctx, login, refreshToken)
+}
+
+// RevokeToken mocks base method.
+func (m *Mockservice) RevokeToken(ctx context.Context, login, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, login, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockserviceMockRecorder) RevokeToken(ctx, login, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*Mockservice)(nil).RevokeToken), ctx, login, token)
+}
+
+// SetRole mocks base method.
+func (m *Mockservice) SetRole(ctx context.Context, login, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, login, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockserviceMockRecorder) SetRole(ctx, login, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*Mockservice)(nil).SetRole), ctx, login, role)
+}
+
+// Mocklimiter is a mock of limiter interface.
+type Mocklimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MocklimiterMockRecorder
+}
+
+// MocklimiterMockRecorder is the mock recorder for Mocklimiter.
+type MocklimiterMockRecorder struct {
+	mock *Mocklimiter
+}
+
+// NewMocklimiter creates a new mock instance.
+func NewMocklimiter(ctrl *gomock.Controller) *Mocklimiter {
+	mock := &Mocklimiter{ctrl: ctrl}
+	mock.recorder = &MocklimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocklimiter) EXPECT() *MocklimiterMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *Mocklimiter) Check(ctx context.Context, login, ip string) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, login, ip)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MocklimiterMockRecorder) Check(ctx, login, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*Mocklimiter)(nil).Check), ctx, login, ip)
+}
+
+// Fail mocks base method.
+func (m *Mocklimiter) Fail(ctx context.Context, login, ip string) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, login, ip)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MocklimiterMockRecorder) Fail(ctx, login, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*Mocklimiter)(nil).Fail), ctx, login, ip)
+}
+
+// Release mocks base method.
+func (m *Mocklimiter) Release(ctx context.Context, login, ip string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release", ctx, login, ip)
+}
+
+// Release indicates an expected call of Release.
+func (mr *MocklimiterMockRecorder) Release(ctx, login, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*Mocklimiter)(nil).Release), ctx, login, ip)
+}
+
+// Success mocks base method.
+func (m *Mocklimiter) Success(ctx context.Context, login, ip string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Success", ctx, login, ip)
+}
+
+// Success indicates an expected call of Success.
+func (mr *MocklimiterMockRecorder) Success(ctx, login, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Success", reflect.TypeOf((*Mocklimiter)(nil).Success), ctx, login, ip)
+}
+
+// Unlock mocks base method.
+func (m *Mocklimiter) Unlock(ctx context.Context, login, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, login, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MocklimiterMockRecorder) Unlock(ctx, login, ip interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*Mockservice)(nil).RegisterUser), ctx, login, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*Mocklimiter)(nil).Unlock), ctx, login, ip)
 }
@@ -3,7 +3,9 @@ package auth
 import (
 	"bytes"
 	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
 	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,7 +15,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 )
@@ -25,10 +29,11 @@ func TestNewHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := NewMockservice(ctrl)
+	mockLimiter := NewMocklimiter(ctrl)
 	type args struct {
-		service    service
-		log        *slog.Logger
-		adminToken string
+		service service
+		log     *slog.Logger
+		limiter limiter
 	}
 	tests := []struct {
 		name string
@@ -38,20 +43,20 @@ func TestNewHandler(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				service:    mockService,
-				log:        log,
-				adminToken: "",
+				service: mockService,
+				log:     log,
+				limiter: mockLimiter,
 			},
 			want: &Handler{
-				service:    mockService,
-				log:        log,
-				adminToken: "",
+				service: mockService,
+				log:     log,
+				limiter: mockLimiter,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewHandler(tt.args.service, tt.args.log, tt.args.adminToken); !reflect.DeepEqual(got, tt.want) {
+			if got := NewHandler(tt.args.service, tt.args.log, tt.args.limiter); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewHandler() = %v, want %v", got, tt.want)
 			}
 		})
@@ -62,15 +67,14 @@ func TestHandler_Register(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
 
 	type body struct {
-		Token    string `json:"token"`
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		Role     string `json:"role"`
 	}
 
 	tests := []struct {
 		name              string
 		method            string
-		token             string
 		body              body
 		flagBody          bool
 		errorRegisterUser error
@@ -79,9 +83,7 @@ func TestHandler_Register(t *testing.T) {
 		{
 			name:   "success_register",
 			method: http.MethodPost,
-			token:  "test",
 			body: body{
-				Token:    "test",
 				Login:    "test",
 				Password: "test",
 			},
@@ -91,9 +93,7 @@ func TestHandler_Register(t *testing.T) {
 		{
 			name:   "err_method",
 			method: http.MethodGet,
-			token:  "test",
 			body: body{
-				Token:    "test",
 				Login:    "test",
 				Password: "test",
 			},
@@ -110,9 +110,7 @@ func TestHandler_Register(t *testing.T) {
 		{
 			name:   "err_register_user",
 			method: http.MethodPost,
-			token:  "test",
 			body: body{
-				Token:    "test",
 				Login:    "test",
 				Password: "test",
 			},
@@ -120,16 +118,15 @@ func TestHandler_Register(t *testing.T) {
 			wantCode:          http.StatusInternalServerError,
 		},
 		{
-			name:   "err_invalid_token",
+			name:   "err_invalid_role",
 			method: http.MethodPost,
-			token:  "test",
 			body: body{
-				Token:    "tes",
 				Login:    "test",
 				Password: "test",
+				Role:     "root",
 			},
-			errorRegisterUser: nil,
-			wantCode:          http.StatusUnauthorized,
+			errorRegisterUser: auth.ErrorRole,
+			wantCode:          http.StatusBadRequest,
 		},
 	}
 
@@ -142,12 +139,11 @@ func TestHandler_Register(t *testing.T) {
 			mockService := NewMockservice(ctrl)
 
 			h := &Handler{
-				service:    mockService,
-				log:        log,
-				adminToken: tt.token,
+				service: mockService,
+				log:     log,
 			}
 
-			mockService.EXPECT().RegisterUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.errorRegisterUser).AnyTimes()
+			mockService.EXPECT().RegisterUser(gomock.Any(), gomock.Any(), gomock.Any(), tt.body.Role).Return(tt.errorRegisterUser).AnyTimes()
 
 			w := httptest.NewRecorder()
 
@@ -187,7 +183,10 @@ func TestHandler_Auth(t *testing.T) {
 		body          body
 		flagBody      bool
 		errorAuthUser error
+		checkWait     time.Duration
+		failWait      time.Duration
 		wantCode      int
+		retryAfter    string
 	}{
 		{
 			name:   "success_register",
@@ -239,6 +238,39 @@ func TestHandler_Auth(t *testing.T) {
 			errorAuthUser: fmt.Errorf("test"),
 			wantCode:      http.StatusInternalServerError,
 		},
+		{
+			name:   "err_wrong_password",
+			method: http.MethodPost,
+			body: body{
+				Login:    "test",
+				Password: "test",
+			},
+			errorAuthUser: auth.ErrorPassword,
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:   "err_wrong_password_locks",
+			method: http.MethodPost,
+			body: body{
+				Login:    "test",
+				Password: "test",
+			},
+			errorAuthUser: auth.ErrorPassword,
+			failWait:      time.Minute,
+			wantCode:      http.StatusTooManyRequests,
+			retryAfter:    "60",
+		},
+		{
+			name:   "err_locked",
+			method: http.MethodPost,
+			body: body{
+				Login:    "test",
+				Password: "test",
+			},
+			checkWait:  1500 * time.Millisecond,
+			wantCode:   http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,13 +280,19 @@ func TestHandler_Auth(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockService := NewMockservice(ctrl)
+			mockLimiter := NewMocklimiter(ctrl)
+			mockLimiter.EXPECT().Check(gomock.Any(), gomock.Any(), "192.0.2.1").Return(tt.checkWait).AnyTimes()
+			mockLimiter.EXPECT().Fail(gomock.Any(), gomock.Any(), "192.0.2.1").Return(tt.failWait).AnyTimes()
+			mockLimiter.EXPECT().Success(gomock.Any(), gomock.Any(), "192.0.2.1").AnyTimes()
+			mockLimiter.EXPECT().Release(gomock.Any(), gomock.Any(), "192.0.2.1").AnyTimes()
 
 			h := &Handler{
 				service: mockService,
 				log:     log,
+				limiter: mockLimiter,
 			}
 
-			mockService.EXPECT().AuthUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.token, tt.errorAuthUser).AnyTimes()
+			mockService.EXPECT().AuthUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(models.Tokens{AccessToken: tt.token, RefreshToken: "refresh", ExpiresIn: 900}, tt.errorAuthUser).AnyTimes()
 
 			w := httptest.NewRecorder()
 
@@ -274,11 +312,290 @@ func TestHandler_Auth(t *testing.T) {
 			if w.Code != tt.wantCode {
 				t.Errorf("Handler.Register() = %v, want %v", w.Code, tt.wantCode)
 			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Handler.Auth() Retry-After = %v, want %v", got, tt.retryAfter)
+			}
 
 		})
 	}
 }
 
+func TestHandler_Unlock(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		mockUp   func(m *Mocklimiter)
+		wantCode int
+	}{
+		{
+			name:   "success_unlock",
+			method: http.MethodPost,
+			body:   `{"login":"user"}`,
+			mockUp: func(m *Mocklimiter) {
+				m.EXPECT().Unlock(gomock.Any(), "user", "").Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "success_unlock_ip",
+			method: http.MethodPost,
+			body:   `{"ip":"10.0.0.1"}`,
+			mockUp: func(m *Mocklimiter) {
+				m.EXPECT().Unlock(gomock.Any(), "", "10.0.0.1").Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			body:     `{"login":"user"}`,
+			mockUp:   func(m *Mocklimiter) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			body:     `{`,
+			mockUp:   func(m *Mocklimiter) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "err_empty_login_and_ip",
+			method:   http.MethodPost,
+			body:     `{}`,
+			mockUp:   func(m *Mocklimiter) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_unlock",
+			method: http.MethodPost,
+			body:   `{"login":"user"}`,
+			mockUp: func(m *Mocklimiter) {
+				m.EXPECT().Unlock(gomock.Any(), "user", "").Return(fmt.Errorf("store error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLimiter := NewMocklimiter(ctrl)
+			tt.mockUp(mockLimiter)
+
+			h := &Handler{
+				service: NewMockservice(ctrl),
+				log:     log,
+				limiter: mockLimiter,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/auth/unlock", bytes.NewBufferString(tt.body))
+			h.Unlock(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.Unlock() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_SetRole(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		body     string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_set_role",
+			method: http.MethodPut,
+			login:  "user",
+			body:   `{"role":"read-only"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().SetRole(gomock.Any(), "user", "read-only").Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			login:    "user",
+			body:     `{"role":"read-only"}`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_empty_login",
+			method:   http.MethodPut,
+			body:     `{"role":"read-only"}`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPut,
+			login:    "user",
+			body:     `{`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_invalid_role",
+			method: http.MethodPut,
+			login:  "user",
+			body:   `{"role":"root"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().SetRole(gomock.Any(), "user", "root").Return(auth.ErrorRole)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_user_not_found",
+			method: http.MethodPut,
+			login:  "user",
+			body:   `{"role":"admin"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().SetRole(gomock.Any(), "user", "admin").Return(pq.ErrUserNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "err_set_role",
+			method: http.MethodPut,
+			login:  "user",
+			body:   `{"role":"admin"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().SetRole(gomock.Any(), "user", "admin").Return(fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/users/"+tt.login+"/role", bytes.NewBufferString(tt.body))
+			r.SetPathValue("login", tt.login)
+			h.SetRole(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.SetRole() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_Refresh(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_refresh",
+			method: http.MethodPost,
+			body:   `{"refresh_token":"old"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RefreshTokens(gomock.Any(), "old").
+					Return(models.Tokens{AccessToken: "access", RefreshToken: "new", ExpiresIn: 900}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			body:     `{"refresh_token":"old"}`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			body:     `{`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_invalid_refresh_token",
+			method: http.MethodPost,
+			body:   `{"refresh_token":"old"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RefreshTokens(gomock.Any(), "old").
+					Return(models.Tokens{}, fmt.Errorf("%w: reused", auth.ErrorRefreshToken))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "err_refresh",
+			method: http.MethodPost,
+			body:   `{"refresh_token":"old"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().RefreshTokens(gomock.Any(), "old").
+					Return(models.Tokens{}, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/auth/refresh", bytes.NewBufferString(tt.body))
+			h.Refresh(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.Refresh() = %v, want %v", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var cookie *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == auth.NameCookie {
+					cookie = c
+				}
+			}
+			if cookie == nil || cookie.Value != "access" {
+				t.Errorf("Handler.Refresh() cookie = %v, want access token", cookie)
+			}
+		})
+	}
+}
+
 func TestHandler_Logout(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
 
@@ -288,61 +605,257 @@ func TestHandler_Logout(t *testing.T) {
 	mockService := NewMockservice(ctrl)
 
 	type fields struct {
-		service    service
-		log        *slog.Logger
-		adminToken string
+		service service
+		log     *slog.Logger
 	}
 
 	tests := []struct {
 		name    string
+		mockUp  func()
 		login   string
 		method  string
+		token   string
+		bearer  string
+		body    string
 		cookies []*http.Cookie
 		fields  fields
 		want    int
+		cleared bool
 	}{
 
 		{
-			name:   "success_logout",
+			name: "success_logout",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "test-token").Return(nil)
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want:    http.StatusOK,
+			cleared: true,
+		},
+		{
+			name: "success_logout_refresh_family",
+			mockUp: func() {
+				mockService.EXPECT().RevokeRefreshToken(gomock.Any(), "test", "refresh").Return(nil)
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "test-token").Return(nil)
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			body:   `{"refresh_token": "refresh"}`,
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want:    http.StatusOK,
+			cleared: true,
+		},
+		{
+			name: "err_invalid_refresh_token",
+			mockUp: func() {
+				mockService.EXPECT().RevokeRefreshToken(gomock.Any(), "test", "foreign").Return(fmt.Errorf("%w: not found", auth.ErrorRefreshToken))
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			body:   `{"refresh_token": "foreign"}`,
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name:   "err_body",
+			mockUp: func() {},
+			login:  "test",
+			method: http.MethodDelete,
+			body:   `{`,
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "success_logout_bearer",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "header-token").Return(nil)
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			bearer: "header-token",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want:    http.StatusOK,
+			cleared: true,
+		},
+		{
+			name: "success_revoke_other_token",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "other-token").Return(nil)
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			token:  "other-token",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want:    http.StatusOK,
+			cleared: false,
+		},
+		{
+			name: "err_foreign_token",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "other-token").Return(auth.ErrorForbidden)
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			token:  "other-token",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "err_invalid_token",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "bad").Return(fmt.Errorf("%w: bad", auth.ErrorToken))
+			},
 			login:  "test",
 			method: http.MethodDelete,
+			token:  "bad",
 			fields: fields{
-				service:    mockService,
-				log:        log,
-				adminToken: "test",
+				service: mockService,
+				log:     log,
 			},
-			want: http.StatusOK,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "err_revoke",
+			mockUp: func() {
+				mockService.EXPECT().RevokeToken(gomock.Any(), "test", "test-token").Return(fmt.Errorf("storage error"))
+			},
+			login:  "test",
+			method: http.MethodDelete,
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			want: http.StatusInternalServerError,
 		},
 		{
 			name:   "err_method",
 			method: http.MethodGet,
 			fields: fields{
-				service:    mockService,
-				log:        log,
-				adminToken: "test",
+				service: mockService,
+				log:     log,
 			},
-			want: http.StatusMethodNotAllowed,
+			mockUp: func() {},
+			want:   http.StatusMethodNotAllowed,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
 			h := &Handler{
-				service:    tt.fields.service,
-				log:        tt.fields.log,
-				adminToken: tt.fields.adminToken,
+				service: tt.fields.service,
+				log:     tt.fields.log,
 			}
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(tt.method, "/logout", nil)
+			r := httptest.NewRequest(tt.method, "/logout", strings.NewReader(tt.body))
 			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
 			r.AddCookie(&http.Cookie{
 				Name:  auth.NameCookie,
 				Value: "test-token",
 			})
+			if tt.token != "" {
+				r.SetPathValue("token", tt.token)
+			}
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
 			h.Logout(w, r)
 			if w.Code != tt.want {
 				t.Errorf("Handler.Logout() = %v, want %v", w.Code, tt.want)
 			}
+			cleared := false
+			for _, c := range w.Result().Cookies() {
+				if c.Name == auth.NameCookie && c.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			if cleared != tt.cleared {
+				t.Errorf("Handler.Logout() cookie cleared = %v, want %v", cleared, tt.cleared)
+			}
+		})
+	}
+}
+
+func TestHandler_JWKS(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	keys := auth.JWKS{Keys: []auth.JWK{{Kty: "OKP", Kid: "ed-2026", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "AAAA"}}}
+
+	tests := []struct {
+		name     string
+		method   string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success",
+			method: http.MethodGet,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().JWKS().Return(keys)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodPost,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/.well-known/jwks.json", nil)
+			h.JWKS(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.JWKS() = %v, want %v", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got auth.JWKS
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Handler.JWKS() body error = %v", err)
+			}
+			if !reflect.DeepEqual(got, keys) {
+				t.Errorf("Handler.JWKS() = %v, want %v", got, keys)
+			}
 		})
 	}
 }
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// AuthMFA - второй шаг входа: токен из /api/auth и код из приложения или код восстановления
+func (h *Handler) AuthMFA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("AuthMFA", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("AuthMFA", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	login, err := h.service.MFALogin(req.MFAToken)
+	if err != nil {
+		h.log.Error("AuthMFA", "error", err)
+		helper.FailResponse(w, http.StatusUnauthorized, "invalid mfa token")
+		return
+	}
+
+	// неверные коды считаются вместе с неверными паролями
+	ip := clientIP(r)
+	if wait := h.limiter.Check(r.Context(), login, ip); wait > 0 {
+		h.log.Error("AuthMFA", "error", "too many attempts", "login", login, "ip", ip)
+		tooManyAttempts(w, wait)
+		return
+	}
+
+	tokens, err := h.service.AuthMFA(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.log.Error("AuthMFA", "error", err)
+		switch {
+		case errors.Is(err, auth.ErrorMFACode):
+			if wait := h.limiter.Fail(r.Context(), login, ip); wait > 0 {
+				tooManyAttempts(w, wait)
+				return
+			}
+			helper.FailResponse(w, http.StatusUnauthorized, "invalid two-factor code")
+		case errors.Is(err, auth.ErrorMFAToken):
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusUnauthorized, "invalid mfa token")
+		default:
+			h.limiter.Release(r.Context(), login, ip)
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to auth user")
+		}
+		return
+	}
+	h.limiter.Success(r.Context(), login, ip)
+
+	setTokenCookie(w, tokens)
+
+	helper.OkResponse(w, tokens)
+}
+
+// EnrollTOTP - ручка подключения двухфакторной аутентификации, секрет и otpauth-ссылка для QR-кода
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("EnrollTOTP", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("EnrollTOTP", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(r.Context(), login)
+	if err != nil {
+		h.log.Error("EnrollTOTP", "failed to enroll totp", err)
+		if errors.Is(err, auth.ErrorMFAEnabled) {
+			helper.FailResponse(w, http.StatusConflict, "two-factor authentication already enabled")
+			return
+		}
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to enroll totp")
+		return
+	}
+
+	helper.OkDataResponse(w, enrollment)
+}
+
+// ConfirmTOTP - ручка включения двухфакторной аутентификации первым кодом, коды восстановления показываются один раз
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("ConfirmTOTP", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error("ConfirmTOTP", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("ConfirmTOTP", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(r.Context(), login, req.Code)
+	if err != nil {
+		h.log.Error("ConfirmTOTP", "failed to confirm totp", err)
+		switch {
+		case errors.Is(err, auth.ErrorMFACode):
+			helper.FailResponse(w, http.StatusBadRequest, "invalid two-factor code")
+		case errors.Is(err, auth.ErrorMFANotEnrolled), errors.Is(err, auth.ErrorMFAEnabled):
+			helper.FailResponse(w, http.StatusConflict, err.Error())
+		default:
+			helper.FailResponse(w, http.StatusInternalServerError, "failed to confirm totp")
+		}
+		return
+	}
+
+	helper.OkDataResponse(w, map[string][]string{"recovery_codes": codes})
+}
+
+// ResetTOTP - ручка сброса двухфакторной аутентификации пользователя, доступна администратору
+func (h *Handler) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.log.Error("ResetTOTP", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	// /api/users/{login}/2fa
+	login := r.PathValue("login")
+	if login == "" {
+		h.log.Error("ResetTOTP", "error", "empty login")
+		helper.FailResponse(w, http.StatusBadRequest, "empty login")
+		return
+	}
+
+	if err := h.service.ResetTOTP(r.Context(), login); err != nil {
+		h.log.Error("ResetTOTP", "failed to reset totp", err)
+		if errors.Is(err, pq.ErrUserNotFound) {
+			helper.FailResponse(w, http.StatusNotFound, "user not found")
+			return
+		}
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to reset totp")
+		return
+	}
+
+	h.log.Info("ResetTOTP", "status", "two-factor authentication reset", "login", login)
+	helper.OkResponse(w, map[string]any{"login": login, "two_factor": false})
+}
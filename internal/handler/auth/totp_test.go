@@ -0,0 +1,421 @@
+package auth
+
+import (
+	"bytes"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/auth"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestHandler_Auth_MFARequired(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := NewMockservice(ctrl)
+	mockLimiter := NewMocklimiter(ctrl)
+	h := &Handler{
+		service: mockService,
+		log:     log,
+		limiter: mockLimiter,
+	}
+
+	// попытка не засчитывается, но счетчик неудач не сбрасывается до второго шага, cookie не ставится
+	mockLimiter.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+	mockLimiter.EXPECT().Release(gomock.Any(), "test", gomock.Any())
+	mockService.EXPECT().AuthUser(gomock.Any(), "test", "test").Return(models.Tokens{MFAToken: "mfa", ExpiresIn: 300}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/auth", bytes.NewBufferString(`{"login":"test","password":"test"}`))
+	h.Auth(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Handler.Auth() = %v, want %v", w.Code, http.StatusOK)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Errorf("Handler.Auth() must not set cookie before second step")
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"mfa_token":"mfa"`)) || bytes.Contains(w.Body.Bytes(), []byte(`"token"`)) {
+		t.Errorf("Handler.Auth() body = %s", w.Body.String())
+	}
+}
+
+func TestHandler_AuthMFA(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		mockUp     func(m *Mockservice, l *Mocklimiter)
+		wantCode   int
+		wantCookie bool
+	}{
+		{
+			name:   "success_auth_mfa",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"mfa","code":"123456"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("mfa").Return("test", nil)
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().AuthMFA(gomock.Any(), "mfa", "123456").Return(models.Tokens{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil)
+				l.EXPECT().Success(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode:   http.StatusOK,
+			wantCookie: true,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			body:     `{`,
+			mockUp:   func(m *Mockservice, l *Mocklimiter) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_mfa_token",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"bad","code":"123456"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("bad").Return("", auth.ErrorMFAToken)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "err_locked",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"mfa","code":"123456"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("mfa").Return("test", nil)
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_code",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"mfa","code":"000000"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("mfa").Return("test", nil)
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().AuthMFA(gomock.Any(), "mfa", "000000").Return(models.Tokens{}, auth.ErrorMFACode)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "err_code_locks",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"mfa","code":"000000"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("mfa").Return("test", nil)
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().AuthMFA(gomock.Any(), "mfa", "000000").Return(models.Tokens{}, auth.ErrorMFACode)
+				l.EXPECT().Fail(gomock.Any(), "test", gomock.Any()).Return(time.Minute)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "err_auth_mfa",
+			method: http.MethodPost,
+			body:   `{"mfa_token":"mfa","code":"123456"}`,
+			mockUp: func(m *Mockservice, l *Mocklimiter) {
+				m.EXPECT().MFALogin("mfa").Return("test", nil)
+				l.EXPECT().Check(gomock.Any(), "test", gomock.Any()).Return(time.Duration(0))
+				m.EXPECT().AuthMFA(gomock.Any(), "mfa", "123456").Return(models.Tokens{}, fmt.Errorf("storage error"))
+				l.EXPECT().Release(gomock.Any(), "test", gomock.Any())
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			mockLimiter := NewMocklimiter(ctrl)
+			tt.mockUp(mockService, mockLimiter)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+				limiter: mockLimiter,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/auth/2fa", bytes.NewBufferString(tt.body))
+			h.AuthMFA(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.AuthMFA() = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := len(w.Result().Cookies()) > 0; got != tt.wantCookie {
+				t.Errorf("Handler.AuthMFA() cookie set = %v, want %v", got, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestHandler_EnrollTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_enroll",
+			method: http.MethodPost,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().EnrollTOTP(gomock.Any(), "test").Return(models.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/x"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			login:    "test",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_login",
+			method:   http.MethodPost,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "err_enabled",
+			method: http.MethodPost,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().EnrollTOTP(gomock.Any(), "test").Return(models.TOTPEnrollment{}, auth.ErrorMFAEnabled)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "err_enroll",
+			method: http.MethodPost,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().EnrollTOTP(gomock.Any(), "test").Return(models.TOTPEnrollment{}, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/me/2fa", nil)
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			h.EnrollTOTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.EnrollTOTP() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_ConfirmTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		body     string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_confirm",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"code":"123456"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), "test", "123456").Return([]string{"abcde-fghij"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodGet,
+			login:    "test",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_body",
+			method:   http.MethodPost,
+			login:    "test",
+			body:     `{`,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_code",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"code":"000000"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), "test", "000000").Return(nil, auth.ErrorMFACode)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_not_enrolled",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"code":"123456"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), "test", "123456").Return(nil, auth.ErrorMFANotEnrolled)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "err_confirm",
+			method: http.MethodPost,
+			login:  "test",
+			body:   `{"code":"123456"}`,
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ConfirmTOTP(gomock.Any(), "test", "123456").Return(nil, fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/me/2fa/confirm", bytes.NewBufferString(tt.body))
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			h.ConfirmTOTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.ConfirmTOTP() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_ResetTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		method   string
+		login    string
+		mockUp   func(m *Mockservice)
+		wantCode int
+	}{
+		{
+			name:   "success_reset",
+			method: http.MethodDelete,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ResetTOTP(gomock.Any(), "test").Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "err_method",
+			method:   http.MethodPost,
+			login:    "test",
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "err_empty_login",
+			method:   http.MethodDelete,
+			mockUp:   func(m *Mockservice) {},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "err_not_found",
+			method: http.MethodDelete,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ResetTOTP(gomock.Any(), "test").Return(pq.ErrUserNotFound)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "err_reset",
+			method: http.MethodDelete,
+			login:  "test",
+			mockUp: func(m *Mockservice) {
+				m.EXPECT().ResetTOTP(gomock.Any(), "test").Return(fmt.Errorf("storage error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := NewMockservice(ctrl)
+			tt.mockUp(mockService)
+
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/users/"+tt.login+"/2fa", nil)
+			r.SetPathValue("login", tt.login)
+			h.ResetTOTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("Handler.ResetTOTP() = %v, want %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -3,32 +3,45 @@ package get
 import (
 	"caching_web_server/internal/helper"
 	"caching_web_server/internal/models"
+	"caching_web_server/internal/purge"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
+// HeaderCache - заголовок с результатом обращения к кешу: HIT или MISS
+const HeaderCache = "X-Cache"
+
 //go:generate mockgen -source=handler.go -destination=handler_mock.go -package=get
 type Service interface {
-	GetDocuments(ctx context.Context, login, filterKey, filterValue string, limit int) ([]models.DocsData, error)
-	GetDocument(ctx context.Context, login, docID string) ([]byte, []byte, string, error)
+	GetDocuments(ctx context.Context, login, scope, filterKey, filterValue string, limit int, version int64) ([]models.DocsData, bool, error)
+	GetDocument(ctx context.Context, login, docID string, notModified func(models.DocumentContent) bool) (models.DocumentContent, error)
+	ListVersion(ctx context.Context, login, scope string) (int64, error)
+	ListETag(login, scope, filterKey, filterValue string, limit int, version int64) string
 }
 
 type Handler struct {
 	service Service
 	log     *slog.Logger
+	policy  helper.CachePolicy
 }
 
-func NewHandler(service Service, log *slog.Logger) *Handler {
+func NewHandler(service Service, log *slog.Logger, policy helper.CachePolicy) *Handler {
 	return &Handler{
 		service: service,
 		log:     log,
+		policy:  policy,
 	}
 }
 
 // GetDocuments - ручка получения документов
+// scope: owned (по умолчанию), shared, all или public; список всегда строится для пользователя из токена
 func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.log.Error("GetDocuments", "error", "invalid method")
@@ -38,7 +51,7 @@ func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Token       string `json:"token"`
-		Login       string `json:"login"`
+		Scope       string `json:"scope"`
 		FilterKey   string `json:"key" `
 		FilterValue string `json:"value"`
 		Limit       int    `json:"limit"`
@@ -50,24 +63,49 @@ func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Login == "" {
-		login, ok := r.Context().Value("login").(string)
-		if !ok {
-			h.log.Error("GetDocuments", "error", "failed to get login from context")
-			helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+	login, ok := r.Context().Value("login").(string)
+	if !ok {
+		h.log.Error("GetDocuments", "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return
+	}
+
+	w.Header().Set("Cache-Control", h.policy.CacheControl(false))
+
+	// без версии список все равно отдаем, просто без кеша и условного ответа
+	version, err := h.service.ListVersion(r.Context(), login, req.Scope)
+	if errors.Is(err, pq.ErrListScope) {
+		h.log.Error("GetDocuments", "invalid scope", req.Scope)
+		helper.FailResponse(w, http.StatusBadRequest, "invalid scope")
+		return
+	}
+	if err != nil {
+		h.log.Error("GetDocuments", "failed to get list version", err)
+		version = docs.NoListVersion
+	}
+	if etag := h.service.ListETag(login, req.Scope, req.FilterKey, req.FilterValue, req.Limit, version); etag != "" {
+		w.Header().Set("ETag", etag)
+		if helper.NotModified(r, etag, time.Time{}) {
+			helper.WriteNotModified(w)
 			return
 		}
-		req.Login = login
 	}
 
-	docs, err := h.service.GetDocuments(r.Context(), req.Login, req.FilterKey, req.FilterValue, req.Limit)
+	list, hit, err := h.service.GetDocuments(r.Context(), login, req.Scope, req.FilterKey, req.FilterValue, req.Limit, version)
 	if err != nil {
 		h.log.Error("GetDocuments", "failed to get documents", err)
+		w.Header().Del("ETag")
 		helper.FailResponse(w, http.StatusInternalServerError, "failed to get documents")
 		return
 	}
 
-	helper.OkDataResponse(w, docs)
+	if hit {
+		w.Header().Set(HeaderCache, "HIT")
+	} else {
+		w.Header().Set(HeaderCache, "MISS")
+	}
+
+	helper.OkDataResponse(w, list)
 }
 
 // GetDocument - ручка получения документа
@@ -87,24 +125,46 @@ func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	}
 	docID := parts[3]
 
-	login, ok := r.Context().Value("login").(string)
-	if !ok {
-		h.log.Error("GetDocument", "error", "failed to get login from context")
-		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
-		return
+	// без логина запрос анонимный, такому доступны только публичные документы
+	login, _ := r.Context().Value("login").(string)
+
+	// If-None-Match сверяется с content_hash до загрузки файла из MinIO
+	notModified := func(content models.DocumentContent) bool {
+		return helper.NotModified(r, documentETag(content.Hash), content.ModTime)
 	}
 
-	file, JSON, mime, err := h.service.GetDocument(r.Context(), login, docID)
+	content, err := h.service.GetDocument(r.Context(), login, docID, notModified)
 	if err != nil {
 		h.log.Error("GetDocument", "failed to get document", err)
+		if errors.Is(err, pq.ErrDocumentNotFound) {
+			helper.FailResponse(w, http.StatusNotFound, "document not found")
+			return
+		}
 		helper.FailResponse(w, http.StatusInternalServerError, "failed to get document")
 		return
 	}
 
-	if file != nil {
-		w.Header().Set("Content-Type", mime)
+	// ключ, по которому прокси сбрасывает кеш документа
+	w.Header().Set(purge.KeyHeader, purge.DocumentKey(docID))
+	w.Header().Set("Cache-Control", h.policy.CacheControl(content.Public))
+
+	etag := documentETag(content.Hash)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !content.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", content.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(content) {
+		helper.WriteNotModified(w)
+		return
+	}
+
+	if content.File != nil {
+		w.Header().Set("Content-Type", content.Mime)
 		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(file)
+		_, err = w.Write(content.File)
 		if err != nil {
 			h.log.Error("GetDocument", "failed to write file", err)
 			helper.FailResponse(w, http.StatusInternalServerError, "failed to write file")
@@ -113,5 +173,13 @@ func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	helper.OkDataResponse(w, JSON)
+	helper.OkDataResponse(w, content.JSON)
+}
+
+// documentETag - ETag документа по хешу содержимого, у документов, загруженных до появления хеша, ETag нет
+func documentETag(hash string) string {
+	if hash == "" {
+		return ""
+	}
+	return `"` + hash + `"`
 }
@@ -36,33 +36,61 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // GetDocument mocks base method.
-func (m *MockService) GetDocument(ctx context.Context, login, docID string) ([]byte, []byte, string, error) {
+func (m *MockService) GetDocument(ctx context.Context, login, docID string, notModified func(models.DocumentContent) bool) (models.DocumentContent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDocument", ctx, login, docID)
-	ret0, _ := ret[0].([]byte)
-	ret1, _ := ret[1].([]byte)
-	ret2, _ := ret[2].(string)
-	ret3, _ := ret[3].(error)
-	return ret0, ret1, ret2, ret3
+	ret := m.ctrl.Call(m, "GetDocument", ctx, login, docID, notModified)
+	ret0, _ := ret[0].(models.DocumentContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetDocument indicates an expected call of GetDocument.
-func (mr *MockServiceMockRecorder) GetDocument(ctx, login, docID interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetDocument(ctx, login, docID, notModified interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockService)(nil).GetDocument), ctx, login, docID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockService)(nil).GetDocument), ctx, login, docID, notModified)
 }
 
 // GetDocuments mocks base method.
-func (m *MockService) GetDocuments(ctx context.Context, login, filterKey, filterValue string, limit int) ([]models.DocsData, error) {
+func (m *MockService) GetDocuments(ctx context.Context, login, scope, filterKey, filterValue string, limit int, version int64) ([]models.DocsData, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDocuments", ctx, login, filterKey, filterValue, limit)
+	ret := m.ctrl.Call(m, "GetDocuments", ctx, login, scope, filterKey, filterValue, limit, version)
 	ret0, _ := ret[0].([]models.DocsData)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDocuments indicates an expected call of GetDocuments.
+func (mr *MockServiceMockRecorder) GetDocuments(ctx, login, scope, filterKey, filterValue, limit, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*MockService)(nil).GetDocuments), ctx, login, scope, filterKey, filterValue, limit, version)
+}
+
+// ListETag mocks base method.
+func (m *MockService) ListETag(login, scope, filterKey, filterValue string, limit int, version int64) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListETag", login, scope, filterKey, filterValue, limit, version)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ListETag indicates an expected call of ListETag.
+func (mr *MockServiceMockRecorder) ListETag(login, scope, filterKey, filterValue, limit, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListETag", reflect.TypeOf((*MockService)(nil).ListETag), login, scope, filterKey, filterValue, limit, version)
+}
+
+// ListVersion mocks base method.
+func (m *MockService) ListVersion(ctx context.Context, login, scope string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersion", ctx, login, scope)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetDocuments indicates an expected call of GetDocuments.
-func (mr *MockServiceMockRecorder) GetDocuments(ctx, login, filterKey, filterValue, limit interface{}) *gomock.Call {
+// ListVersion indicates an expected call of ListVersion.
+func (mr *MockServiceMockRecorder) ListVersion(ctx, login, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*MockService)(nil).GetDocuments), ctx, login, filterKey, filterValue, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersion", reflect.TypeOf((*MockService)(nil).ListVersion), ctx, login, scope)
 }
@@ -2,8 +2,11 @@ package get
 
 import (
 	"bytes"
+	"caching_web_server/internal/helper"
 	"caching_web_server/internal/middleware"
 	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
 	"errors"
@@ -13,6 +16,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 )
@@ -28,8 +32,10 @@ func TestHandler_GetDocuments(t *testing.T) {
 		log     *slog.Logger
 	}
 	type body struct {
-		Token       string `json:"token"`
+		Token string `json:"token"`
+		// Login - поле из старых клиентов, ручка его не читает
 		Login       string `json:"login"`
+		Scope       string `json:"scope"`
 		FilterKey   string `json:"key" `
 		FilterValue string `json:"value"`
 		Limit       int    `json:"limit"`
@@ -44,11 +50,17 @@ func TestHandler_GetDocuments(t *testing.T) {
 		body     body
 		fields   fields
 		code     int
+		cache    string
+		// ifNoneMatch - значение заголовка If-None-Match в запросе
+		ifNoneMatch string
+		etag        string
 	}{
 		{
 			name: "success_get_documents",
 			mockUp: func() {
-				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.DocsData{}, nil)
+				mockService.EXPECT().ListVersion(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockService.EXPECT().ListETag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(`"1-abc"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.DocsData{}, false, nil)
 			},
 			login:    "test",
 			method:   http.MethodGet,
@@ -64,7 +76,101 @@ func TestHandler_GetDocuments(t *testing.T) {
 				service: mockService,
 				log:     log,
 			},
-			code: http.StatusOK,
+			code:  http.StatusOK,
+			cache: "MISS",
+			etag:  `"1-abc"`,
+		},
+		{
+			name: "success_get_documents_from_cache",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockService.EXPECT().ListETag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(`"1-abc"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.DocsData{}, true, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Token: "test",
+				Login: "test",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:  http.StatusOK,
+			cache: "HIT",
+			etag:  `"1-abc"`,
+		},
+		{
+			name: "not_modified",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), "test", "").Return(int64(1), nil)
+				mockService.EXPECT().ListETag("test", "", "", "", 1, int64(1)).Return(`"1-abc"`)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Token: "test",
+				Login: "test",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			ifNoneMatch: `"1-abc"`,
+			code:        http.StatusNotModified,
+			etag:        `"1-abc"`,
+		},
+		{
+			name: "modified",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
+				mockService.EXPECT().ListETag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(`"2-abc"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.DocsData{}, false, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Token: "test",
+				Login: "test",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			ifNoneMatch: `"1-abc"`,
+			code:        http.StatusOK,
+			cache:       "MISS",
+			etag:        `"2-abc"`,
+		},
+		{
+			name: "error_list_version",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), gomock.Any(), gomock.Any()).Return(docs.NoListVersion, errors.New("version error"))
+				mockService.EXPECT().ListETag("test", "", "", "", 1, docs.NoListVersion).Return("")
+				mockService.EXPECT().GetDocuments(gomock.Any(), "test", "", "", "", 1, docs.NoListVersion).Return([]models.DocsData{}, false, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Token: "test",
+				Login: "test",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			ifNoneMatch: `"1-abc"`,
+			code:        http.StatusOK,
+			cache:       "MISS",
 		},
 		{
 			name:   "error_method",
@@ -84,6 +190,71 @@ func TestHandler_GetDocuments(t *testing.T) {
 			},
 			code: http.StatusMethodNotAllowed,
 		},
+		{
+			name: "success_get_shared_documents",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), "test", "shared").Return(int64(1), nil)
+				mockService.EXPECT().ListETag("test", "shared", "", "", 1, int64(1)).Return(`"1-def"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), "test", "shared", "", "", 1, int64(1)).
+					Return([]models.DocsData{{Id: "id1", Owner: "owner01", Permission: models.PermissionWrite}}, false, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Login: "test",
+				Scope: "shared",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:  http.StatusOK,
+			cache: "MISS",
+			etag:  `"1-def"`,
+		},
+		{
+			name: "success_get_public_documents_without_etag",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), "test", "public").Return(docs.NoListVersion, nil)
+				mockService.EXPECT().ListETag("test", "public", "", "", 1, docs.NoListVersion).Return("")
+				mockService.EXPECT().GetDocuments(gomock.Any(), "test", "public", "", "", 1, docs.NoListVersion).Return([]models.DocsData{}, false, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Login: "test",
+				Scope: "public",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:  http.StatusOK,
+			cache: "MISS",
+		},
+		{
+			name: "error_scope",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), "test", "everything").Return(docs.NoListVersion, pq.ErrListScope)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Login: "test",
+				Scope: "everything",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code: http.StatusBadRequest,
+		},
 		{
 			name:   "error_body",
 			mockUp: func() {},
@@ -96,6 +267,29 @@ func TestHandler_GetDocuments(t *testing.T) {
 			},
 			code: http.StatusBadRequest,
 		},
+		{
+			name: "success_body_login_ignored",
+			mockUp: func() {
+				mockService.EXPECT().ListVersion(gomock.Any(), "test", "").Return(int64(1), nil)
+				mockService.EXPECT().ListETag("test", "", "", "", 1, int64(1)).Return(`"1-abc"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), "test", "", "", "", 1, int64(1)).Return([]models.DocsData{}, false, nil)
+			},
+			login:    "test",
+			method:   http.MethodGet,
+			bodyBool: true,
+			body: body{
+				Token: "test",
+				Login: "victim",
+				Limit: 1,
+			},
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:  http.StatusOK,
+			cache: "MISS",
+			etag:  `"1-abc"`,
+		},
 		{
 			name:     "error_no_login",
 			mockUp:   func() {},
@@ -118,7 +312,9 @@ func TestHandler_GetDocuments(t *testing.T) {
 		{
 			name: "error_get_documents",
 			mockUp: func() {
-				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("get documents error"))
+				mockService.EXPECT().ListVersion(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockService.EXPECT().ListETag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(`"1-abc"`)
+				mockService.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, false, errors.New("get documents error"))
 			},
 			login:    "test",
 			bodyBool: true,
@@ -152,14 +348,17 @@ func TestHandler_GetDocuments(t *testing.T) {
 			}
 
 			w := httptest.NewRecorder()
-			ctx := context.WithValue(context.Background(), middleware.NameLogin, tt.login)
 			r, err := http.NewRequest(tt.method, "/docs", &buf)
-			r.WithContext(ctx)
-
 			if err != nil {
 				log.Error("TestHandler_GetDocuments", "failed to create request", err)
 				return
 			}
+			if tt.login != "" {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, tt.login))
+			}
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
 			h := &Handler{
 				service: tt.fields.service,
 				log:     tt.fields.log,
@@ -169,6 +368,12 @@ func TestHandler_GetDocuments(t *testing.T) {
 			if w.Code != tt.code {
 				t.Errorf("GetDocuments() = %v, want %v", w.Code, tt.code)
 			}
+			if got := w.Header().Get(HeaderCache); got != tt.cache {
+				t.Errorf("GetDocuments() %s = %v, want %v", HeaderCache, got, tt.cache)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("GetDocuments() ETag = %v, want %v", got, tt.etag)
+			}
 		})
 	}
 }
@@ -191,13 +396,16 @@ func TestHandler_GetDocument(t *testing.T) {
 		args       args
 		docID      string
 		cookieBool bool
+		headers    map[string]string
 		code       int
+		etag       string
+		control    string
 	}{
 		{
 			name: "success_new_handler_file",
 			mockUp: func() {
-				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any()).
-					Return([]byte{}, nil, "image/jpg", nil)
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{File: []byte{}, Mime: "image/jpg", Hash: "abc", ModTime: time.Now()}, nil)
 			},
 			method: http.MethodGet,
 			args: args{
@@ -207,12 +415,90 @@ func TestHandler_GetDocument(t *testing.T) {
 			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
 			cookieBool: true,
 			code:       http.StatusOK,
+			etag:       `"abc"`,
+			control:    "private, no-cache",
+		},
+		{
+			name: "success_public_document",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{File: []byte("file"), Mime: "image/jpg", Hash: "abc", Public: true}, nil)
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: true,
+			code:       http.StatusOK,
+			etag:       `"abc"`,
+			control:    "public, max-age=3600",
+		},
+		{
+			name: "success_not_modified_etag",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{File: []byte("file"), Mime: "image/jpg", Hash: "abc", ModTime: time.Now()}, nil)
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: true,
+			headers:    map[string]string{"If-None-Match": `"abc"`},
+			code:       http.StatusNotModified,
+			etag:       `"abc"`,
+		},
+		{
+			// сервис сверил ETag по метаданным и не загружал файл
+			name: "success_not_modified_before_fetch",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, notModified func(models.DocumentContent) bool) (models.DocumentContent, error) {
+						content := models.DocumentContent{Mime: "image/jpg", Hash: "abc", ModTime: time.Now()}
+						if !notModified(content) {
+							t.Errorf("notModified() = false, want true for matching If-None-Match")
+						}
+						return content, nil
+					})
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: true,
+			headers:    map[string]string{"If-None-Match": `W/"abc", "other"`},
+			code:       http.StatusNotModified,
+			etag:       `"abc"`,
+		},
+		{
+			name: "success_modified_etag",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{File: []byte("file"), Mime: "image/jpg", Hash: "abc", ModTime: time.Now()}, nil)
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: true,
+			headers:    map[string]string{"If-None-Match": `"old"`},
+			code:       http.StatusOK,
+			etag:       `"abc"`,
+		},
+		{
+			name: "success_not_modified_since",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{File: []byte("file"), Mime: "image/jpg", ModTime: time.Now().Add(-time.Hour)}, nil)
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: true,
+			headers:    map[string]string{"If-Modified-Since": time.Now().UTC().Format(http.TimeFormat)},
+			code:       http.StatusNotModified,
 		},
 		{
 			name: "success_new_handler_json",
 			mockUp: func() {
-				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any()).
-					Return(nil, []byte{}, "application/json", nil)
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{JSON: []byte{}, Mime: "application/json"}, nil)
 			},
 			method: http.MethodGet,
 			args: args{
@@ -248,19 +534,36 @@ func TestHandler_GetDocument(t *testing.T) {
 			code:       http.StatusBadRequest,
 		},
 		{
-			name:       "error_no_login",
-			mockUp:     func() {},
+			name: "success_anonymous",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), "", "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d", gomock.Any()).
+					Return(models.DocumentContent{File: []byte("file"), Mime: "image/jpg", Hash: "abc", Public: true}, nil)
+			},
 			method:     http.MethodGet,
 			args:       args{service: mockService, log: log},
 			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
 			cookieBool: false,
-			code:       http.StatusInternalServerError,
+			code:       http.StatusOK,
+			etag:       `"abc"`,
+			control:    "public, max-age=3600",
+		},
+		{
+			name: "error_not_found",
+			mockUp: func() {
+				mockService.EXPECT().GetDocument(gomock.Any(), "", gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{}, pq.ErrDocumentNotFound)
+			},
+			method:     http.MethodGet,
+			args:       args{service: mockService, log: log},
+			docID:      "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d",
+			cookieBool: false,
+			code:       http.StatusNotFound,
 		},
 		{
 			name: "error_get_document",
 			mockUp: func() {
-				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any()).
-					Return(nil, nil, "", errors.New("get document error"))
+				mockService.EXPECT().GetDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(models.DocumentContent{}, errors.New("get document error"))
 			},
 			method:     http.MethodGet,
 			args:       args{service: mockService, log: log},
@@ -282,17 +585,27 @@ func TestHandler_GetDocument(t *testing.T) {
 			h := &Handler{
 				service: tt.args.service,
 				log:     tt.args.log,
+				policy:  helper.CachePolicy{PublicMaxAge: time.Hour},
 			}
 
 			if tt.cookieBool {
 				ctx := context.WithValue(r.Context(), middleware.NameLogin, "test")
 				r = r.WithContext(ctx)
 			}
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
 			h.GetDocument(w, r)
 
 			if w.Code != tt.code {
 				t.Errorf("GetDocument() = %v, want %v", w.Code, tt.code)
 			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("GetDocument() ETag = %v, want %v", got, tt.etag)
+			}
+			if tt.control != "" && w.Header().Get("Cache-Control") != tt.control {
+				t.Errorf("GetDocument() Cache-Control = %v, want %v", w.Header().Get("Cache-Control"), tt.control)
+			}
 		})
 	}
 }
@@ -306,6 +619,7 @@ func TestNewHandler(t *testing.T) {
 	type args struct {
 		service Service
 		log     *slog.Logger
+		policy  helper.CachePolicy
 	}
 	tests := []struct {
 		name string
@@ -318,16 +632,18 @@ func TestNewHandler(t *testing.T) {
 			args: args{
 				service: mockService,
 				log:     log,
+				policy:  helper.CachePolicy{PublicMaxAge: time.Hour},
 			},
 			want: &Handler{
 				service: mockService,
 				log:     log,
+				policy:  helper.CachePolicy{PublicMaxAge: time.Hour},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewHandler(tt.args.service, tt.args.log); !reflect.DeepEqual(got, tt.want) {
+			if got := NewHandler(tt.args.service, tt.args.log, tt.args.policy); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewHandler() = %v, want %v", got, tt.want)
 			}
 		})
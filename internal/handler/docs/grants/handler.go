@@ -0,0 +1,166 @@
+package grants
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=handler.go -destination=handler_mock.go -package=grants
+type service interface {
+	GetGrants(ctx context.Context, login, docID string) ([]models.Grant, error)
+	AddGrants(ctx context.Context, login, docID string, logins, groups []string, permission string) ([]models.Grant, error)
+	DeleteGrants(ctx context.Context, login, docID string, logins, groups []string) ([]models.Grant, error)
+}
+
+type Handler struct {
+	service service
+	log     *slog.Logger
+}
+
+func NewHandler(service service, log *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetGrants - ручка списка пользователей и групп с доступом к документу, для владельца и пользователей с правом manage
+func (h *Handler) GetGrants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.log.Error("GetGrants", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, docID, ok := h.request(w, r, "GetGrants")
+	if !ok {
+		return
+	}
+
+	grants, err := h.service.GetGrants(r.Context(), login, docID)
+	if err != nil {
+		h.log.Error("GetGrants", "failed to get grants", err)
+		h.fail(w, err, "failed to get grants")
+		return
+	}
+
+	helper.OkDataResponse(w, grants)
+}
+
+// AddGrants - ручка выдачи доступа к документу: {"logins": [...], "groups": [...], "permission": "read|write|manage"}
+func (h *Handler) AddGrants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("AddGrants", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, docID, ok := h.request(w, r, "AddGrants")
+	if !ok {
+		return
+	}
+
+	req, ok := h.decode(w, r, "AddGrants")
+	if !ok {
+		return
+	}
+
+	grants, err := h.service.AddGrants(r.Context(), login, docID, req.Logins, req.Groups, req.Permission)
+	if err != nil {
+		h.log.Error("AddGrants", "failed to add grants", err)
+		h.fail(w, err, "failed to add grants")
+		return
+	}
+
+	helper.OkDataResponse(w, grants)
+}
+
+// DeleteGrants - ручка отзыва доступа к документу: {"logins": [...], "groups": [...]}
+func (h *Handler) DeleteGrants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.log.Error("DeleteGrants", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, docID, ok := h.request(w, r, "DeleteGrants")
+	if !ok {
+		return
+	}
+
+	req, ok := h.decode(w, r, "DeleteGrants")
+	if !ok {
+		return
+	}
+
+	grants, err := h.service.DeleteGrants(r.Context(), login, docID, req.Logins, req.Groups)
+	if err != nil {
+		h.log.Error("DeleteGrants", "failed to delete grants", err)
+		h.fail(w, err, "failed to delete grants")
+		return
+	}
+
+	helper.OkDataResponse(w, grants)
+}
+
+// request - логин из контекста и id документа из пути /api/docs/{id}/grants
+func (h *Handler) request(w http.ResponseWriter, r *http.Request, op string) (string, string, bool) {
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error(op, "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return "", "", false
+	}
+
+	docID := r.PathValue("id")
+	if _, err := uuid.Parse(docID); err != nil {
+		h.log.Error(op, "failed to parse document id", err)
+		helper.FailResponse(w, http.StatusBadRequest, "invalid document id")
+		return "", "", false
+	}
+
+	return login, docID, true
+}
+
+// grantsRequest - тело запросов на изменение доступа, уровень нужен только при выдаче
+type grantsRequest struct {
+	Logins     []string `json:"logins"`
+	Groups     []string `json:"groups"`
+	Permission string   `json:"permission"`
+}
+
+// decode - разбирает тело запроса
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, op string) (grantsRequest, bool) {
+	var req grantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error(op, "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return grantsRequest{}, false
+	}
+
+	return req, true
+}
+
+// fail - ответ на ошибку сервиса: документ без доступа неотличим от несуществующего
+func (h *Handler) fail(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, pq.ErrDocumentNotFound):
+		helper.FailResponse(w, http.StatusNotFound, "document not found")
+	case errors.Is(err, pq.ErrPermissionDenied):
+		helper.FailResponse(w, http.StatusForbidden, "insufficient document permission")
+	case errors.Is(err, pq.ErrGrantUserNotFound), errors.Is(err, pq.ErrGrantGroupNotFound), errors.Is(err, docs.ErrGrantLogins), errors.Is(err, docs.ErrGrantPermission):
+		helper.FailResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		helper.FailResponse(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package grants is a generated GoMock package.
+package grants
+
+import (
+	models "caching_web_server/internal/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockservice is a mock of service interface.
+type Mockservice struct {
+	ctrl     *gomock.Controller
+	recorder *MockserviceMockRecorder
+}
+
+// MockserviceMockRecorder is the mock recorder for Mockservice.
+type MockserviceMockRecorder struct {
+	mock *Mockservice
+}
+
+// NewMockservice creates a new mock instance.
+func NewMockservice(ctrl *gomock.Controller) *Mockservice {
+	mock := &Mockservice{ctrl: ctrl}
+	mock.recorder = &MockserviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
+	return m.recorder
+}
+
+// AddGrants mocks base method.
+func (m *Mockservice) AddGrants(ctx context.Context, login, docID string, logins, groups []string, permission string) ([]models.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGrants", ctx, login, docID, logins, groups, permission)
+	ret0, _ := ret[0].([]models.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGrants indicates an expected call of AddGrants.
+func (mr *MockserviceMockRecorder) AddGrants(ctx, login, docID, logins, groups, permission interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGrants", reflect.TypeOf((*Mockservice)(nil).AddGrants), ctx, login, docID, logins, groups, permission)
+}
+
+// DeleteGrants mocks base method.
+func (m *Mockservice) DeleteGrants(ctx context.Context, login, docID string, logins, groups []string) ([]models.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrants", ctx, login, docID, logins, groups)
+	ret0, _ := ret[0].([]models.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteGrants indicates an expected call of DeleteGrants.
+func (mr *MockserviceMockRecorder) DeleteGrants(ctx, login, docID, logins, groups interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrants", reflect.TypeOf((*Mockservice)(nil).DeleteGrants), ctx, login, docID, logins, groups)
+}
+
+// GetGrants mocks base method.
+func (m *Mockservice) GetGrants(ctx context.Context, login, docID string) ([]models.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrants", ctx, login, docID)
+	ret0, _ := ret[0].([]models.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrants indicates an expected call of GetGrants.
+func (mr *MockserviceMockRecorder) GetGrants(ctx, login, docID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrants", reflect.TypeOf((*Mockservice)(nil).GetGrants), ctx, login, docID)
+}
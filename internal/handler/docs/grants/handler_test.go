@@ -0,0 +1,345 @@
+package grants
+
+import (
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+const docID = "2b7e1a8c-6f0d-4c55-9a0a-3f2a1f6c8d10"
+
+func TestHandler_GetGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		id     string
+		code   int
+	}{
+		{
+			name: "success_get_grants",
+			mockUp: func() {
+				mockService.EXPECT().GetGrants(gomock.Any(), "test", docID).Return([]models.Grant{{Login: "reader01"}}, nil)
+			},
+			method: http.MethodGet,
+			id:     docID,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPost,
+			id:     docID,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_invalid_id",
+			mockUp: func() {},
+			method: http.MethodGet,
+			id:     "test",
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_not_owner",
+			mockUp: func() {
+				mockService.EXPECT().GetGrants(gomock.Any(), "test", docID).Return(nil, pq.ErrDocumentNotFound)
+			},
+			method: http.MethodGet,
+			id:     docID,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "error_get_grants",
+			mockUp: func() {
+				mockService.EXPECT().GetGrants(gomock.Any(), "test", docID).Return(nil, errors.New("error"))
+			},
+			method: http.MethodGet,
+			id:     docID,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/docs/"+tt.id+"/grants", nil)
+			r.SetPathValue("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.GetGrants(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_AddGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_add_grants",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, "").Return([]models.Grant{{Login: "reader01"}}, nil)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodGet,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_body",
+			mockUp: func() {},
+			method: http.MethodPost,
+			body:   `{`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_no_logins",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, nil, nil, "").Return(nil, docs.ErrGrantLogins)
+			},
+			method: http.MethodPost,
+			body:   `{}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "success_add_grants_with_permission",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, models.PermissionWrite).
+					Return([]models.Grant{{Login: "reader01", Permission: models.PermissionWrite}}, nil)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"], "permission": "write"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "error_invalid_permission",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, "owner").Return(nil, docs.ErrGrantPermission)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"], "permission": "owner"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_permission_denied",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, "").Return(nil, pq.ErrPermissionDenied)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "error_unknown_login",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"unknown1"}, nil, "").
+					Return(nil, fmt.Errorf("%w: unknown1", pq.ErrGrantUserNotFound))
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["unknown1"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "success_add_group_grants",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, nil, []string{"team"}, models.PermissionRead).
+					Return([]models.Grant{{Group: "team", Permission: models.PermissionRead}}, nil)
+			},
+			method: http.MethodPost,
+			body:   `{"groups": ["team"], "permission": "read"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "error_unknown_group",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, nil, []string{"ghosts"}, "").
+					Return(nil, fmt.Errorf("%w: ghosts", pq.ErrGrantGroupNotFound))
+			},
+			method: http.MethodPost,
+			body:   `{"groups": ["ghosts"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_not_owner",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, "").Return(nil, pq.ErrDocumentNotFound)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "error_add_grants",
+			mockUp: func() {
+				mockService.EXPECT().AddGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil, "").Return(nil, errors.New("error"))
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/docs/"+docID+"/grants", strings.NewReader(tt.body))
+			r.SetPathValue("id", docID)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.AddGrants(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_delete_grants",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil).Return([]models.Grant{}, nil)
+			},
+			method: http.MethodDelete,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name: "success_delete_group_grants",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGrants(gomock.Any(), "test", docID, nil, []string{"team"}).Return([]models.Grant{}, nil)
+			},
+			method: http.MethodDelete,
+			body:   `{"groups": ["team"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_body",
+			mockUp: func() {},
+			method: http.MethodDelete,
+			body:   ``,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_not_owner",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil).Return(nil, pq.ErrDocumentNotFound)
+			},
+			method: http.MethodDelete,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "error_delete_grants",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGrants(gomock.Any(), "test", docID, []string{"reader01"}, nil).Return(nil, errors.New("error"))
+			},
+			method: http.MethodDelete,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				service: mockService,
+				log:     log,
+			}
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/docs/"+docID+"/grants", strings.NewReader(tt.body))
+			r.SetPathValue("id", docID)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.DeleteGrants(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	want := &Handler{
+		service: mockService,
+		log:     log,
+	}
+	if got := NewHandler(mockService, log); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewHandler() = %v, want %v", got, want)
+	}
+}
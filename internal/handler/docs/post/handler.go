@@ -4,8 +4,10 @@ import (
 	"caching_web_server/internal/helper"
 	"caching_web_server/internal/middleware"
 	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
@@ -95,6 +97,11 @@ func (h *Handler) SaveDocument(w http.ResponseWriter, r *http.Request) {
 	err = h.service.SaveDocument(r.Context(), login, meta, jsonData, fileData)
 	if err != nil {
 		h.log.Error("SaveDocument", "failed to save document", err)
+		// доступ можно открыть только существующим пользователям
+		if errors.Is(err, pq.ErrGrantUserNotFound) {
+			helper.FailResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helper.FailResponse(w, http.StatusInternalServerError, "failed to save document")
 		return
 	}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"caching_web_server/internal/middleware"
 	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"mime/multipart"
@@ -179,6 +181,28 @@ func TestHandler_SaveDocument(t *testing.T) {
 			},
 			code: http.StatusInternalServerError,
 		},
+		{
+			name: "error_unknown_grant_login",
+			fields: fields{
+				service: mockService,
+				log:     log,
+				maxSize: 10 << 20,
+			},
+			body: models.Meta{
+				Name:   "test",
+				File:   true,
+				Token:  "test",
+				Mime:   "image/jpg",
+				Grants: []string{"unknown1"},
+			},
+			meta: true,
+			file: true,
+			mockUp: func() {
+				mockService.EXPECT().SaveDocument(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("%w: unknown1", pq.ErrGrantUserNotFound))
+			},
+			code: http.StatusBadRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
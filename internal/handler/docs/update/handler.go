@@ -0,0 +1,174 @@
+package update
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=handler.go -destination=handler_mock.go -package=update
+type service interface {
+	UpdateDocument(ctx context.Context, login, docID string, meta models.Meta, jsonData, file []byte) error
+	PatchDocument(ctx context.Context, login, docID string, patch models.DocumentPatch) error
+}
+
+type Handler struct {
+	service service
+	log     *slog.Logger
+	maxSize int64
+}
+
+func NewHandler(service service, log *slog.Logger, maxSize int64) *Handler {
+	return &Handler{
+		service: service,
+		log:     log,
+		maxSize: maxSize,
+	}
+}
+
+// UpdateDocument - ручка замены содержимого документа, multipart как при загрузке: file и/или json, meta по желанию
+// из meta берутся только name и mime, публичность и доступ меняются отдельно
+func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.log.Error("UpdateDocument", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, docID, ok := h.request(w, r, "UpdateDocument")
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize)
+	if err := r.ParseMultipartForm(h.maxSize); err != nil {
+		h.log.Error("UpdateDocument", "failed to parse multipart form", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to parse multipart form")
+		return
+	}
+
+	// meta
+	var meta models.Meta
+	if metaStr := r.FormValue("meta"); metaStr != "" {
+		if err := json.Unmarshal([]byte(metaStr), &meta); err != nil {
+			h.log.Error("UpdateDocument", "failed to unmarshal meta", err)
+			helper.FailResponse(w, http.StatusBadRequest, "failed to unmarshal meta")
+			return
+		}
+	}
+
+	// json
+	var jsonData []byte
+	if jsonStr := r.FormValue("json"); jsonStr != "" {
+		if !json.Valid([]byte(jsonStr)) {
+			h.log.Error("UpdateDocument", "error", "invalid json")
+			helper.FailResponse(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		jsonData = json.RawMessage(jsonStr)
+	}
+
+	// file
+	var fileData []byte
+	file, _, err := r.FormFile("file")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		h.log.Error("UpdateDocument", "failed to get file", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to get file")
+		return
+	}
+	if file != nil {
+		defer func() {
+			if err := file.Close(); err != nil {
+				h.log.Error("UpdateDocument", "failed to close file", err)
+			}
+		}()
+		fileData, err = io.ReadAll(file)
+		if err != nil {
+			h.log.Error("UpdateDocument", "failed to read file", err)
+			helper.FailResponse(w, http.StatusBadRequest, "failed to read file")
+			return
+		}
+	}
+
+	if err := h.service.UpdateDocument(r.Context(), login, docID, meta, jsonData, fileData); err != nil {
+		h.log.Error("UpdateDocument", "failed to update document", err)
+		h.fail(w, err, "failed to update document")
+		return
+	}
+
+	helper.OkResponse(w, map[string]bool{docID: true})
+}
+
+// PatchDocument - ручка изменения метаданных документа: {"name", "mime", "public", "json"}, отсутствующие поля не меняются
+func (h *Handler) PatchDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		h.log.Error("PatchDocument", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, docID, ok := h.request(w, r, "PatchDocument")
+	if !ok {
+		return
+	}
+
+	var patch models.DocumentPatch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxSize)).Decode(&patch); err != nil {
+		h.log.Error("PatchDocument", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	if err := h.service.PatchDocument(r.Context(), login, docID, patch); err != nil {
+		h.log.Error("PatchDocument", "failed to patch document", err)
+		h.fail(w, err, "failed to patch document")
+		return
+	}
+
+	helper.OkResponse(w, map[string]bool{docID: true})
+}
+
+// request - логин из контекста и id документа из пути /api/docs/{id}
+func (h *Handler) request(w http.ResponseWriter, r *http.Request, op string) (string, string, bool) {
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error(op, "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return "", "", false
+	}
+
+	docID := r.PathValue("id")
+	if _, err := uuid.Parse(docID); err != nil {
+		h.log.Error(op, "failed to parse document id", err)
+		helper.FailResponse(w, http.StatusBadRequest, "invalid document id")
+		return "", "", false
+	}
+
+	return login, docID, true
+}
+
+// fail - ответ на ошибку сервиса: документ без доступа неотличим от несуществующего
+func (h *Handler) fail(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, pq.ErrDocumentNotFound):
+		helper.FailResponse(w, http.StatusNotFound, "document not found")
+	case errors.Is(err, pq.ErrPermissionDenied):
+		helper.FailResponse(w, http.StatusForbidden, "insufficient document permission")
+	case errors.Is(err, pq.ErrDocumentConflict):
+		helper.FailResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, docs.ErrDocumentContent), errors.Is(err, docs.ErrDocumentPatch), errors.Is(err, docs.ErrDocumentName):
+		helper.FailResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		helper.FailResponse(w, http.StatusInternalServerError, message)
+	}
+}
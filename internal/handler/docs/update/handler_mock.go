@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package update is a generated GoMock package.
+package update
+
+import (
+	models "caching_web_server/internal/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockservice is a mock of service interface.
+type Mockservice struct {
+	ctrl     *gomock.Controller
+	recorder *MockserviceMockRecorder
+}
+
+// MockserviceMockRecorder is the mock recorder for Mockservice.
+type MockserviceMockRecorder struct {
+	mock *Mockservice
+}
+
+// NewMockservice creates a new mock instance.
+func NewMockservice(ctrl *gomock.Controller) *Mockservice {
+	mock := &Mockservice{ctrl: ctrl}
+	mock.recorder = &MockserviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
+	return m.recorder
+}
+
+// PatchDocument mocks base method.
+func (m *Mockservice) PatchDocument(ctx context.Context, login, docID string, patch models.DocumentPatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchDocument", ctx, login, docID, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchDocument indicates an expected call of PatchDocument.
+func (mr *MockserviceMockRecorder) PatchDocument(ctx, login, docID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchDocument", reflect.TypeOf((*Mockservice)(nil).PatchDocument), ctx, login, docID, patch)
+}
+
+// UpdateDocument mocks base method.
+func (m *Mockservice) UpdateDocument(ctx context.Context, login, docID string, meta models.Meta, jsonData, file []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDocument", ctx, login, docID, meta, jsonData, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDocument indicates an expected call of UpdateDocument.
+func (mr *MockserviceMockRecorder) UpdateDocument(ctx, login, docID, meta, jsonData, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDocument", reflect.TypeOf((*Mockservice)(nil).UpdateDocument), ctx, login, docID, meta, jsonData, file)
+}
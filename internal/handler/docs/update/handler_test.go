@@ -0,0 +1,271 @@
+package update
+
+import (
+	"bytes"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+const docID = "2b7e1a8c-6f0d-4c55-9a0a-3f2a1f6c8d10"
+
+// createMultipart - тело PUT-запроса из непустых частей
+func createMultipart(t *testing.T, meta, json, file string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, value := range map[string]string{"meta": meta, "json": json} {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file != "" {
+		part, err := writer.CreateFormFile("file", "report.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf, writer.FormDataContentType()
+}
+
+func TestHandler_UpdateDocument(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		id     string
+		meta   string
+		json   string
+		file   string
+		code   int
+	}{
+		{
+			name: "success_replace_file",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{Name: "report.md"}, nil, []byte("new")).Return(nil)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			meta:   `{"name": "report.md"}`,
+			file:   "new",
+			code:   http.StatusOK,
+		},
+		{
+			name: "success_replace_json",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{}, []byte(`{"a": 1}`), nil).Return(nil)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			json:   `{"a": 1}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPost,
+			id:     docID,
+			file:   "new",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_invalid_id",
+			mockUp: func() {},
+			method: http.MethodPut,
+			id:     "test",
+			file:   "new",
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "error_invalid_json",
+			mockUp: func() {},
+			method: http.MethodPut,
+			id:     docID,
+			json:   `{`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_no_content",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{Name: "report.md"}, nil, nil).Return(docs.ErrDocumentContent)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			meta:   `{"name": "report.md"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_read_only",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{}, nil, []byte("new")).Return(pq.ErrPermissionDenied)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			file:   "new",
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "error_not_found",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{}, nil, []byte("new")).Return(pq.ErrDocumentNotFound)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			file:   "new",
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "error_conflict",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{}, nil, []byte("new")).Return(pq.ErrDocumentConflict)
+			},
+			method: http.MethodPut,
+			id:     docID,
+			file:   "new",
+			code:   http.StatusConflict,
+		},
+		{
+			name: "error_update_document",
+			mockUp: func() {
+				mockService.EXPECT().UpdateDocument(gomock.Any(), "test", docID, models.Meta{}, nil, []byte("new")).Return(errors.New("error"))
+			},
+			method: http.MethodPut,
+			id:     docID,
+			file:   "new",
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log, 1<<20)
+			tt.mockUp()
+			body, contentType := createMultipart(t, tt.meta, tt.json, tt.file)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/docs/"+tt.id, body)
+			r.Header.Set("Content-Type", contentType)
+			r.SetPathValue("id", tt.id)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.UpdateDocument(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_PatchDocument(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_patch_document",
+			mockUp: func() {
+				mockService.EXPECT().PatchDocument(gomock.Any(), "test", docID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, patch models.DocumentPatch) error {
+						if patch.Name == nil || *patch.Name != "notes.txt" || patch.Public == nil || *patch.Public || patch.Mime != nil {
+							t.Errorf("PatchDocument() patch = %+v", patch)
+						}
+						return nil
+					})
+			},
+			method: http.MethodPatch,
+			body:   `{"name": "notes.txt", "public": false}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPut,
+			body:   `{"public": true}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_body",
+			mockUp: func() {},
+			method: http.MethodPatch,
+			body:   `{`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_empty_patch",
+			mockUp: func() {
+				mockService.EXPECT().PatchDocument(gomock.Any(), "test", docID, models.DocumentPatch{}).Return(docs.ErrDocumentPatch)
+			},
+			method: http.MethodPatch,
+			body:   `{}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_public_without_manage",
+			mockUp: func() {
+				mockService.EXPECT().PatchDocument(gomock.Any(), "test", docID, gomock.Any()).Return(pq.ErrPermissionDenied)
+			},
+			method: http.MethodPatch,
+			body:   `{"public": true}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name: "error_not_found",
+			mockUp: func() {
+				mockService.EXPECT().PatchDocument(gomock.Any(), "test", docID, gomock.Any()).Return(pq.ErrDocumentNotFound)
+			},
+			method: http.MethodPatch,
+			body:   `{"public": true}`,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log, 1<<20)
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/docs/"+docID, strings.NewReader(tt.body))
+			r.SetPathValue("id", docID)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.PatchDocument(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package groups
+
+import (
+	"caching_web_server/internal/helper"
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+//go:generate mockgen -source=handler.go -destination=handler_mock.go -package=groups
+type service interface {
+	CreateGroup(ctx context.Context, login, name string) (models.Group, error)
+	GetGroups(ctx context.Context, login string) ([]models.Group, error)
+	AddGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error)
+	DeleteGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error)
+}
+
+type Handler struct {
+	service service
+	log     *slog.Logger
+}
+
+func NewHandler(service service, log *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetGroups - ручка списка групп, которыми пользователь владеет или в которых состоит
+func (h *Handler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.log.Error("GetGroups", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := h.login(w, r, "GetGroups")
+	if !ok {
+		return
+	}
+
+	groups, err := h.service.GetGroups(r.Context(), login)
+	if err != nil {
+		h.log.Error("GetGroups", "failed to get groups", err)
+		h.fail(w, err, "failed to get groups")
+		return
+	}
+
+	helper.OkDataResponse(w, groups)
+}
+
+// CreateGroup - ручка создания группы: {"name": "..."}
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("CreateGroup", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := h.login(w, r, "CreateGroup")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("CreateGroup", "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return
+	}
+
+	group, err := h.service.CreateGroup(r.Context(), login, req.Name)
+	if err != nil {
+		h.log.Error("CreateGroup", "failed to create group", err)
+		h.fail(w, err, "failed to create group")
+		return
+	}
+
+	helper.OkDataResponse(w, group)
+}
+
+// AddMembers - ручка добавления участников в группу /api/groups/{name}/members: {"logins": [...]}
+func (h *Handler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.log.Error("AddMembers", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := h.login(w, r, "AddMembers")
+	if !ok {
+		return
+	}
+
+	logins, ok := h.decodeMembers(w, r, "AddMembers")
+	if !ok {
+		return
+	}
+
+	group, err := h.service.AddGroupMembers(r.Context(), login, r.PathValue("name"), logins)
+	if err != nil {
+		h.log.Error("AddMembers", "failed to add members", err)
+		h.fail(w, err, "failed to add members")
+		return
+	}
+
+	helper.OkDataResponse(w, group)
+}
+
+// DeleteMembers - ручка исключения участников из группы /api/groups/{name}/members: {"logins": [...]}
+func (h *Handler) DeleteMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.log.Error("DeleteMembers", "error", "invalid method")
+		helper.FailResponse(w, http.StatusMethodNotAllowed, "invalid method")
+		return
+	}
+
+	login, ok := h.login(w, r, "DeleteMembers")
+	if !ok {
+		return
+	}
+
+	logins, ok := h.decodeMembers(w, r, "DeleteMembers")
+	if !ok {
+		return
+	}
+
+	group, err := h.service.DeleteGroupMembers(r.Context(), login, r.PathValue("name"), logins)
+	if err != nil {
+		h.log.Error("DeleteMembers", "failed to delete members", err)
+		h.fail(w, err, "failed to delete members")
+		return
+	}
+
+	helper.OkDataResponse(w, group)
+}
+
+// login - логин из контекста
+func (h *Handler) login(w http.ResponseWriter, r *http.Request, op string) (string, bool) {
+	login, ok := r.Context().Value(middleware.NameLogin).(string)
+	if !ok {
+		h.log.Error(op, "error", "failed to get login from context")
+		helper.FailResponse(w, http.StatusInternalServerError, "failed to get login from context")
+		return "", false
+	}
+	return login, true
+}
+
+// decodeMembers - логины из тела запроса на изменение состава группы
+func (h *Handler) decodeMembers(w http.ResponseWriter, r *http.Request, op string) ([]string, bool) {
+	var req struct {
+		Logins []string `json:"logins"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error(op, "failed to decode request", err)
+		helper.FailResponse(w, http.StatusBadRequest, "failed to decode request")
+		return nil, false
+	}
+	return req.Logins, true
+}
+
+// fail - ответ на ошибку сервиса: чужая группа неотличима от несуществующей
+func (h *Handler) fail(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, pq.ErrGroupNotFound):
+		helper.FailResponse(w, http.StatusNotFound, "group not found")
+	case errors.Is(err, pq.ErrGroupExists):
+		helper.FailResponse(w, http.StatusConflict, "group already exists")
+	case errors.Is(err, docs.ErrGroupName), errors.Is(err, docs.ErrGroupMembers), errors.Is(err, pq.ErrMemberNotFound):
+		helper.FailResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		helper.FailResponse(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package groups is a generated GoMock package.
+package groups
+
+import (
+	models "caching_web_server/internal/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockservice is a mock of service interface.
+type Mockservice struct {
+	ctrl     *gomock.Controller
+	recorder *MockserviceMockRecorder
+}
+
+// MockserviceMockRecorder is the mock recorder for Mockservice.
+type MockserviceMockRecorder struct {
+	mock *Mockservice
+}
+
+// NewMockservice creates a new mock instance.
+func NewMockservice(ctrl *gomock.Controller) *Mockservice {
+	mock := &Mockservice{ctrl: ctrl}
+	mock.recorder = &MockserviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
+	return m.recorder
+}
+
+// AddGroupMembers mocks base method.
+func (m *Mockservice) AddGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMembers", ctx, login, name, logins)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGroupMembers indicates an expected call of AddGroupMembers.
+func (mr *MockserviceMockRecorder) AddGroupMembers(ctx, login, name, logins interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMembers", reflect.TypeOf((*Mockservice)(nil).AddGroupMembers), ctx, login, name, logins)
+}
+
+// CreateGroup mocks base method.
+func (m *Mockservice) CreateGroup(ctx context.Context, login, name string) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, login, name)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockserviceMockRecorder) CreateGroup(ctx, login, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*Mockservice)(nil).CreateGroup), ctx, login, name)
+}
+
+// DeleteGroupMembers mocks base method.
+func (m *Mockservice) DeleteGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupMembers", ctx, login, name, logins)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteGroupMembers indicates an expected call of DeleteGroupMembers.
+func (mr *MockserviceMockRecorder) DeleteGroupMembers(ctx, login, name, logins interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupMembers", reflect.TypeOf((*Mockservice)(nil).DeleteGroupMembers), ctx, login, name, logins)
+}
+
+// GetGroups mocks base method.
+func (m *Mockservice) GetGroups(ctx context.Context, login string) ([]models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", ctx, login)
+	ret0, _ := ret[0].([]models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockserviceMockRecorder) GetGroups(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*Mockservice)(nil).GetGroups), ctx, login)
+}
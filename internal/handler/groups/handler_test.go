@@ -0,0 +1,289 @@
+package groups
+
+import (
+	"caching_web_server/internal/middleware"
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/service/docs"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestHandler_GetGroups(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		code   int
+	}{
+		{
+			name: "success_get_groups",
+			mockUp: func() {
+				mockService.EXPECT().GetGroups(gomock.Any(), "test").Return([]models.Group{{Name: "team"}}, nil)
+			},
+			method: http.MethodGet,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPut,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name: "error_get_groups",
+			mockUp: func() {
+				mockService.EXPECT().GetGroups(gomock.Any(), "test").Return(nil, errors.New("error"))
+			},
+			method: http.MethodGet,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log)
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/groups", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.GetGroups(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_CreateGroup(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_create_group",
+			mockUp: func() {
+				mockService.EXPECT().CreateGroup(gomock.Any(), "test", "team").Return(models.Group{Name: "team", Owner: "test"}, nil)
+			},
+			method: http.MethodPost,
+			body:   `{"name": "team"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodGet,
+			body:   `{"name": "team"}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_body",
+			mockUp: func() {},
+			method: http.MethodPost,
+			body:   `{`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_group_name",
+			mockUp: func() {
+				mockService.EXPECT().CreateGroup(gomock.Any(), "test", "a").Return(models.Group{}, docs.ErrGroupName)
+			},
+			method: http.MethodPost,
+			body:   `{"name": "a"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_group_exists",
+			mockUp: func() {
+				mockService.EXPECT().CreateGroup(gomock.Any(), "test", "team").Return(models.Group{}, pq.ErrGroupExists)
+			},
+			method: http.MethodPost,
+			body:   `{"name": "team"}`,
+			code:   http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log)
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/groups", strings.NewReader(tt.body))
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.CreateGroup(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_AddMembers(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_add_members",
+			mockUp: func() {
+				mockService.EXPECT().AddGroupMembers(gomock.Any(), "test", "team", []string{"reader01"}).
+					Return(models.Group{Name: "team", Members: []string{"reader01", "test"}}, nil)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodGet,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name: "error_no_logins",
+			mockUp: func() {
+				mockService.EXPECT().AddGroupMembers(gomock.Any(), "test", "team", nil).Return(models.Group{}, docs.ErrGroupMembers)
+			},
+			method: http.MethodPost,
+			body:   `{}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_unknown_member",
+			mockUp: func() {
+				mockService.EXPECT().AddGroupMembers(gomock.Any(), "test", "team", []string{"unknown1"}).
+					Return(models.Group{}, fmt.Errorf("%w: unknown1", pq.ErrMemberNotFound))
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["unknown1"]}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_not_group_owner",
+			mockUp: func() {
+				mockService.EXPECT().AddGroupMembers(gomock.Any(), "test", "team", []string{"reader01"}).Return(models.Group{}, pq.ErrGroupNotFound)
+			},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log)
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/groups/team/members", strings.NewReader(tt.body))
+			r.SetPathValue("name", "team")
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.AddMembers(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteMembers(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name   string
+		mockUp func()
+		method string
+		body   string
+		code   int
+	}{
+		{
+			name: "success_delete_members",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGroupMembers(gomock.Any(), "test", "team", []string{"reader01"}).
+					Return(models.Group{Name: "team", Members: []string{"test"}}, nil)
+			},
+			method: http.MethodDelete,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_method",
+			mockUp: func() {},
+			method: http.MethodPost,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "error_body",
+			mockUp: func() {},
+			method: http.MethodDelete,
+			body:   ``,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name: "error_delete_members",
+			mockUp: func() {
+				mockService.EXPECT().DeleteGroupMembers(gomock.Any(), "test", "team", []string{"reader01"}).Return(models.Group{}, errors.New("error"))
+			},
+			method: http.MethodDelete,
+			body:   `{"logins": ["reader01"]}`,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(mockService, log)
+			tt.mockUp()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/api/groups/team/members", strings.NewReader(tt.body))
+			r.SetPathValue("name", "team")
+			r = r.WithContext(context.WithValue(r.Context(), middleware.NameLogin, "test"))
+
+			h.DeleteMembers(w, r)
+			if w.Code != tt.code {
+				t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, tt.code)
+			}
+		})
+	}
+}
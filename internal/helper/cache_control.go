@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+)
+
+// CachePolicy - правила заголовка Cache-Control
+type CachePolicy struct {
+	PublicMaxAge  time.Duration
+	PrivateMaxAge time.Duration
+	// PrivateNoStore - запретить клиенту хранить приватные ответы, перекрывает PrivateMaxAge
+	PrivateNoStore bool
+}
+
+// CacheControl - значение Cache-Control для публичного или приватного ответа
+// публичные ответы можно хранить в общих кешах, приватные - только в кеше клиента
+// при PrivateMaxAge <= 0 клиент хранит приватный ответ, но перепроверяет его по ETag перед каждым использованием
+func (p CachePolicy) CacheControl(public bool) string {
+	if public {
+		return fmt.Sprintf("public, max-age=%d", int(p.PublicMaxAge.Seconds()))
+	}
+	if p.PrivateNoStore {
+		return "private, no-store"
+	}
+	if p.PrivateMaxAge <= 0 {
+		return "private, no-cache"
+	}
+	return fmt.Sprintf("private, max-age=%d", int(p.PrivateMaxAge.Seconds()))
+}
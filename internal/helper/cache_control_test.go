@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachePolicy_CacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CachePolicy
+		public bool
+		want   string
+	}{
+		{
+			name:   "public",
+			policy: CachePolicy{PublicMaxAge: time.Hour},
+			public: true,
+			want:   "public, max-age=3600",
+		},
+		{
+			name:   "private_no_cache",
+			policy: CachePolicy{PublicMaxAge: time.Hour},
+			public: false,
+			want:   "private, no-cache",
+		},
+		{
+			name:   "private_no_store",
+			policy: CachePolicy{PublicMaxAge: time.Hour, PrivateMaxAge: time.Minute, PrivateNoStore: true},
+			public: false,
+			want:   "private, no-store",
+		},
+		{
+			name:   "public_ignores_no_store",
+			policy: CachePolicy{PublicMaxAge: time.Hour, PrivateNoStore: true},
+			public: true,
+			want:   "public, max-age=3600",
+		},
+		{
+			name:   "private_max_age",
+			policy: CachePolicy{PublicMaxAge: time.Hour, PrivateMaxAge: time.Minute},
+			public: false,
+			want:   "private, max-age=60",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CacheControl(tt.public); got != tt.want {
+				t.Errorf("CacheControl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modTime := time.Date(2025, 8, 13, 17, 57, 37, 500, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		etag    string
+		modTime time.Time
+		want    bool
+	}{
+		{
+			name: "no_headers",
+			etag: `"abc"`,
+			want: false,
+		},
+		{
+			name:    "if_none_match_equal",
+			headers: map[string]string{"If-None-Match": `"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "if_none_match_list",
+			headers: map[string]string{"If-None-Match": `"xyz", W/"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "if_none_match_star",
+			headers: map[string]string{"If-None-Match": `*`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "if_none_match_other",
+			headers: map[string]string{"If-None-Match": `"xyz"`},
+			etag:    `"abc"`,
+			want:    false,
+		},
+		{
+			name:    "if_none_match_without_etag",
+			headers: map[string]string{"If-None-Match": `"abc"`},
+			etag:    "",
+			want:    false,
+		},
+		{
+			name: "if_none_match_wins_over_if_modified_since",
+			headers: map[string]string{
+				"If-None-Match":     `"xyz"`,
+				"If-Modified-Since": modTime.Format(http.TimeFormat),
+			},
+			etag:    `"abc"`,
+			modTime: modTime,
+			want:    false,
+		},
+		{
+			name:    "if_modified_since_equal",
+			headers: map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)},
+			modTime: modTime,
+			want:    true,
+		},
+		{
+			name:    "if_modified_since_older",
+			headers: map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)},
+			modTime: modTime,
+			want:    false,
+		},
+		{
+			name:    "if_modified_since_invalid",
+			headers: map[string]string{"If-Modified-Since": "yesterday"},
+			modTime: modTime,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := NotModified(r, tt.etag, tt.modTime); got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteNotModified(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "application/json")
+	WriteNotModified(w)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Status code is not correct. Got %d, want %d.", w.Code, http.StatusNotModified)
+	}
+	if w.Header().Get("Content-Type") != "" {
+		t.Errorf("Content-Type must be removed")
+	}
+}
@@ -0,0 +1,176 @@
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+//go:generate mockgen -source=limiter.go -destination=limiter_mock.go -package=lockout
+
+// Store - хранилище счетчиков: MemoryStore для одного экземпляра или pq.AttemptStore для кластера
+type Store interface {
+	// Attempt - атомарно учитывает попытку входа до проверки пароля: если ключ заблокирован, возвращает блокировку и ничего не считает,
+	// иначе увеличивает счетчик (заново, если прошлой попытке больше window) и, если delay от нового значения больше нуля, блокирует ключ
+	// нулевое время - попытка учтена и ее можно проверять
+	Attempt(ctx context.Context, key string, window time.Duration, delay func(failures int) time.Duration) (time.Time, error)
+	// Release - отменяет учтенную попытку, которая не оказалась неудачей; блокировка снимается, если счетчик стал меньше threshold
+	Release(ctx context.Context, key string, threshold int) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	Reset(ctx context.Context, key string) error
+	// Cleanup - удаляет счетчики без попыток после before и без действующей блокировки
+	Cleanup(ctx context.Context, before time.Time) error
+}
+
+// Policy - правила блокировки
+type Policy struct {
+	// Threshold - после стольких неудач подряд логин блокируется, IPThreshold - то же для адреса
+	Threshold   int
+	IPThreshold int
+	// BaseDelay - первая блокировка, каждая следующая неудача удваивает ее вплоть до MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window - через сколько после последней неудачи счетчик забывается
+	Window time.Duration
+}
+
+// Limiter - учет неудачных входов по логину и по адресу
+type Limiter struct {
+	store  Store
+	policy Policy
+	log    *slog.Logger
+	now    func() time.Time
+}
+
+// NewLimiter - конструктор
+func NewLimiter(store Store, policy Policy, log *slog.Logger) *Limiter {
+	return &Limiter{
+		store:  store,
+		policy: policy,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+func loginKey(login string) string {
+	return "login:" + login
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check - учитывает попытку до проверки пароля и возвращает, сколько еще ждать, 0 - можно проверять
+// попытка, на которой счетчик достигает порога, сразу блокирует ключ, поэтому параллельные попытки сверх порога получают отказ;
+// после проверки попытку надо завершить через Fail, Success или Release; при недоступном хранилище вход не блокируем
+func (l *Limiter) Check(ctx context.Context, login, ip string) time.Duration {
+	if wait := l.attempt(ctx, loginKey(login), l.policy.Threshold); wait > 0 {
+		return wait
+	}
+	if wait := l.attempt(ctx, ipKey(ip), l.policy.IPThreshold); wait > 0 {
+		l.release(ctx, loginKey(login), l.policy.Threshold)
+		return wait
+	}
+	return 0
+}
+
+// Fail - попытка оказалась неудачей, она уже учтена в Check; возвращает время блокировки, если она наступила
+func (l *Limiter) Fail(ctx context.Context, login, ip string) time.Duration {
+	var wait time.Duration
+	for _, key := range []string{loginKey(login), ipKey(ip)} {
+		until, err := l.store.LockedUntil(ctx, key)
+		if err != nil {
+			l.log.Error("Fail", "failed to get lock", err)
+			continue
+		}
+		if d := until.Sub(l.now()); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Success - успешный вход сбрасывает счетчик логина, а попытка не засчитывается адресу
+func (l *Limiter) Success(ctx context.Context, login, ip string) {
+	if err := l.store.Reset(ctx, loginKey(login)); err != nil {
+		l.log.Error("Success", "failed to reset counter", err)
+	}
+	l.release(ctx, ipKey(ip), l.policy.IPThreshold)
+}
+
+// Release - попытка не дошла до решения о пароле (ошибка сервера, ожидание второго фактора), она не засчитывается
+func (l *Limiter) Release(ctx context.Context, login, ip string) {
+	l.release(ctx, loginKey(login), l.policy.Threshold)
+	l.release(ctx, ipKey(ip), l.policy.IPThreshold)
+}
+
+// Unlock - снимает блокировку логина и адреса, пустые пропускаются
+func (l *Limiter) Unlock(ctx context.Context, login, ip string) error {
+	var keys []string
+	if login != "" {
+		keys = append(keys, loginKey(login))
+	}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	for _, key := range keys {
+		if err := l.store.Reset(ctx, key); err != nil {
+			l.log.Error("Unlock", "failed to reset counter", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt - учитывает попытку по ключу, возвращает, сколько ждать, если ключ уже заблокирован
+func (l *Limiter) attempt(ctx context.Context, key string, threshold int) time.Duration {
+	delay := func(failures int) time.Duration {
+		if threshold <= 0 || failures < threshold {
+			return 0
+		}
+		d := l.delay(failures - threshold)
+		l.log.Warn("Check", "locked", key, "failures", failures, "for", d)
+		return d
+	}
+	until, err := l.store.Attempt(ctx, key, l.policy.Window, delay)
+	if err != nil {
+		l.log.Error("Check", "failed to register attempt", err)
+		return 0
+	}
+	return max(until.Sub(l.now()), 0)
+}
+
+func (l *Limiter) release(ctx context.Context, key string, threshold int) {
+	if err := l.store.Release(ctx, key, threshold); err != nil {
+		l.log.Error("Release", "failed to release attempt", err)
+	}
+}
+
+// Run - периодически чистит забытые счетчики
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.Cleanup(ctx, l.now().Add(-l.policy.Window)); err != nil {
+				l.log.Error("Run", "failed to cleanup counters", err)
+			}
+		}
+	}
+}
+
+// delay - BaseDelay * 2^over, но не больше MaxDelay
+func (l *Limiter) delay(over int) time.Duration {
+	d := l.policy.BaseDelay
+	for i := 0; i < over && d < l.policy.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > l.policy.MaxDelay {
+		d = l.policy.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,192 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+var errStore = errors.New("store error")
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestLimiter(policy Policy) (*Limiter, *clock) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	c := &clock{t: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+
+	store := NewMemoryStore()
+	store.now = c.now
+	l := NewLimiter(store, policy, log)
+	l.now = c.now
+	return l, c
+}
+
+// fail - попытка с неверным паролем: учет до проверки и итог после
+func fail(ctx context.Context, l *Limiter, login, ip string) time.Duration {
+	if wait := l.Check(ctx, login, ip); wait > 0 {
+		return wait
+	}
+	return l.Fail(ctx, login, ip)
+}
+
+func TestLimiter_LoginLockout(t *testing.T) {
+	ctx := context.Background()
+	l, c := newTestLimiter(Policy{
+		Threshold:   3,
+		IPThreshold: 100,
+		BaseDelay:   time.Minute,
+		MaxDelay:    5 * time.Minute,
+		Window:      time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		if d := fail(ctx, l, "user", "10.0.0.1"); d != 0 {
+			t.Fatalf("fail #%d = %v, want no lock before threshold", i+1, d)
+		}
+	}
+
+	// третья неудача - блокировка на BaseDelay, после каждой следующей она удваивается до MaxDelay
+	wants := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, want := range wants {
+		if d := fail(ctx, l, "user", "10.0.0.1"); d != want {
+			t.Errorf("fail #%d = %v, want %v", i+3, d, want)
+		}
+		if d := l.Check(ctx, "user", "10.0.0.2"); d != want {
+			t.Errorf("Check() = %v, want lock by login from any address", d)
+		}
+		c.t = c.t.Add(want + time.Second)
+	}
+	if d := l.Check(ctx, "other", "10.0.0.1"); d != 0 {
+		t.Errorf("Check() = %v, want other login not locked", d)
+	}
+
+	if err := l.Unlock(ctx, "user", ""); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if d := fail(ctx, l, "user", "10.0.0.1"); d != 0 {
+		t.Errorf("fail = %v, want counter reset by unlock", d)
+	}
+}
+
+func TestLimiter_ConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter(Policy{
+		Threshold:   3,
+		IPThreshold: 100,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      time.Hour,
+	})
+
+	// пачка попыток до проверки паролей: пропускаются только попытки до порога
+	var allowed int
+	for range 10 {
+		if l.Check(ctx, "user", "10.0.0.1") == 0 {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Check() allowed %d attempts, want 3", allowed)
+	}
+}
+
+func TestLimiter_IPLockout(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter(Policy{
+		Threshold:   100,
+		IPThreshold: 2,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      time.Hour,
+	})
+
+	// перебор разных логинов с одного адреса
+	fail(ctx, l, "user1", "10.0.0.1")
+	if d := fail(ctx, l, "user2", "10.0.0.1"); d != time.Minute {
+		t.Errorf("fail = %v, want %v", d, time.Minute)
+	}
+	if d := l.Check(ctx, "user3", "10.0.0.1"); d != time.Minute {
+		t.Errorf("Check() = %v, want address locked", d)
+	}
+	if d := l.Check(ctx, "user3", "10.0.0.2"); d != 0 {
+		t.Errorf("Check() = %v, want other address not locked", d)
+	}
+
+	if err := l.Unlock(ctx, "", "10.0.0.1"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if d := l.Check(ctx, "user4", "10.0.0.1"); d != 0 {
+		t.Errorf("Check() = %v, want address unlocked", d)
+	}
+}
+
+func TestLimiter_SuccessAndRelease(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter(Policy{
+		Threshold:   2,
+		IPThreshold: 2,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      time.Hour,
+	})
+
+	// успешный вход сбрасывает логин и не засчитывается адресу
+	fail(ctx, l, "user", "10.0.0.1")
+	if d := l.Check(ctx, "user", "10.0.0.1"); d != 0 {
+		t.Fatalf("Check() = %v, want 0", d)
+	}
+	l.Success(ctx, "user", "10.0.0.1")
+	if d := fail(ctx, l, "user", "10.0.0.2"); d != 0 {
+		t.Errorf("fail = %v, want login counter reset after success", d)
+	}
+	if d := l.Check(ctx, "other", "10.0.0.1"); d != 0 {
+		t.Errorf("Check() = %v, want address not locked by success", d)
+	}
+
+	// попытка без решения о пароле снимает и блокировку, которую поставила сама
+	l.Release(ctx, "other", "10.0.0.1")
+	if d := l.Check(ctx, "user", "10.0.0.2"); d != 0 {
+		t.Fatalf("Check() = %v, want 0", d)
+	}
+	l.Release(ctx, "user", "10.0.0.2")
+	if d := l.Check(ctx, "user", "10.0.0.3"); d != 0 {
+		t.Errorf("Check() = %v, want released attempt not counted", d)
+	}
+}
+
+func TestLimiter_StoreErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	mockStore := NewMockStore(ctrl)
+	l := NewLimiter(mockStore, Policy{Threshold: 1, IPThreshold: 1, BaseDelay: time.Minute, MaxDelay: time.Hour}, log)
+	ctx := context.Background()
+
+	// хранилище недоступно - вход не блокируем
+	mockStore.EXPECT().Attempt(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Time{}, errStore).Times(2)
+	if d := l.Check(ctx, "user", "10.0.0.1"); d != 0 {
+		t.Errorf("Check() = %v, want 0", d)
+	}
+
+	mockStore.EXPECT().LockedUntil(gomock.Any(), gomock.Any()).Return(time.Time{}, errStore).Times(2)
+	if d := l.Fail(ctx, "user", "10.0.0.1"); d != 0 {
+		t.Errorf("Fail() = %v, want 0", d)
+	}
+
+	mockStore.EXPECT().Reset(gomock.Any(), "login:user").Return(errStore)
+	if err := l.Unlock(ctx, "user", "10.0.0.1"); !errors.Is(err, errStore) {
+		t.Errorf("Unlock() error = %v, wantErr %v", err, errStore)
+	}
+}
@@ -0,0 +1,97 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type counter struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// MemoryStore - счетчики в памяти процесса, подходит для одного экземпляра
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	now      func() time.Time
+}
+
+// NewMemoryStore - конструктор
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryStore) Attempt(_ context.Context, key string, window time.Duration, delay func(failures int) time.Duration) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	c, ok := m.counters[key]
+	if !ok {
+		c = &counter{}
+		m.counters[key] = c
+	}
+	if c.lockedUntil.After(now) {
+		return c.lockedUntil, nil
+	}
+	if now.Sub(c.lastFailure) > window {
+		c.failures = 0
+	}
+	c.failures++
+	c.lastFailure = now
+	if d := delay(c.failures); d > 0 {
+		c.lockedUntil = now.Add(d)
+	}
+
+	return time.Time{}, nil
+}
+
+func (m *MemoryStore) Release(_ context.Context, key string, threshold int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.counters[key]; ok {
+		c.failures = max(c.failures-1, 0)
+		if c.failures < threshold {
+			c.lockedUntil = time.Time{}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.counters[key]; ok {
+		return c.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+func (m *MemoryStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.counters, key)
+	return nil
+}
+
+func (m *MemoryStore) Cleanup(_ context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, c := range m.counters {
+		if c.lastFailure.Before(before) && !c.lockedUntil.After(now) {
+			delete(m.counters, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	c := &clock{t: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	m := NewMemoryStore()
+	m.now = c.now
+
+	never := func(int) time.Duration { return 0 }
+	for want := 1; want <= 3; want++ {
+		if until, err := m.Attempt(ctx, "k", time.Hour, never); err != nil || !until.IsZero() {
+			t.Fatalf("Attempt() = %v, %v, want counted", until, err)
+		}
+		if got := m.counters["k"].failures; got != want {
+			t.Fatalf("failures = %v, want %v", got, want)
+		}
+	}
+
+	// после окна счетчик начинается заново, попытка на пороге учитывается и блокирует следующие
+	c.t = c.t.Add(2 * time.Hour)
+	until := c.t.Add(time.Minute)
+	lockAt := func(failures int) time.Duration {
+		if failures != 1 {
+			t.Errorf("delay(%d), want 1 after window", failures)
+		}
+		return time.Minute
+	}
+	if got, _ := m.Attempt(ctx, "k", time.Hour, lockAt); !got.IsZero() {
+		t.Errorf("Attempt() = %v, want counted", got)
+	}
+	if got, _ := m.LockedUntil(ctx, "k"); !got.Equal(until) {
+		t.Errorf("LockedUntil() = %v, want %v", got, until)
+	}
+	if got, _ := m.Attempt(ctx, "k", time.Hour, never); !got.Equal(until) {
+		t.Errorf("Attempt() = %v, want rejected until %v", got, until)
+	}
+	if got := m.counters["k"].failures; got != 1 {
+		t.Errorf("failures = %v, want rejected attempt not counted", got)
+	}
+	if got, _ := m.LockedUntil(ctx, "missing"); !got.IsZero() {
+		t.Errorf("LockedUntil() = %v, want zero", got)
+	}
+
+	// действующую блокировку очистка не трогает
+	if err := m.Cleanup(ctx, c.t.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.LockedUntil(ctx, "k"); !got.Equal(until) {
+		t.Errorf("Cleanup() removed active lock")
+	}
+
+	c.t = c.t.Add(2 * time.Minute)
+	if err := m.Cleanup(ctx, c.t); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.counters) != 0 {
+		t.Errorf("Cleanup() left %d counters", len(m.counters))
+	}
+
+	m.Attempt(ctx, "k", time.Hour, never)
+	m.Attempt(ctx, "k", time.Hour, never)
+	if err := m.Release(ctx, "k", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.counters["k"].failures; got != 1 {
+		t.Errorf("Release() failures = %v, want 1", got)
+	}
+	if err := m.Reset(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.counters) != 0 {
+		t.Errorf("Reset() left counter")
+	}
+}
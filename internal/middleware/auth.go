@@ -2,17 +2,37 @@ package middleware
 
 import (
 	"caching_web_server/internal/helper"
+	"caching_web_server/internal/models"
 	"caching_web_server/internal/service/auth"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 const NameLogin = "login"
 
+// NameRole - ключ роли пользователя в контексте запроса
+const NameRole = "role"
+
+// NameScopes - ключ прав API-ключа в контексте запроса, при входе по паролю его нет
+const NameScopes = "scopes"
+
+// realm - область защиты для заголовка WWW-Authenticate
+const realm = "api"
+
+var (
+	ErrNoToken     = errors.New("missing token")
+	ErrInvalidAuth = errors.New("invalid Authorization header")
+)
+
 //go:generate mockgen -source=auth.go -destination=auth_mock.go -package=middleware
 type service interface {
-	VerifyToken(auth string) (string, error)
+	VerifyToken(auth string) (models.Identity, error)
+	VerifyAPIKey(ctx context.Context, key string) (models.Identity, error)
 }
 
 type Middleware struct {
@@ -29,26 +49,133 @@ func NewMiddleware(service service, log *slog.Logger) *Middleware {
 
 func (m *Middleware) Authorize(next http.HandlerFunc) http.HandlerFunc {
 	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(auth.NameCookie)
+		token, err := Token(r)
 		if err != nil {
-			m.log.Error("Authorize", "error", "missing or invalid Authorization header")
+			m.log.Error("Authorize", "error", err.Error())
+			if errors.Is(err, ErrInvalidAuth) {
+				challenge(w, "invalid_request", err.Error())
+			} else {
+				challenge(w, "", "")
+			}
 			helper.FailResponse(w, http.StatusUnauthorized, "missing or invalid Authorization header")
 			return
 		}
 
-		token := cookie.Value
-
-		login, err := m.service.VerifyToken(token)
+		// API-ключи для автоматизации передаются так же, как токен, и отличаются префиксом
+		var id models.Identity
+		if strings.HasPrefix(token, auth.APIKeyPrefix) {
+			id, err = m.service.VerifyAPIKey(r.Context(), token)
+		} else {
+			id, err = m.service.VerifyToken(token)
+		}
 		if err != nil {
 			m.log.Error("Authorize", "error", err.Error())
+			challenge(w, "invalid_token", "the access token is invalid, expired or revoked")
 			helper.FailResponse(w, http.StatusUnauthorized, "Authorize")
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), NameLogin, login)
+		ctx := context.WithValue(r.Context(), NameLogin, id.Login)
+		ctx = context.WithValue(ctx, NameRole, id.Role)
+		if id.Scopes != nil {
+			ctx = context.WithValue(ctx, NameScopes, id.Scopes)
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 
 	return fn
 }
+
+// OptionalAuthorize - как Authorize, но пропускает запрос без токена анонимно
+// логин в контексте есть только у авторизованных, недействительный токен по-прежнему отклоняется
+func (m *Middleware) OptionalAuthorize(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := Token(r); errors.Is(err, ErrNoToken) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.Authorize(next).ServeHTTP(w, r)
+	})
+
+	return fn
+}
+
+// RequireRole - пропускает только пользователей с одной из ролей, ставится после Authorize
+func (m *Middleware) RequireRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, _ := r.Context().Value(NameRole).(string)
+		if !slices.Contains(roles, role) {
+			m.log.Error("RequireRole", "error", "insufficient role", "role", role)
+			helper.FailResponse(w, http.StatusForbidden, "insufficient role")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return fn
+}
+
+// RequireScope - для API-ключей проверяет право scope, запросы с токеном входа ограничивает только роль
+func (m *Middleware) RequireScope(next http.HandlerFunc, scope string) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes, ok := r.Context().Value(NameScopes).([]string)
+		if ok && !slices.Contains(scopes, scope) {
+			m.log.Error("RequireScope", "error", "insufficient scope", "scope", scope)
+			helper.FailResponse(w, http.StatusForbidden, "insufficient scope")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return fn
+}
+
+// RequireSession - пропускает только вход по паролю: API-ключом нельзя управлять ключами
+func (m *Middleware) RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(NameScopes).([]string); ok {
+			m.log.Error("RequireSession", "error", "api key not allowed")
+			helper.FailResponse(w, http.StatusForbidden, "api key not allowed")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return fn
+}
+
+// Token - достает токен из запроса
+// заголовок Authorization: Bearer важнее cookie, cookie смотрим только без заголовка
+func Token(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return "", fmt.Errorf("%w: expected Bearer scheme", ErrInvalidAuth)
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return "", fmt.Errorf("%w: empty token", ErrInvalidAuth)
+		}
+		return token, nil
+	}
+
+	cookie, err := r.Cookie(auth.NameCookie)
+	if err != nil || cookie.Value == "" {
+		return "", ErrNoToken
+	}
+	return cookie.Value, nil
+}
+
+// challenge - заголовок WWW-Authenticate по RFC 6750, без кода ошибки - когда токена нет совсем
+func challenge(w http.ResponseWriter, code, description string) {
+	value := fmt.Sprintf(`Bearer realm="%s"`, realm)
+	if code != "" {
+		value += fmt.Sprintf(`, error="%s", error_description="%s"`, code, description)
+	}
+	w.Header().Set("WWW-Authenticate", value)
+}
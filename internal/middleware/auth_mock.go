@@ -5,6 +5,8 @@
 package middleware
 
 import (
+	models "caching_web_server/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -33,11 +35,26 @@ func (m *Mockservice) EXPECT() *MockserviceMockRecorder {
 	return m.recorder
 }
 
+// VerifyAPIKey mocks base method.
+func (m *Mockservice) VerifyAPIKey(ctx context.Context, key string) (models.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAPIKey", ctx, key)
+	ret0, _ := ret[0].(models.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAPIKey indicates an expected call of VerifyAPIKey.
+func (mr *MockserviceMockRecorder) VerifyAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAPIKey", reflect.TypeOf((*Mockservice)(nil).VerifyAPIKey), ctx, key)
+}
+
 // VerifyToken mocks base method.
-func (m *Mockservice) VerifyToken(auth string) (string, error) {
+func (m *Mockservice) VerifyToken(auth string) (models.Identity, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "VerifyToken", auth)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(models.Identity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
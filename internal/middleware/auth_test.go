@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"caching_web_server/internal/models"
 	"caching_web_server/internal/service/auth"
 	"context"
 	"errors"
@@ -30,13 +31,15 @@ func TestMiddleware_Authorize(t *testing.T) {
 		name       string
 		mockUp     func()
 		coolieBool bool
+		header     string
 		fields     fields
 		code       int
+		challenge  string
 	}{
 		{
 			name: "success_authorize",
 			mockUp: func() {
-				mockService.EXPECT().VerifyToken(gomock.Any()).Return("test", nil)
+				mockService.EXPECT().VerifyToken(gomock.Any()).Return(models.Identity{Login: "test", Role: models.RoleUser}, nil)
 			},
 			coolieBool: true,
 			fields: fields{
@@ -53,19 +56,84 @@ func TestMiddleware_Authorize(t *testing.T) {
 				service: mockService,
 				log:     log,
 			},
-			code: http.StatusUnauthorized,
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer realm="api"`,
 		},
 		{
 			name: "error_verify_token",
 			mockUp: func() {
-				mockService.EXPECT().VerifyToken(gomock.Any()).Return("", errors.New("error"))
+				mockService.EXPECT().VerifyToken(gomock.Any()).Return(models.Identity{}, errors.New("error"))
 			},
 			coolieBool: true,
 			fields: fields{
 				service: mockService,
 				log:     log,
 			},
-			code: http.StatusUnauthorized,
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer realm="api", error="invalid_token", error_description="the access token is invalid, expired or revoked"`,
+		},
+		{
+			name: "success_bearer_header",
+			mockUp: func() {
+				mockService.EXPECT().VerifyToken("header-token").Return(models.Identity{Login: "test", Role: models.RoleUser}, nil)
+			},
+			header: "Bearer header-token",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "success_header_wins_over_cookie",
+			mockUp: func() {
+				mockService.EXPECT().VerifyToken("header-token").Return(models.Identity{Login: "test", Role: models.RoleUser}, nil)
+			},
+			coolieBool: true,
+			header:     "bearer header-token",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "success_api_key",
+			mockUp: func() {
+				mockService.EXPECT().VerifyAPIKey(gomock.Any(), "cws_key").
+					Return(models.Identity{Login: "test", Role: models.RoleUser, Scopes: []string{models.ScopeRead}}, nil)
+			},
+			header: "Bearer cws_key",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "error_api_key",
+			mockUp: func() {
+				mockService.EXPECT().VerifyAPIKey(gomock.Any(), "cws_key").Return(models.Identity{}, errors.New("error"))
+			},
+			header: "Bearer cws_key",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer realm="api", error="invalid_token", error_description="the access token is invalid, expired or revoked"`,
+		},
+		{
+			name:       "error_basic_scheme",
+			mockUp:     func() {},
+			coolieBool: true,
+			header:     "Basic dXNlcjpwYXNz",
+			fields: fields{
+				service: mockService,
+				log:     log,
+			},
+			code:      http.StatusUnauthorized,
+			challenge: `Bearer realm="api", error="invalid_request", error_description="invalid Authorization header: expected Bearer scheme"`,
 		},
 	}
 	for _, tt := range tests {
@@ -88,6 +156,10 @@ func TestMiddleware_Authorize(t *testing.T) {
 				r = r.WithContext(ctx)
 			}
 
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Write([]byte("test_auth"))
 			})
@@ -96,6 +168,196 @@ func TestMiddleware_Authorize(t *testing.T) {
 			if w.Code != tt.code {
 				t.Errorf("Authorize() = %v, want %v", w.Code, http.StatusOK)
 			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("Authorize() WWW-Authenticate = %v, want %v", got, tt.challenge)
+			}
+		})
+	}
+}
+
+func TestMiddleware_OptionalAuthorize(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctlr := gomock.NewController(t)
+	defer ctlr.Finish()
+
+	mockService := NewMockservice(ctlr)
+
+	tests := []struct {
+		name       string
+		mockUp     func()
+		coolieBool bool
+		code       int
+		login      string
+		hasLogin   bool
+	}{
+		{
+			name: "success_authorize",
+			mockUp: func() {
+				mockService.EXPECT().VerifyToken("test").Return(models.Identity{Login: "user", Role: models.RoleUser}, nil)
+			},
+			coolieBool: true,
+			code:       http.StatusOK,
+			login:      "user",
+			hasLogin:   true,
+		},
+		{
+			name:       "success_anonymous",
+			mockUp:     func() {},
+			coolieBool: false,
+			code:       http.StatusOK,
+		},
+		{
+			name: "error_verify_token",
+			mockUp: func() {
+				mockService.EXPECT().VerifyToken("test").Return(models.Identity{}, errors.New("error"))
+			},
+			coolieBool: true,
+			code:       http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			m := &Middleware{
+				service: mockService,
+				log:     log,
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.coolieBool {
+				r.AddCookie(&http.Cookie{
+					Name:  auth.NameCookie,
+					Value: "test",
+				})
+			}
+
+			var (
+				login    string
+				hasLogin bool
+			)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				login, hasLogin = r.Context().Value(NameLogin).(string)
+			})
+
+			m.OptionalAuthorize(handler).ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("OptionalAuthorize() = %v, want %v", w.Code, tt.code)
+			}
+			if hasLogin != tt.hasLogin || login != tt.login {
+				t.Errorf("OptionalAuthorize() login = %q (%v), want %q (%v)", login, hasLogin, tt.login, tt.hasLogin)
+			}
+		})
+	}
+}
+
+func TestMiddleware_RequireScope(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		session bool
+		code    int
+	}{
+		{
+			name:    "success_session",
+			session: true,
+			code:    http.StatusOK,
+		},
+		{
+			name:   "success_api_key_scope",
+			scopes: []string{models.ScopeRead, models.ScopeWrite},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "error_api_key_without_scope",
+			scopes: []string{models.ScopeRead},
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "error_api_key_empty_scopes",
+			scopes: []string{},
+			code:   http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{
+				log: log,
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if !tt.session {
+				r = r.WithContext(context.WithValue(r.Context(), NameScopes, tt.scopes))
+			}
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("test_scope"))
+			})
+
+			m.RequireScope(handler, models.ScopeWrite).ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("RequireScope() = %v, want %v", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestMiddleware_RequireSession(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	m := &Middleware{
+		log: log,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test_session"))
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	m.RequireSession(handler).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("RequireSession() = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r = r.WithContext(context.WithValue(r.Context(), NameScopes, []string{models.ScopeRead}))
+	m.RequireSession(handler).ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("RequireSession() = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		cookie  string
+		want    string
+		wantErr error
+	}{
+		{name: "cookie", cookie: "c", want: "c"},
+		{name: "bearer", header: "Bearer h", want: "h"},
+		{name: "bearer_wins", header: "Bearer h", cookie: "c", want: "h"},
+		{name: "no_token", wantErr: ErrNoToken},
+		{name: "empty_bearer", header: "Bearer ", cookie: "c", wantErr: ErrInvalidAuth},
+		{name: "other_scheme", header: "Basic abc", cookie: "c", wantErr: ErrInvalidAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.NameCookie, Value: tt.cookie})
+			}
+			got, err := Token(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Token() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -135,3 +397,59 @@ func TestNewMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestMiddleware_RequireRole(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name  string
+		role  any
+		roles []string
+		code  int
+	}{
+		{
+			name:  "success_admin",
+			role:  models.RoleAdmin,
+			roles: []string{models.RoleAdmin},
+			code:  http.StatusOK,
+		},
+		{
+			name:  "success_one_of_roles",
+			role:  models.RoleUser,
+			roles: []string{models.RoleAdmin, models.RoleUser},
+			code:  http.StatusOK,
+		},
+		{
+			name:  "error_read_only",
+			role:  models.RoleReadOnly,
+			roles: []string{models.RoleAdmin, models.RoleUser},
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "error_without_role",
+			roles: []string{models.RoleAdmin},
+			code:  http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{
+				log: log,
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.role != nil {
+				r = r.WithContext(context.WithValue(r.Context(), NameRole, tt.role))
+			}
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("test_role"))
+			})
+
+			m.RequireRole(handler, tt.roles...).ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("RequireRole() = %v, want %v", w.Code, tt.code)
+			}
+		})
+	}
+}
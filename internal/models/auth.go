@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tokens - пара токенов, которую получает клиент при входе и обновлении
+// при включенной двухфакторной аутентификации вход по паролю возвращает только MFAToken для второго шага
+type Tokens struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	// ExpiresIn - время жизни access-токена или MFAToken в секундах
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Роли пользователей: admin управляет пользователями, read-only только читает документы
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleReadOnly = "read-only"
+)
+
+// ValidRole - проверяет, что роль известна
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleUser, RoleReadOnly:
+		return true
+	}
+	return false
+}
+
+// Identity - пользователь, от имени которого выполняется запрос
+type Identity struct {
+	Login string
+	Role  string
+	// Scopes - права API-ключа, у входа по паролю nil: ограничивает только роль
+	Scopes []string
+}
+
+// Права API-ключей
+const (
+	ScopeRead   = "read"
+	ScopeWrite  = "write"
+	ScopeDelete = "delete"
+)
+
+// ValidScope - проверяет, что право известно
+func ValidScope(scope string) bool {
+	switch scope {
+	case ScopeRead, ScopeWrite, ScopeDelete:
+		return true
+	}
+	return false
+}
+
+// APIKey - именованный ключ пользователя для автоматизации, сам ключ показывается только при создании
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Account - профиль пользователя для /api/me
+type Account struct {
+	Login     string       `json:"login"`
+	Role      string       `json:"role"`
+	CreatedAt time.Time    `json:"created_at"`
+	TwoFactor bool         `json:"two_factor"`
+	Usage     AccountUsage `json:"usage"`
+}
+
+// AccountUsage - сколько пользователь занимает: свои документы, доступные ему чужие и API-ключи
+type AccountUsage struct {
+	Documents int64 `json:"documents"`
+	Shared    int64 `json:"shared"`
+	APIKeys   int64 `json:"api_keys"`
+}
+
+// TOTPEnrollment - данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DocumentContent - содержимое документа, которое держим в кеше
+type DocumentContent struct {
+	File    []byte
+	JSON    []byte
+	Mime    string
+	Hash    string
+	ModTime time.Time
+	Public  bool
+}
+
+// Size - размер содержимого в байтах
+func (c DocumentContent) Size() int64 {
+	return int64(len(c.File) + len(c.JSON) + len(c.Mime) + len(c.Hash))
+}
+
+// DocsListSize - примерный размер списка документов в байтах
+func DocsListSize(docs []DocsData) int64 {
+	var size int
+	for _, doc := range docs {
+		size += len(doc.Id) + len(doc.Name) + len(doc.Mime) + len(doc.Created) + len(doc.Owner) + len(doc.Permission)
+		for _, grant := range doc.Grants {
+			size += len(grant)
+		}
+		for _, group := range doc.GroupGrants {
+			size += len(group)
+		}
+	}
+	return int64(size)
+}
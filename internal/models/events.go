@@ -0,0 +1,20 @@
+package models
+
+const (
+	ChangeDocument = "document"
+	ChangeGrant    = "grant"
+	// ChangeGroup - изменился состав группы, документа в уведомлении нет
+	ChangeGroup = "group"
+)
+
+// ChangeEvent - уведомление об изменении, которое рассылает Postgres через NOTIFY
+type ChangeEvent struct {
+	Kind   string   `json:"kind"`
+	DocID  string   `json:"doc_id"`
+	Logins []string `json:"logins"`
+	// ListsResync - затронутых пользователей слишком много для одного уведомления, сбрасываются все списки
+	ListsResync bool `json:"lists_resync,omitempty"`
+
+	// Resync - соединение со слушателем восстановлено, часть уведомлений могла потеряться
+	Resync bool `json:"-"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type Meta struct {
 	Name   string   `json:"name"`
 	File   bool     `json:"file"`
@@ -8,3 +10,12 @@ type Meta struct {
 	Mime   string   `json:"mime"`
 	Grants []string `json:"grants"`
 }
+
+// DocumentPatch - изменяемые поля документа в PATCH /api/docs/{id}, отсутствующее поле не меняется
+// JSON: null удаляет JSON-данные документа
+type DocumentPatch struct {
+	Name   *string         `json:"name"`
+	Mime   *string         `json:"mime"`
+	Public *bool           `json:"public"`
+	JSON   json.RawMessage `json:"json"`
+}
@@ -18,8 +18,13 @@ type Document struct {
 	Public      bool
 	JsonDate    []byte
 	StoragePath string
+	ContentHash string
 	CreatedAt   time.Time
-	IsDeleted   bool
+	// UpdatedAt - время последней замены содержимого или метаданных, при создании совпадает с CreatedAt
+	UpdatedAt time.Time
+	IsDeleted bool
+	// Permission - уровень доступа запросившего пользователя, к чужому публичному документу - read
+	Permission string
 }
 
 type Grands struct {
@@ -27,6 +32,58 @@ type Grands struct {
 	UserID int64
 }
 
+// Уровни доступа к документу: read - чтение, write - еще и замена содержимого и метаданных,
+// manage - еще и управление доступом; owner есть только у владельца и в grants не хранится
+const (
+	PermissionRead   = "read"
+	PermissionWrite  = "write"
+	PermissionManage = "manage"
+	PermissionOwner  = "owner"
+)
+
+// ValidPermission - проверяет, что уровень доступа можно выдать
+func ValidPermission(permission string) bool {
+	switch permission {
+	case PermissionRead, PermissionWrite, PermissionManage:
+		return true
+	}
+	return false
+}
+
+// PermissionAllows - уровень have включает все права уровня want
+func PermissionAllows(have, want string) bool {
+	rank := func(permission string) int {
+		switch permission {
+		case PermissionRead:
+			return 1
+		case PermissionWrite:
+			return 2
+		case PermissionManage:
+			return 3
+		case PermissionOwner:
+			return 4
+		}
+		return 0
+	}
+	return rank(want) > 0 && rank(have) >= rank(want)
+}
+
+// Grant - пользователь или группа, которым открыт доступ к документу, и уровень доступа
+// заполнено ровно одно из Login и Group
+type Grant struct {
+	Login      string `json:"login,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Permission string `json:"permission"`
+}
+
+// Group - группа пользователей, которой можно открыть доступ к документу; составом управляет владелец
+type Group struct {
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	Members   []string  `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type DocumentResponse struct {
 	Doc    Document
 	Grants []string
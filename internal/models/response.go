@@ -20,11 +20,33 @@ type Data struct {
 }
 
 type DocsData struct {
-	Id      string   `json:"id"`
-	Name    string   `json:"name"`
-	Mime    string   `json:"mime"`
-	File    bool     `json:"file"`
-	Public  bool     `json:"public"`
-	Created string   `json:"created"`
-	Grants  []string `json:"grant"`
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Mime    string `json:"mime"`
+	File    bool   `json:"file"`
+	Public  bool   `json:"public"`
+	Created string `json:"created"`
+	// Owner - логин владельца, Permission - уровень доступа запросившего
+	Owner      string `json:"owner"`
+	Permission string `json:"permission"`
+	// Grants и GroupGrants - каким пользователям и группам открыт доступ, видят только владелец и пользователи с правом manage
+	Grants      []string `json:"grant"`
+	GroupGrants []string `json:"group_grants"`
+}
+
+// Области выборки списка документов: свои, открытые мне, и те и другие, все публичные
+const (
+	ListScopeOwned  = "owned"
+	ListScopeShared = "shared"
+	ListScopeAll    = "all"
+	ListScopePublic = "public"
+)
+
+// ValidListScope - проверяет, что область выборки известна
+func ValidListScope(scope string) bool {
+	switch scope {
+	case ListScopeOwned, ListScopeShared, ListScopeAll, ListScopePublic:
+		return true
+	}
+	return false
 }
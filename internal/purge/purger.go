@@ -0,0 +1,163 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errRejected - прокси отклонил запрос (403, 405 и т.п.), повтор ничего не изменит
+var errRejected = errors.New("purge rejected by proxy")
+
+const (
+	MethodPurge = "PURGE"
+	MethodBan   = "BAN"
+
+	// KeyHeader - заголовок с суррогатными ключами, по которым прокси сбрасывает кеш
+	KeyHeader = "Surrogate-Key"
+
+	queueSize = 1024
+)
+
+// DocumentKey - суррогатный ключ документа
+func DocumentKey(docID string) string {
+	return "doc-" + docID
+}
+
+type job struct {
+	paths []string
+	keys  []string
+}
+
+type Purger struct {
+	client  *http.Client
+	targets []string
+	method  string
+	retries int
+	backoff time.Duration
+	queue   chan job
+	log     *slog.Logger
+}
+
+// NewPurger - конструктор
+// targets - базовые адреса прокси (http://varnish:6081), method - PURGE или BAN
+func NewPurger(targets []string, method string, retries int, log *slog.Logger) *Purger {
+	if method == "" {
+		method = MethodPurge
+	}
+	return &Purger{
+		client:  &http.Client{Timeout: 5 * time.Second},
+		targets: targets,
+		method:  method,
+		retries: retries,
+		backoff: 200 * time.Millisecond,
+		queue:   make(chan job, queueSize),
+		log:     log,
+	}
+}
+
+// Run - отправляет запросы из очереди, пока не отменен контекст
+func (p *Purger) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-p.queue:
+			p.send(ctx, j)
+		}
+	}
+}
+
+// PurgeDocument - ставит в очередь сброс кеша документа и списка документов
+func (p *Purger) PurgeDocument(docID string) {
+	p.enqueue(job{
+		paths: []string{"/api/docs/" + docID, "/api/docs"},
+		keys:  []string{DocumentKey(docID)},
+	})
+}
+
+// enqueue - не блокирует вызывающего, при переполнении очереди запрос теряется
+func (p *Purger) enqueue(j job) {
+	if len(p.targets) == 0 {
+		return
+	}
+	select {
+	case p.queue <- j:
+	default:
+		p.log.Error("Purge", "error", "purge queue is full", "paths", j.paths)
+	}
+}
+
+func (p *Purger) send(ctx context.Context, j job) {
+	for _, target := range p.targets {
+		for _, path := range j.paths {
+			url := strings.TrimRight(target, "/") + path
+			// каждый сбой пишется один раз: отказ прокси - предупреждение, исчерпанные повторы - ошибка
+			err := p.do(ctx, url, j.keys)
+			switch {
+			case err == nil:
+			case errors.Is(err, errRejected):
+				p.log.Warn("Purge", "purge rejected", err, "url", url)
+			default:
+				p.log.Error("Purge", "failed to purge", err, "url", url)
+			}
+		}
+	}
+}
+
+// do - отправляет запрос с повторами и экспоненциальной задержкой
+func (p *Purger) do(ctx context.Context, url string, keys []string) error {
+	var err error
+	delay := p.backoff
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		err = p.request(ctx, url, keys)
+		if err == nil || errors.Is(err, errRejected) {
+			return err
+		}
+	}
+	return err
+}
+
+func (p *Purger) request(ctx context.Context, url string, keys []string) error {
+	req, err := http.NewRequestWithContext(ctx, p.method, url, nil)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		req.Header.Set(KeyHeader, strings.Join(keys, " "))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			p.log.Error("Purge", "failed to close body", err)
+		}
+	}()
+
+	// 404 - объекта нет в кеше прокси, повторять нечего
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	// прочие ответы означают, что кеш не сброшен: обычно прокси не разрешает PURGE или BAN с нашего адреса
+	if resp.StatusCode < http.StatusInternalServerError {
+		return fmt.Errorf("%w: %s: status %d", errRejected, url, resp.StatusCode)
+	}
+	return fmt.Errorf("purge %s: unexpected status %d", url, resp.StatusCode)
+}
@@ -0,0 +1,239 @@
+package purge
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type proxyRequest struct {
+	method string
+	path   string
+	keys   string
+}
+
+// proxy - заглушка кеширующего прокси, отвечает статусами из очереди
+type proxy struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []proxyRequest
+	done     chan struct{}
+	want     int
+}
+
+func newProxy(want int, statuses ...int) *proxy {
+	return &proxy{
+		statuses: statuses,
+		done:     make(chan struct{}),
+		want:     want,
+	}
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, proxyRequest{
+		method: r.Method,
+		path:   r.URL.Path,
+		keys:   r.Header.Get(KeyHeader),
+	})
+
+	status := http.StatusOK
+	if len(p.statuses) > 0 {
+		status = p.statuses[0]
+		p.statuses = p.statuses[1:]
+	}
+	w.WriteHeader(status)
+
+	if len(p.requests) == p.want {
+		close(p.done)
+	}
+}
+
+func (p *proxy) wait(t *testing.T) []proxyRequest {
+	t.Helper()
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for purge requests")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests
+}
+
+func TestPurger_PurgeDocument(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name       string
+		method     string
+		statuses   []int
+		want       int
+		wantMethod string
+		wantPaths  []string
+	}{
+		{
+			name:       "success_purge",
+			method:     "",
+			want:       2,
+			wantMethod: MethodPurge,
+			wantPaths:  []string{"/api/docs/id1", "/api/docs"},
+		},
+		{
+			name:       "success_ban",
+			method:     MethodBan,
+			want:       2,
+			wantMethod: MethodBan,
+			wantPaths:  []string{"/api/docs/id1", "/api/docs"},
+		},
+		{
+			name:       "retry_on_server_error",
+			method:     MethodPurge,
+			statuses:   []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			want:       4,
+			wantMethod: MethodPurge,
+			wantPaths:  []string{"/api/docs/id1", "/api/docs/id1", "/api/docs/id1", "/api/docs"},
+		},
+		{
+			name:       "not_found_is_not_retried",
+			method:     MethodPurge,
+			statuses:   []int{http.StatusNotFound},
+			want:       2,
+			wantMethod: MethodPurge,
+			wantPaths:  []string{"/api/docs/id1", "/api/docs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			px := newProxy(tt.want, tt.statuses...)
+			srv := httptest.NewServer(px)
+			defer srv.Close()
+
+			p := NewPurger([]string{srv.URL + "/"}, tt.method, 3, log)
+			p.backoff = time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go p.Run(ctx)
+
+			p.PurgeDocument("id1")
+
+			requests := px.wait(t)
+			if len(requests) != len(tt.wantPaths) {
+				t.Fatalf("got %d requests, want %d", len(requests), len(tt.wantPaths))
+			}
+			for i, req := range requests {
+				if req.method != tt.wantMethod {
+					t.Errorf("request %d method = %v, want %v", i, req.method, tt.wantMethod)
+				}
+				if req.path != tt.wantPaths[i] {
+					t.Errorf("request %d path = %v, want %v", i, req.path, tt.wantPaths[i])
+				}
+				if req.keys != DocumentKey("id1") {
+					t.Errorf("request %d keys = %v, want %v", i, req.keys, DocumentKey("id1"))
+				}
+			}
+		})
+	}
+}
+
+func TestPurger_GiveUpAfterRetries(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	px := newProxy(3, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	srv := httptest.NewServer(px)
+	defer srv.Close()
+
+	p := NewPurger([]string{srv.URL}, MethodPurge, 2, log)
+	p.backoff = time.Millisecond
+
+	err := p.do(context.Background(), srv.URL+"/api/docs", nil)
+	if err == nil {
+		t.Errorf("do() error = nil, want error")
+	}
+	if got := len(px.wait(t)); got != 3 {
+		t.Errorf("got %d requests, want %d", got, 3)
+	}
+}
+
+// после исчерпанных повторов - одна ошибка в логе, промежуточные попытки не пишутся
+func TestPurger_LogOnceAfterRetries(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+
+	px := newProxy(3, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	srv := httptest.NewServer(px)
+	defer srv.Close()
+
+	p := NewPurger([]string{srv.URL}, MethodPurge, 2, log)
+	p.backoff = time.Millisecond
+
+	p.send(context.Background(), job{paths: []string{"/api/docs"}})
+	px.wait(t)
+
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Errorf("log has %d lines, want 1: %q", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "level=ERROR") {
+		t.Errorf("log = %q, want error", buf.String())
+	}
+}
+
+// отказ прокси - не успех и не повод повторять, но его должно быть видно в логах ровно один раз
+func TestPurger_RejectedStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "forbidden", status: http.StatusForbidden},
+		{name: "method_not_allowed", status: http.StatusMethodNotAllowed},
+		{name: "not_modified", status: http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(slog.NewTextHandler(&buf, nil))
+
+			px := newProxy(1, tt.status)
+			srv := httptest.NewServer(px)
+			defer srv.Close()
+
+			p := NewPurger([]string{srv.URL}, MethodPurge, 3, log)
+			p.backoff = time.Millisecond
+
+			// отказ не повторяется и пишется один раз, только предупреждением
+			p.send(context.Background(), job{paths: []string{"/api/docs"}})
+			if got := len(px.wait(t)); got != 1 {
+				t.Errorf("got %d requests, want %d", got, 1)
+			}
+			if got := strings.Count(buf.String(), "\n"); got != 1 {
+				t.Errorf("log has %d lines, want 1: %q", got, buf.String())
+			}
+			if !strings.Contains(buf.String(), "level=WARN") || strings.Contains(buf.String(), "level=ERROR") {
+				t.Errorf("log = %q, want only warning", buf.String())
+			}
+		})
+	}
+}
+
+func TestPurger_NoTargets(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	p := NewPurger(nil, MethodPurge, 3, log)
+	p.PurgeDocument("id1")
+
+	if len(p.queue) != 0 {
+		t.Errorf("queue len = %d, want 0", len(p.queue))
+	}
+}
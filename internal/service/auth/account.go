@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ChangePassword - меняет пароль, все выданные раньше токены перестают действовать
+// возвращает новую пару токенов, чтобы текущая сессия продолжилась
+func (s *Service) ChangePassword(ctx context.Context, login, currentPassword, newPassword string) (models.Tokens, error) {
+	if err := s.confirmPassword(ctx, login, currentPassword); err != nil {
+		return models.Tokens{}, err
+	}
+
+	if err := validatePassword(newPassword); err != nil {
+		s.log.Error("ChangePassword", "error", "invalid new password")
+		return models.Tokens{}, err
+	}
+
+	hash, err := HashPassword(newPassword, s.params)
+	if err != nil {
+		s.log.Error("ChangePassword", "failed to hash password", err)
+		return models.Tokens{}, err
+	}
+
+	cutoff, err := s.storage.ChangePassword(ctx, login, hash)
+	if err != nil {
+		s.log.Error("ChangePassword", "failed to change password", err)
+		return models.Tokens{}, err
+	}
+	s.markCutOff(login, cutoff)
+
+	refresh, err := s.issueRefreshToken(ctx, login, uuid.New())
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	return s.tokens(ctx, login, refresh)
+}
+
+// DeleteAccount - удаляет учетную запись после подтверждения паролем
+// документы удаляются мягко, их файлы из хранилища убирает фоновая очистка
+func (s *Service) DeleteAccount(ctx context.Context, login, password string) error {
+	if err := s.confirmPassword(ctx, login, password); err != nil {
+		return err
+	}
+
+	cutoff, err := s.storage.DeleteUser(ctx, login)
+	if err != nil {
+		s.log.Error("DeleteAccount", "failed to delete user", err)
+		return err
+	}
+	s.markCutOff(login, cutoff)
+
+	return nil
+}
+
+// Account - профиль пользователя
+func (s *Service) Account(ctx context.Context, login string) (models.Account, error) {
+	account, err := s.storage.GetAccount(ctx, login)
+	if err != nil {
+		s.log.Error("Account", "failed to get account", err)
+		return models.Account{}, err
+	}
+	return account, nil
+}
+
+// confirmPassword - проверяет текущий пароль перед опасным действием
+func (s *Service) confirmPassword(ctx context.Context, login, password string) error {
+	hashPass, err := s.storage.GetHashPass(ctx, login)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorCurrentPassword
+		}
+		s.log.Error("confirmPassword", "failed to get hash pass", err)
+		return err
+	}
+	if ok, _ := checkPassword(hashPass, password, s.params); !ok {
+		return ErrorCurrentPassword
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_ChangePassword(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hpw, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name        string
+		mockUp      func()
+		current     string
+		newPassword string
+		wantErr     error
+	}{
+		{
+			name: "error_user_not_found",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return("", sql.ErrNoRows)
+			},
+			current:     "DocumenT1@",
+			newPassword: "NewDocumenT1@",
+			wantErr:     ErrorCurrentPassword,
+		},
+		{
+			name: "error_current_password",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+			},
+			current:     "wrong",
+			newPassword: "NewDocumenT1@",
+			wantErr:     ErrorCurrentPassword,
+		},
+		{
+			name: "error_weak_password",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+			},
+			current:     "DocumenT1@",
+			newPassword: "weak",
+			wantErr:     ErrorPassword,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+				mockStorage.EXPECT().ChangePassword(gomock.Any(), "Document", gomock.Any()).Return(time.Time{}, errStorage)
+			},
+			current:     "DocumenT1@",
+			newPassword: "NewDocumenT1@",
+			wantErr:     errStorage,
+		},
+		{
+			name: "success_change_password",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+				mockStorage.EXPECT().ChangePassword(gomock.Any(), "Document", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, hash string) (time.Time, error) {
+						if ok, _ := checkPassword(hash, "NewDocumenT1@", testPasswordParams); !ok {
+							t.Errorf("ChangePassword() must store hash of new password")
+						}
+						return time.Now(), nil
+					})
+				mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), "Document", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil)
+			},
+			current:     "DocumenT1@",
+			newPassword: "NewDocumenT1@",
+			wantErr:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			tokens, err := s.ChangePassword(context.Background(), "Document", tt.current, tt.newPassword)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tokens.AccessToken == "" {
+				t.Errorf("ChangePassword() must return new tokens")
+			}
+			if err == nil {
+				if _, err := s.VerifyToken(tokens.AccessToken); err != nil {
+					t.Errorf("VerifyToken() must accept token issued after password change: %v", err)
+				}
+			}
+		})
+	}
+
+	// токены, выданные до смены пароля, больше не действуют
+	if _, err := s.VerifyToken(tokenIssuedAt(t, "salt", "Document", time.Now().Add(-time.Minute))); err == nil {
+		t.Errorf("VerifyToken() must reject token issued before password change")
+	}
+	if _, err := s.VerifyToken(legacyToken(t, "salt", "Document")); err == nil {
+		t.Errorf("VerifyToken() must reject token without iat after password change")
+	}
+	if _, err := s.VerifyToken(tokenIssuedAt(t, "salt", "Other", time.Now().Add(-time.Minute))); err != nil {
+		t.Errorf("VerifyToken() must accept tokens of other users: %v", err)
+	}
+}
+
+func TestService_DeleteAccount(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hpw, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name     string
+		mockUp   func()
+		password string
+		wantErr  error
+	}{
+		{
+			name: "error_password",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+			},
+			password: "wrong",
+			wantErr:  ErrorCurrentPassword,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+				mockStorage.EXPECT().DeleteUser(gomock.Any(), "Document").Return(time.Time{}, errStorage)
+			},
+			password: "DocumenT1@",
+			wantErr:  errStorage,
+		},
+		{
+			name: "success_delete_account",
+			mockUp: func() {
+				mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+				mockStorage.EXPECT().DeleteUser(gomock.Any(), "Document").Return(time.Now().Add(time.Second), nil)
+			},
+			password: "DocumenT1@",
+			wantErr:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			err := s.DeleteAccount(context.Background(), "Document", tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteAccount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := s.VerifyToken(tokenIssuedAt(t, "salt", "Document", time.Now())); err == nil {
+		t.Errorf("VerifyToken() must reject tokens of deleted user")
+	}
+}
+
+func TestService_Account(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	want := models.Account{Login: "Document", Role: "user", Usage: models.AccountUsage{Documents: 2}}
+	mockStorage.EXPECT().GetAccount(gomock.Any(), "Document").Return(want, nil)
+
+	got, err := s.Account(context.Background(), "Document")
+	if err != nil || got != want {
+		t.Errorf("Account() = %+v, %v, want %+v", got, err, want)
+	}
+
+	mockStorage.EXPECT().GetAccount(gomock.Any(), "Document").Return(models.Account{}, errStorage)
+	if _, err := s.Account(context.Background(), "Document"); !errors.Is(err, errStorage) {
+		t.Errorf("Account() error = %v, wantErr %v", err, errStorage)
+	}
+}
+
+func TestService_syncCutoffs(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	now := time.Now()
+	s.markCutOff("local", now)
+	s.markCutOff("stale", now.Add(-25*time.Hour))
+
+	mockStorage.EXPECT().GetTokenCutoffs(gomock.Any(), gomock.Any()).Return(map[string]time.Time{
+		"remote": now,
+	}, nil)
+
+	s.syncCutoffs(context.Background())
+
+	before := now.Add(-time.Minute)
+	if !s.isCutOff("local", before) || !s.isCutOff("remote", before) {
+		t.Errorf("syncCutoffs() must keep local and add remote cutoffs")
+	}
+	if s.isCutOff("stale", now.Add(-26*time.Hour)) {
+		t.Errorf("syncCutoffs() must drop cutoffs older than any token lifetime")
+	}
+	if s.isCutOff("local", now) {
+		t.Errorf("isCutOff() must accept tokens issued after cutoff")
+	}
+
+	// ошибка чтения не сбрасывает локальную копию
+	mockStorage.EXPECT().GetTokenCutoffs(gomock.Any(), gomock.Any()).Return(nil, errStorage)
+
+	s.syncCutoffs(context.Background())
+
+	if !s.isCutOff("remote", before) {
+		t.Errorf("syncCutoffs() must keep cutoffs on storage error")
+	}
+}
+
+// отзыв старше времени жизни access-токена продолжает действовать на старые токены без iat
+func TestService_syncCutoffs_LegacyToken(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), 15*time.Minute, 24*time.Hour, testPasswordParams)
+
+	cutoff := time.Now().Add(-time.Hour)
+	s.markCutOff("Document", cutoff)
+
+	mockStorage.EXPECT().GetTokenCutoffs(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, since time.Time) (map[string]time.Time, error) {
+			if !since.Before(cutoff) {
+				t.Errorf("GetTokenCutoffs() since = %v, must keep cutoff %v", since, cutoff)
+			}
+			return map[string]time.Time{"Document": cutoff}, nil
+		})
+
+	s.syncCutoffs(context.Background())
+
+	if _, err := s.VerifyToken(legacyToken(t, "salt", "Document")); err == nil {
+		t.Errorf("VerifyToken() must reject legacy token after cutoff older than access token ttl")
+	}
+}
+
+// tokenIssuedAt - токен с заданным временем выдачи
+func tokenIssuedAt(t *testing.T, salt, login string, iat time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"login": login,
+		"role":  "user",
+		"iat":   iat.Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(salt))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
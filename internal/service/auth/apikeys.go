@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix - начало каждого API-ключа, по нему ключ отличается от JWT
+const APIKeyPrefix = "cws_"
+
+var (
+	ErrorAPIKey = errors.New("invalid api key")
+	ErrorScope  = errors.New("invalid scope")
+)
+
+// CreateAPIKey - создает API-ключ пользователя, в ответе единственный раз возвращается сам ключ
+// read-only пользователь может выдать ключу только право на чтение
+func (s *Service) CreateAPIKey(ctx context.Context, owner models.Identity, name string, scopes []string, expiresAt *time.Time) (models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIKey{}, fmt.Errorf("%w: empty name", ErrorAPIKey)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return models.APIKey{}, fmt.Errorf("%w: expiry in the past", ErrorAPIKey)
+	}
+
+	if len(scopes) == 0 {
+		return models.APIKey{}, fmt.Errorf("%w: no scopes", ErrorScope)
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	for _, scope := range scopes {
+		if !models.ValidScope(scope) {
+			return models.APIKey{}, fmt.Errorf("%w: %q", ErrorScope, scope)
+		}
+		if owner.Role == models.RoleReadOnly && scope != models.ScopeRead {
+			return models.APIKey{}, fmt.Errorf("%w: %q not allowed for read-only user", ErrorScope, scope)
+		}
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		s.log.Error("CreateAPIKey", "failed to generate api key", err)
+		return models.APIKey{}, err
+	}
+
+	key := models.APIKey{
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	secret := APIKeyPrefix + token
+	if err := s.storage.SaveAPIKey(ctx, owner.Login, hashOpaqueToken(secret), &key); err != nil {
+		s.log.Error("CreateAPIKey", "failed to save api key", err)
+		return models.APIKey{}, err
+	}
+	key.Key = secret
+
+	return key, nil
+}
+
+// ListAPIKeys - ключи пользователя без самих ключей
+func (s *Service) ListAPIKeys(ctx context.Context, login string) ([]models.APIKey, error) {
+	keys, err := s.storage.GetAPIKeys(ctx, login)
+	if err != nil {
+		s.log.Error("ListAPIKeys", "failed to get api keys", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey - удаляет ключ пользователя, запросы с ним сразу перестают проходить
+func (s *Service) RevokeAPIKey(ctx context.Context, login string, id uuid.UUID) error {
+	if err := s.storage.DeleteAPIKey(ctx, login, id); err != nil {
+		s.log.Error("RevokeAPIKey", "failed to delete api key", err)
+		return err
+	}
+	return nil
+}
+
+// VerifyAPIKey - проверяет API-ключ и возвращает владельца с его текущей ролью и правами ключа
+func (s *Service) VerifyAPIKey(ctx context.Context, key string) (models.Identity, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return models.Identity{}, ErrorAPIKey
+	}
+
+	id, expiresAt, err := s.storage.UseAPIKey(ctx, hashOpaqueToken(key))
+	if err != nil {
+		if errors.Is(err, pq.ErrAPIKeyNotFound) {
+			return models.Identity{}, ErrorAPIKey
+		}
+		s.log.Error("VerifyAPIKey", "failed to get api key", err)
+		return models.Identity{}, err
+	}
+	if expiresAt != nil && time.Now().After(*expiresAt) {
+		return models.Identity{}, fmt.Errorf("%w: expired", ErrorAPIKey)
+	}
+
+	return id, nil
+}
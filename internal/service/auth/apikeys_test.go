@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func TestService_CreateAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	user := models.Identity{Login: "Document", Role: models.RoleUser}
+	readOnly := models.Identity{Login: "Document", Role: models.RoleReadOnly}
+
+	tests := []struct {
+		name       string
+		owner      models.Identity
+		keyName    string
+		scopes     []string
+		expiresAt  *time.Time
+		mockUp     func(m *Mockstorage)
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name:    "success_create",
+			owner:   user,
+			keyName: " ci ",
+			scopes:  []string{"write", "read", "write"},
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().SaveAPIKey(gomock.Any(), "Document", gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantScopes: []string{"read", "write"},
+			wantErr:    nil,
+		},
+		{
+			name:      "success_read_only_with_expiry",
+			owner:     readOnly,
+			keyName:   "backup",
+			scopes:    []string{"read"},
+			expiresAt: &future,
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().SaveAPIKey(gomock.Any(), "Document", gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantScopes: []string{"read"},
+			wantErr:    nil,
+		},
+		{
+			name:    "err_empty_name",
+			owner:   user,
+			keyName: " ",
+			scopes:  []string{"read"},
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorAPIKey,
+		},
+		{
+			name:      "err_expired",
+			owner:     user,
+			keyName:   "ci",
+			scopes:    []string{"read"},
+			expiresAt: &past,
+			mockUp:    func(m *Mockstorage) {},
+			wantErr:   ErrorAPIKey,
+		},
+		{
+			name:    "err_no_scopes",
+			owner:   user,
+			keyName: "ci",
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorScope,
+		},
+		{
+			name:    "err_unknown_scope",
+			owner:   user,
+			keyName: "ci",
+			scopes:  []string{"admin"},
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorScope,
+		},
+		{
+			name:    "err_read_only_write",
+			owner:   readOnly,
+			keyName: "ci",
+			scopes:  []string{"read", "write"},
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorScope,
+		},
+		{
+			name:    "err_storage",
+			owner:   user,
+			keyName: "ci",
+			scopes:  []string{"read"},
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().SaveAPIKey(gomock.Any(), "Document", gomock.Any(), gomock.Any()).Return(pq.ErrAPIKeyExists)
+			},
+			wantErr: pq.ErrAPIKeyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := NewMockstorage(ctrl)
+			tt.mockUp(mockStorage)
+			s := &Service{
+				storage: mockStorage,
+				log:     log,
+			}
+
+			key, err := s.CreateAPIKey(context.Background(), tt.owner, tt.keyName, tt.scopes, tt.expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Service.CreateAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(key.Key, APIKeyPrefix) || key.Name != strings.TrimSpace(tt.keyName) {
+				t.Errorf("Service.CreateAPIKey() key = %+v", key)
+			}
+			if !reflect.DeepEqual(key.Scopes, tt.wantScopes) {
+				t.Errorf("Service.CreateAPIKey() scopes = %v, want %v", key.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestService_VerifyAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	owner := models.Identity{Login: "Document", Role: models.RoleUser, Scopes: []string{"read"}}
+
+	tests := []struct {
+		name    string
+		key     string
+		mockUp  func(m *Mockstorage)
+		want    models.Identity
+		wantErr error
+	}{
+		{
+			name: "success_verify",
+			key:  APIKeyPrefix + "secret",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().UseAPIKey(gomock.Any(), hashOpaqueToken(APIKeyPrefix+"secret")).Return(owner, &future, nil)
+			},
+			want:    owner,
+			wantErr: nil,
+		},
+		{
+			name: "success_without_expiry",
+			key:  APIKeyPrefix + "secret",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().UseAPIKey(gomock.Any(), gomock.Any()).Return(owner, nil, nil)
+			},
+			want:    owner,
+			wantErr: nil,
+		},
+		{
+			name:    "err_prefix",
+			key:     "secret",
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorAPIKey,
+		},
+		{
+			name: "err_not_found",
+			key:  APIKeyPrefix + "secret",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().UseAPIKey(gomock.Any(), gomock.Any()).Return(models.Identity{}, nil, pq.ErrAPIKeyNotFound)
+			},
+			wantErr: ErrorAPIKey,
+		},
+		{
+			name: "err_expired",
+			key:  APIKeyPrefix + "secret",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().UseAPIKey(gomock.Any(), gomock.Any()).Return(owner, &past, nil)
+			},
+			wantErr: ErrorAPIKey,
+		},
+		{
+			name: "err_storage",
+			key:  APIKeyPrefix + "secret",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().UseAPIKey(gomock.Any(), gomock.Any()).Return(models.Identity{}, nil, errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := NewMockstorage(ctrl)
+			tt.mockUp(mockStorage)
+			s := &Service{
+				storage: mockStorage,
+				log:     log,
+			}
+
+			got, err := s.VerifyAPIKey(context.Background(), tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Service.VerifyAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Service.VerifyAPIKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_RevokeAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := &Service{
+		storage: mockStorage,
+		log:     log,
+	}
+	id := uuid.New()
+
+	mockStorage.EXPECT().DeleteAPIKey(gomock.Any(), "Document", id).Return(nil)
+	if err := s.RevokeAPIKey(context.Background(), "Document", id); err != nil {
+		t.Errorf("Service.RevokeAPIKey() error = %v", err)
+	}
+
+	mockStorage.EXPECT().DeleteAPIKey(gomock.Any(), "Document", id).Return(pq.ErrAPIKeyNotFound)
+	if err := s.RevokeAPIKey(context.Background(), "Document", id); !errors.Is(err, pq.ErrAPIKeyNotFound) {
+		t.Errorf("Service.RevokeAPIKey() error = %v, wantErr %v", err, pq.ErrAPIKeyNotFound)
+	}
+}
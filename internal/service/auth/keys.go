@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var ErrorKey = errors.New("invalid signing key")
+
+// Key - ключ подписи токенов, без закрытой части ключ только проверяет подпись
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet - ключи, которыми подписываются и проверяются токены
+// подписывает только активный ключ, проверяют все: так старые токены живут во время ротации
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	// legacy - общий секрет HS256 для токенов без kid, пустой - такие токены не принимаются
+	legacy []byte
+	// legacyUntil - при ключах из каталога токены без kid принимаются только до этого момента
+	legacyUntil time.Time
+}
+
+// NewHMACKeySet - только общий секрет HS256, как было до появления ключей
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		keys:   make(map[string]*Key),
+		legacy: []byte(secret),
+	}
+}
+
+// LoadKeySet - загружает ключи из каталога dir, kid - имя файла без .pem
+// закрытые ключи (PKCS#8 или PKCS#1) подписывают и проверяют, открытые (PKIX) только проверяют
+// пустой dir - режим HS256 с общим секретом legacySecret
+// с каталогом токены HS256 без kid принимаются только до legacyUntil, нулевое время - не принимаются совсем
+func LoadKeySet(dir, activeKID, legacySecret string, legacyUntil time.Time) (*KeySet, error) {
+	ks := NewHMACKeySet(legacySecret)
+	if dir == "" {
+		return ks, nil
+	}
+	if legacyUntil.IsZero() {
+		ks.legacy = nil
+	}
+	ks.legacyUntil = legacyUntil
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("%w: no private key for active kid %q", ErrorKey, activeKID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+func loadKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block", ErrorKey)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(file), ".pem")}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM type %q", ErrorKey, block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrorKey, parsed)
+	}
+
+	return key, nil
+}
+
+// sign - подписывает claims активным ключом, без ключей - общим секретом
+func (ks *KeySet) sign(claims jwt.MapClaims) (string, error) {
+	if ks.active == nil {
+		if len(ks.legacy) == 0 {
+			return "", fmt.Errorf("%w: no signing key", ErrorKey)
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.legacy)
+	}
+
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Private)
+}
+
+// verificationKey - ключ для проверки по kid, алгоритм токена обязан совпадать с алгоритмом ключа
+func (ks *KeySet) verificationKey(t *jwt.Token) (any, error) {
+	kid, ok := t.Header["kid"].(string)
+	if !ok {
+		// токены, выданные до появления ключей
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || len(ks.legacy) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		if ks.active != nil && !time.Now().Before(ks.legacyUntil) {
+			return nil, fmt.Errorf("legacy HS256 tokens no longer accepted")
+		}
+		return ks.legacy, nil
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// isLegacy - токен подписан общим секретом, хотя сервер уже подписывает ключами из каталога
+// секрет мог утечь, поэтому его содержимому, кроме логина, не доверяем
+func (ks *KeySet) isLegacy(t *jwt.Token) bool {
+	_, ok := t.Header["kid"].(string)
+	return ks.active != nil && !ok
+}
+
+// JWK - открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS - набор открытых ключей для /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS - открытые части всех ключей, общий секрет HS256 не публикуется
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writeKey - сохраняет ключ в PEM, public - только открытую часть
+func writeKey(t *testing.T, dir, kid string, key any, public bool) {
+	t.Helper()
+
+	var (
+		der  []byte
+		kind = "PRIVATE KEY"
+		err  error
+	)
+	if public {
+		kind = "PUBLIC KEY"
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			der, err = x509.MarshalPKIXPublicKey(&k.PublicKey)
+		case ed25519.PrivateKey:
+			der, err = x509.MarshalPKIXPublicKey(k.Public())
+		}
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newKeyService(t *testing.T, ks *KeySet) *Service {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	return &Service{log: log, keys: ks, accessTTL: time.Hour}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "rsa-2025", rsaKey, false)
+	writeKey(t, dir, "ed-2026", edKey, false)
+
+	oldKeys, err := LoadKeySet(dir, "rsa-2025", "", time.Time{})
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	oldToken, err := newKeyService(t, oldKeys).generateToken("Document", "user")
+	if err != nil {
+		t.Fatalf("generateToken() error = %v", err)
+	}
+
+	// новый активный ключ, старый оставлен только для проверки
+	writeKey(t, dir, "rsa-2025", rsaKey, true)
+	newKeys, err := LoadKeySet(dir, "ed-2026", "", time.Time{})
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	s := newKeyService(t, newKeys)
+
+	newToken, err := s.generateToken("Document", "user")
+	if err != nil {
+		t.Fatalf("generateToken() error = %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "ed-2026" || parsed.Header["alg"] != "EdDSA" {
+		t.Errorf("generateToken() header = %v", parsed.Header)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if id, err := s.VerifyToken(token); err != nil || id.Login != "Document" {
+			t.Errorf("VerifyToken(%s) = %v, %v", name, id, err)
+		}
+	}
+
+	jwks := s.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() = %d keys, want 2", len(jwks.Keys))
+	}
+	if k := jwks.Keys[0]; k.Kid != "ed-2026" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.X == "" {
+		t.Errorf("JWKS() ed25519 key = %+v", k)
+	}
+	if k := jwks.Keys[1]; k.Kid != "rsa-2025" || k.Kty != "RSA" || k.Alg != "RS256" || k.N == "" || k.E != "AQAB" {
+		t.Errorf("JWKS() rsa key = %+v", k)
+	}
+}
+
+func TestKeySet_LoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "public-only", edKey, true)
+
+	if _, err := LoadKeySet(dir, "missing", "", time.Time{}); !errors.Is(err, ErrorKey) {
+		t.Errorf("LoadKeySet() error = %v, wantErr %v", err, ErrorKey)
+	}
+	// активный ключ обязан иметь закрытую часть
+	if _, err := LoadKeySet(dir, "public-only", "", time.Time{}); !errors.Is(err, ErrorKey) {
+		t.Errorf("LoadKeySet() error = %v, wantErr %v", err, ErrorKey)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeySet(dir, "public-only", "", time.Time{}); !errors.Is(err, ErrorKey) {
+		t.Errorf("LoadKeySet() error = %v, wantErr %v", err, ErrorKey)
+	}
+}
+
+func TestKeySet_VerificationRules(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "ed", edKey, false)
+
+	withLegacy, err := LoadKeySet(dir, "ed", "salt", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutLegacy, err := LoadKeySet(dir, "ed", "", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// секрет задан, но без явного срока старые токены не принимаются
+	withoutOptIn, err := LoadKeySet(dir, "ed", "salt", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyExpired, err := LoadKeySet(dir, "ed", "salt", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hsToken := func(header map[string]any) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"login": "Document",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		signed, err := token.SignedString([]byte("salt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		keys    *KeySet
+		token   string
+		wantErr bool
+	}{
+		{name: "legacy_hs256", keys: withLegacy, token: hsToken(nil)},
+		{name: "legacy_disabled", keys: withoutLegacy, token: hsToken(nil), wantErr: true},
+		{name: "legacy_without_opt_in", keys: withoutOptIn, token: hsToken(nil), wantErr: true},
+		{name: "legacy_expired", keys: legacyExpired, token: hsToken(nil), wantErr: true},
+		// HS256 с kid асимметричного ключа - подмена алгоритма
+		{name: "alg_confusion", keys: withLegacy, token: hsToken(map[string]any{"kid": "ed"}), wantErr: true},
+		{name: "unknown_kid", keys: withLegacy, token: hsToken(map[string]any{"kid": "other"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newKeyService(t, tt.keys).VerifyToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// роль из токена, подписанного общим секретом, не повышает права: секрет мог утечь
+func TestKeySet_LegacyRole(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "ed", edKey, false)
+
+	keys, err := LoadKeySet(dir, "ed", "salt", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newKeyService(t, keys)
+
+	legacy, err := newKeyService(t, NewHMACKeySet("salt")).generateToken("Document", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := s.VerifyToken(legacy); err != nil || id.Login != "Document" || id.Role != models.RoleReadOnly {
+		t.Errorf("VerifyToken(legacy) = %v, %v, want role %s", id, err, models.RoleReadOnly)
+	}
+
+	current, err := s.generateToken("Document", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := s.VerifyToken(current); err != nil || id.Role != models.RoleAdmin {
+		t.Errorf("VerifyToken(current) = %v, %v, want role %s", id, err, models.RoleAdmin)
+	}
+}
+
+func TestNewHMACKeySet(t *testing.T) {
+	s := newKeyService(t, NewHMACKeySet("salt"))
+	token, err := s.generateToken("Document", "user")
+	if err != nil {
+		t.Fatalf("generateToken() error = %v", err)
+	}
+	if id, err := s.VerifyToken(token); err != nil || id.Login != "Document" {
+		t.Errorf("VerifyToken() = %v, %v", id, err)
+	}
+	if len(s.JWKS().Keys) != 0 {
+		t.Errorf("JWKS() must not publish shared secret")
+	}
+
+	if _, err := newKeyService(t, NewHMACKeySet("")).generateToken("Document", "user"); !errors.Is(err, ErrorKey) {
+		t.Errorf("generateToken() error = %v, wantErr %v", err, ErrorKey)
+	}
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// PasswordParams - параметры argon2id, записываются в сам хеш, поэтому их можно менять без миграции
+type PasswordParams struct {
+	// Memory - память в КиБ
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// DefaultPasswordParams - параметры по умолчанию, рекомендация RFC 9106 для ограниченной памяти
+var DefaultPasswordParams = PasswordParams{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 2,
+}
+
+const (
+	saltLen = 16
+	keyLen  = 32
+)
+
+var errHashFormat = errors.New("unknown password hash format")
+
+// HashPassword - хеширует пароль argon2id в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>
+func HashPassword(password string, params PasswordParams) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, keyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// checkPassword - проверяет пароль по хешу в любом из поддерживаемых форматов
+// rehash - хеш устарел (старый формат или другие параметры) и его стоит пересчитать
+func checkPassword(stored, pw string, params PasswordParams) (ok, rehash bool) {
+	if strings.HasPrefix(stored, "$argon2id$") {
+		hashParams, salt, key, err := decodeArgon2(stored)
+		if err != nil {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(pw), salt, hashParams.Time, hashParams.Memory, hashParams.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false
+		}
+		return true, hashParams != params
+	}
+
+	return checkLegacyPassword(stored, pw), true
+}
+
+func decodeArgon2(stored string) (PasswordParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хеш
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return PasswordParams{}, nil, nil, errHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PasswordParams{}, nil, nil, errHashFormat
+	}
+
+	var params PasswordParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return PasswordParams{}, nil, nil, errHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return PasswordParams{}, nil, nil, errHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return PasswordParams{}, nil, nil, errHashFormat
+	}
+
+	return params, salt, key, nil
+}
+
+// legacyHash - прежний формат: JSON с солью и SHA-256 от соли и пароля
+type legacyHash struct {
+	Salt string `json:"salt"`
+	Sum  string `json:"sum"`
+}
+
+func checkLegacyPassword(hashJSON, pw string) bool {
+	var ph legacyHash
+	if err := json.Unmarshal([]byte(hashJSON), &ph); err != nil {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(ph.Salt)
+	if err != nil {
+		return false
+	}
+	sum, err := base64.StdEncoding.DecodeString(ph.Sum)
+	if err != nil {
+		return false
+	}
+	h := sha256.Sum256(append(salt, []byte(pw)...))
+	return subtle.ConstantTimeCompare(h[:], sum) == 1
+}
+
+// upgradeHash - пересчитывает устаревший хеш после успешного входа
+// ошибка не мешает входу, попробуем в следующий раз
+func (s *Service) upgradeHash(ctx context.Context, login, password string) {
+	hash, err := HashPassword(password, s.params)
+	if err != nil {
+		s.log.Error("upgradeHash", "failed to hash password", err)
+		return
+	}
+	if err := s.storage.UpdatePasswordHash(ctx, login, hash); err != nil {
+		s.log.Error("upgradeHash", "failed to update password hash", err)
+		return
+	}
+	s.log.Info("upgradeHash", "password hash upgraded", login)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// testPasswordParams - дешевые параметры, чтобы тесты не тратили память и время
+var testPasswordParams = PasswordParams{Memory: 1024, Time: 1, Threads: 1}
+
+// legacyHashPassword - хеш в прежнем формате JSON с SHA-256
+func legacyHashPassword(t *testing.T, password string) string {
+	t.Helper()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.Sum256(append(salt, []byte(password)...))
+	result, err := json.Marshal(legacyHash{
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		Sum:  base64.StdEncoding.EncodeToString(h[:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(result)
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("HashPassword() = %v, want PHC argon2id format", hash)
+	}
+
+	other, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if hash == other {
+		t.Errorf("HashPassword() must use random salt")
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	current, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weaker, err := HashPassword("DocumenT1@", PasswordParams{Memory: 512, Time: 1, Threads: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		stored     string
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{name: "argon2_ok", stored: current, password: "DocumenT1@", wantOK: true},
+		{name: "argon2_wrong_password", stored: current, password: "wrong", wantOK: false},
+		{name: "argon2_old_params", stored: weaker, password: "DocumenT1@", wantOK: true, wantRehash: true},
+		{name: "legacy_ok", stored: legacyHashPassword(t, "DocumenT1@"), password: "DocumenT1@", wantOK: true, wantRehash: true},
+		{name: "legacy_wrong_password", stored: legacyHashPassword(t, "DocumenT1@"), password: "wrong", wantOK: false, wantRehash: true},
+		{name: "broken_argon2", stored: "$argon2id$v=19$m=1024,t=1,p=1$bad", password: "DocumenT1@", wantOK: false},
+		{name: "garbage", stored: "bad_hash_pass", password: "DocumenT1@", wantOK: false, wantRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := checkPassword(tt.stored, tt.password, testPasswordParams)
+			if ok != tt.wantOK {
+				t.Errorf("checkPassword() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && rehash != tt.wantRehash {
+				t.Errorf("checkPassword() rehash = %v, want %v", rehash, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestService_AuthUser_UpgradesLegacyHash(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	var upgraded string
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(legacyHashPassword(t, "DocumenT1@"), nil)
+	mockStorage.EXPECT().UpdatePasswordHash(gomock.Any(), "Document", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string) error {
+			upgraded = hash
+			return nil
+		})
+	mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil).Times(2)
+	mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil).Times(2)
+
+	if _, err := s.AuthUser(context.Background(), "Document", "DocumenT1@"); err != nil {
+		t.Fatalf("AuthUser() error = %v", err)
+	}
+	if ok, rehash := checkPassword(upgraded, "DocumenT1@", testPasswordParams); !ok || rehash {
+		t.Errorf("AuthUser() must store current argon2id hash, got %v", upgraded)
+	}
+
+	// ошибка пересчета хеша не мешает входу
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(legacyHashPassword(t, "DocumenT1@"), nil)
+	mockStorage.EXPECT().UpdatePasswordHash(gomock.Any(), "Document", gomock.Any()).Return(errStorage)
+	if _, err := s.AuthUser(context.Background(), "Document", "DocumenT1@"); err != nil {
+		t.Errorf("AuthUser() error = %v", err)
+	}
+}
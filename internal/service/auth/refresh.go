@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshTokens - обменивает refresh-токен на новую пару, старый refresh-токен больше не действует
+// повторное использование refresh-токена отзывает все токены его семьи
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	if refreshToken == "" {
+		return models.Tokens{}, ErrorRefreshToken
+	}
+
+	next, err := newOpaqueToken()
+	if err != nil {
+		s.log.Error("RefreshTokens", "failed to generate refresh token", err)
+		return models.Tokens{}, err
+	}
+
+	login, err := s.storage.RotateRefreshToken(ctx, hashOpaqueToken(refreshToken), hashOpaqueToken(next), time.Now().Add(s.refreshTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, pq.ErrRefreshTokenReused):
+			s.log.Warn("RefreshTokens", "refresh token reused, token family revoked", err)
+			return models.Tokens{}, fmt.Errorf("%w: %v", ErrorRefreshToken, err)
+		case errors.Is(err, pq.ErrRefreshTokenNotFound), errors.Is(err, pq.ErrRefreshTokenExpired):
+			return models.Tokens{}, fmt.Errorf("%w: %v", ErrorRefreshToken, err)
+		}
+		s.log.Error("RefreshTokens", "failed to rotate refresh token", err)
+		return models.Tokens{}, err
+	}
+
+	return s.tokens(ctx, login, next)
+}
+
+// RevokeRefreshToken - отзывает семью refresh-токена пользователя, после выхода сессию обновить нельзя
+func (s *Service) RevokeRefreshToken(ctx context.Context, login, refreshToken string) error {
+	if refreshToken == "" {
+		return ErrorRefreshToken
+	}
+
+	err := s.storage.RevokeRefreshFamily(ctx, login, hashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, pq.ErrRefreshTokenNotFound) {
+			return fmt.Errorf("%w: %v", ErrorRefreshToken, err)
+		}
+		s.log.Error("RevokeRefreshToken", "failed to revoke token family", err)
+		return err
+	}
+
+	return nil
+}
+
+// issueRefreshToken - создает refresh-токен в семье family, в базе хранится только его хеш
+func (s *Service) issueRefreshToken(ctx context.Context, login string, family uuid.UUID) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		s.log.Error("issueRefreshToken", "failed to generate refresh token", err)
+		return "", err
+	}
+
+	err = s.storage.SaveRefreshToken(ctx, login, hashOpaqueToken(token), family, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		s.log.Error("issueRefreshToken", "failed to save refresh token", err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// tokens - выпускает access-токен и собирает ответ
+// роль читаем из базы, так новая роль попадает в токен при следующем обновлении
+func (s *Service) tokens(ctx context.Context, login, refresh string) (models.Tokens, error) {
+	role, err := s.storage.GetRole(ctx, login)
+	if err != nil {
+		s.log.Error("tokens", "failed to get role", err)
+		return models.Tokens{}, err
+	}
+
+	access, err := s.generateToken(login, role)
+	if err != nil {
+		s.log.Error("tokens", "failed to generate token", err)
+		return models.Tokens{}, err
+	}
+
+	return models.Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+	}, nil
+}
+
+// newOpaqueToken - случайный токен без содержимого: refresh-токены и API-ключи
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashOpaqueToken - токен случайный и длинный, соль не нужна
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_RefreshTokens(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), 15*time.Minute, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		token   string
+		wantErr error
+	}{
+		{
+			name: "success_refresh",
+			mockUp: func() {
+				mockStorage.EXPECT().RotateRefreshToken(gomock.Any(), hashOpaqueToken("old"), gomock.Any(), gomock.Any()).Return("Document", nil)
+				mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("read-only", nil)
+			},
+			token:   "old",
+			wantErr: nil,
+		},
+		{
+			name:    "error_empty_token",
+			mockUp:  func() {},
+			token:   "",
+			wantErr: ErrorRefreshToken,
+		},
+		{
+			name: "error_reused",
+			mockUp: func() {
+				mockStorage.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", pq.ErrRefreshTokenReused)
+			},
+			token:   "old",
+			wantErr: ErrorRefreshToken,
+		},
+		{
+			name: "error_expired",
+			mockUp: func() {
+				mockStorage.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", pq.ErrRefreshTokenExpired)
+			},
+			token:   "old",
+			wantErr: ErrorRefreshToken,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", errStorage)
+			},
+			token:   "old",
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			tokens, err := s.RefreshTokens(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RefreshTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tokens.RefreshToken == "" || tokens.RefreshToken == tt.token {
+				t.Errorf("RefreshTokens() must issue a new refresh token")
+			}
+			if tokens.ExpiresIn != int64((15 * time.Minute).Seconds()) {
+				t.Errorf("RefreshTokens() expires_in = %v", tokens.ExpiresIn)
+			}
+			// роль берется из базы, а не из старого токена
+			id, err := s.VerifyToken(tokens.AccessToken)
+			if err != nil || id.Login != "Document" || id.Role != "read-only" {
+				t.Errorf("VerifyToken() = %v, %v", id, err)
+			}
+		})
+	}
+}
+
+func TestService_AuthUser_IssuesRefreshToken(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hpw, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), 15*time.Minute, 24*time.Hour, testPasswordParams)
+
+	var savedHash string
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+	mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+	mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), "Document", gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string, _ any, _ time.Time) error {
+			savedHash = hash
+			return nil
+		})
+	mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil)
+
+	tokens, err := s.AuthUser(context.Background(), "Document", "DocumenT1@")
+	if err != nil {
+		t.Fatalf("AuthUser() error = %v", err)
+	}
+	// в базу попадает только хеш
+	if savedHash == tokens.RefreshToken || savedHash != hashOpaqueToken(tokens.RefreshToken) {
+		t.Errorf("AuthUser() must store refresh token hash")
+	}
+
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil)
+	mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+	mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errStorage)
+	if _, err := s.AuthUser(context.Background(), "Document", "DocumenT1@"); !errors.Is(err, errStorage) {
+		t.Errorf("AuthUser() error = %v, wantErr %v", err, errStorage)
+	}
+}
+
+func TestService_RevokeRefreshToken(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), 15*time.Minute, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		token   string
+		wantErr error
+	}{
+		{
+			name: "success_revoke_family",
+			mockUp: func() {
+				mockStorage.EXPECT().RevokeRefreshFamily(gomock.Any(), "Document", hashOpaqueToken("old")).Return(nil)
+			},
+			token: "old",
+		},
+		{
+			name:    "error_empty_token",
+			mockUp:  func() {},
+			token:   "",
+			wantErr: ErrorRefreshToken,
+		},
+		{
+			name: "error_foreign_token",
+			mockUp: func() {
+				mockStorage.EXPECT().RevokeRefreshFamily(gomock.Any(), "Document", gomock.Any()).Return(pq.ErrRefreshTokenNotFound)
+			},
+			token:   "old",
+			wantErr: ErrorRefreshToken,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().RevokeRefreshFamily(gomock.Any(), "Document", gomock.Any()).Return(errStorage)
+			},
+			token:   "old",
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			err := s.RevokeRefreshToken(context.Background(), "Document", tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RevokeRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// после выхода refresh-токен сессии и его преемники не обновляются
+func TestService_RefreshAfterLogout(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), 15*time.Minute, 24*time.Hour, testPasswordParams)
+
+	// одна семья: хеши ее токенов и флаг отзыва, как в refresh_tokens
+	family := map[string]bool{}
+	revoked := false
+	mockStorage.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, oldHash, newHash string, _ time.Time) (string, error) {
+			if !family[oldHash] {
+				return "", pq.ErrRefreshTokenNotFound
+			}
+			if revoked {
+				return "", pq.ErrRefreshTokenReused
+			}
+			family[newHash] = true
+			return "Document", nil
+		}).AnyTimes()
+	mockStorage.EXPECT().RevokeRefreshFamily(gomock.Any(), "Document", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, hash string) error {
+			if !family[hash] {
+				return pq.ErrRefreshTokenNotFound
+			}
+			revoked = true
+			return nil
+		})
+	mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil).AnyTimes()
+
+	family[hashOpaqueToken("first")] = true
+	tokens, err := s.RefreshTokens(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("RefreshTokens() error = %v", err)
+	}
+
+	if err := s.RevokeRefreshToken(context.Background(), "Document", tokens.RefreshToken); err != nil {
+		t.Fatalf("RevokeRefreshToken() error = %v", err)
+	}
+
+	if _, err := s.RefreshTokens(context.Background(), tokens.RefreshToken); !errors.Is(err, ErrorRefreshToken) {
+		t.Errorf("RefreshTokens() after logout error = %v, wantErr %v", err, ErrorRefreshToken)
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RevokeToken - отзывает токен пользователя, после этого VerifyToken его не пропускает
+func (s *Service) RevokeToken(ctx context.Context, login, token string) error {
+	claims, err := s.checkToken(token)
+	if err != nil {
+		s.log.Error("RevokeToken", "failed to check token", err)
+		return fmt.Errorf("%w: %v", ErrorToken, err)
+	}
+	if claims.login != login {
+		return ErrorForbidden
+	}
+	if claims.jti == "" {
+		// старый токен без jti по отдельности не отозвать: отзываем все токены пользователя, выданные до этого момента
+		cutoff, err := s.storage.CutOffTokens(ctx, login)
+		if err != nil {
+			s.log.Error("RevokeToken", "failed to cut off tokens", err)
+			return err
+		}
+		s.markCutOff(login, cutoff)
+		return nil
+	}
+
+	err = s.storage.RevokeToken(ctx, claims.jti, claims.exp)
+	if err != nil {
+		s.log.Error("RevokeToken", "failed to revoke token", err)
+		return err
+	}
+	s.markRevoked(claims.jti, claims.exp)
+
+	return nil
+}
+
+// Run - периодически подтягивает отозванные другими экземплярами токены и чистит истекшие
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	s.syncRevoked(ctx)
+	s.syncCutoffs(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncRevoked(ctx)
+			s.syncCutoffs(ctx)
+		}
+	}
+}
+
+// syncRevoked - сливает таблицу отзыва с локальной копией, истекшие записи выбрасывает
+func (s *Service) syncRevoked(ctx context.Context) {
+	n, err := s.storage.DeleteExpiredRevocations(ctx)
+	if err != nil {
+		s.log.Error("syncRevoked", "failed to delete expired revocations", err)
+	} else if n > 0 {
+		s.log.Info("syncRevoked", "expired revocations deleted", n)
+	}
+
+	revoked, err := s.storage.GetRevokedTokens(ctx)
+	if err != nil {
+		s.log.Error("syncRevoked", "failed to get revoked tokens", err)
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revoked == nil {
+		s.revoked = make(map[string]time.Time)
+	}
+	// локальные записи оставляем: отзыв мог случиться, пока шел запрос
+	for jti, exp := range s.revoked {
+		if exp.Before(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	for jti, exp := range revoked {
+		s.revoked[jti] = exp
+	}
+}
+
+func (s *Service) markRevoked(jti string, exp time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revoked == nil {
+		s.revoked = make(map[string]time.Time)
+	}
+	s.revoked[jti] = exp
+}
+
+func (s *Service) isRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[jti]
+	return ok
+}
+
+// legacyTokenTTL - столько жили токены, выданные до появления iat и jti; такие токены могут еще ходить
+const legacyTokenTTL = 24 * time.Hour
+
+// syncCutoffs - подтягивает отзывы всех токенов пользователей
+// отзыв хранится, пока может действовать самый долгоживущий токен, иначе старый токен без iat снова заработает
+func (s *Service) syncCutoffs(ctx context.Context) {
+	since := time.Now().Add(-s.cutoffRetention())
+
+	cutoffs, err := s.storage.GetTokenCutoffs(ctx, since)
+	if err != nil {
+		s.log.Error("syncCutoffs", "failed to get token cutoffs", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cutoffs == nil {
+		s.cutoffs = make(map[string]time.Time)
+	}
+	for login, cutoff := range s.cutoffs {
+		if cutoff.Before(since) {
+			delete(s.cutoffs, login)
+		}
+	}
+	for login, cutoff := range cutoffs {
+		s.cutoffs[login] = cutoff
+	}
+}
+
+func (s *Service) cutoffRetention() time.Duration {
+	return max(s.accessTTL, legacyTokenTTL)
+}
+
+func (s *Service) markCutOff(login string, cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cutoffs == nil {
+		s.cutoffs = make(map[string]time.Time)
+	}
+	s.cutoffs[login] = cutoff
+}
+
+// isCutOff - токен выдан до отзыва всех токенов пользователя
+// iat хранится с точностью до секунды, поэтому токены, выданные в ту же секунду, что и отзыв, действуют
+func (s *Service) isCutOff(login string, iat time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff, ok := s.cutoffs[login]
+	if !ok {
+		return false
+	}
+	return iat.Unix() < cutoff.Unix()
+}
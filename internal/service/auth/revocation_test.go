@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_RevokeToken(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	token, err := s.generateToken("Document", "user")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		login   string
+		token   string
+		wantErr error
+	}{
+		{
+			name:    "error_invalid_token",
+			mockUp:  func() {},
+			login:   "Document",
+			token:   "bad_token",
+			wantErr: ErrorToken,
+		},
+		{
+			name:    "error_other_user",
+			mockUp:  func() {},
+			login:   "Other",
+			token:   token,
+			wantErr: ErrorForbidden,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(errStorage)
+			},
+			login:   "Document",
+			token:   token,
+			wantErr: errStorage,
+		},
+		{
+			name: "success_revoke_token",
+			mockUp: func() {
+				mockStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+			login:   "Document",
+			token:   token,
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			err := s.RevokeToken(context.Background(), tt.login, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RevokeToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := s.VerifyToken(token); err == nil {
+		t.Errorf("VerifyToken() must reject revoked token")
+	}
+}
+
+func TestService_VerifyToken_WithoutJTI(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	claims, err := s.checkToken(legacyToken(t, "salt", "Document"))
+	if err != nil {
+		t.Fatalf("checkToken() error = %v", err)
+	}
+	if claims.jti != "" || claims.login != "Document" {
+		t.Errorf("checkToken() = %+v", claims)
+	}
+
+	// токен без jti отзывается вместе со всеми выданными раньше токенами пользователя
+	legacy := legacyToken(t, "salt", "Document")
+	mockStorage.EXPECT().CutOffTokens(gomock.Any(), "Document").Return(time.Now(), nil)
+	if err := s.RevokeToken(context.Background(), "Document", legacy); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := s.VerifyToken(legacy); err == nil {
+		t.Errorf("VerifyToken() must reject revoked token without jti")
+	}
+
+	mockStorage.EXPECT().CutOffTokens(gomock.Any(), "Document").Return(time.Time{}, errStorage)
+	if err := s.RevokeToken(context.Background(), "Document", legacy); !errors.Is(err, errStorage) {
+		t.Errorf("RevokeToken() error = %v, wantErr %v", err, errStorage)
+	}
+}
+
+func TestService_syncRevoked(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	now := time.Now()
+	s.markRevoked("local", now.Add(time.Hour))
+	s.markRevoked("expired", now.Add(-time.Minute))
+
+	mockStorage.EXPECT().DeleteExpiredRevocations(gomock.Any()).Return(int64(1), nil)
+	mockStorage.EXPECT().GetRevokedTokens(gomock.Any()).Return(map[string]time.Time{
+		"remote": now.Add(time.Hour),
+	}, nil)
+
+	s.syncRevoked(context.Background())
+
+	if !s.isRevoked("local") || !s.isRevoked("remote") {
+		t.Errorf("syncRevoked() must keep local and add remote revocations")
+	}
+	if s.isRevoked("expired") {
+		t.Errorf("syncRevoked() must drop expired revocations")
+	}
+
+	// ошибка чтения таблицы не сбрасывает локальную копию
+	mockStorage.EXPECT().DeleteExpiredRevocations(gomock.Any()).Return(int64(0), errStorage)
+	mockStorage.EXPECT().GetRevokedTokens(gomock.Any()).Return(nil, errStorage)
+
+	s.syncRevoked(context.Background())
+
+	if !s.isRevoked("remote") {
+		t.Errorf("syncRevoked() must keep revocations on storage error")
+	}
+}
+
+// legacyToken - токен в формате до появления jti
+func legacyToken(t *testing.T, salt, login string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"login": login,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(salt))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
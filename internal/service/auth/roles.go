@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+)
+
+// SetRole - меняет роль пользователя, действующие токены сохраняют старую роль до обновления
+func (s *Service) SetRole(ctx context.Context, login, role string) error {
+	if !models.ValidRole(role) {
+		s.log.Error("SetRole", "invalid role", role)
+		return ErrorRole
+	}
+
+	if err := s.storage.SetRole(ctx, login, role); err != nil {
+		s.log.Error("SetRole", "failed to set role", err)
+		return err
+	}
+
+	return nil
+}
+
+// BootstrapAdmin - создает первого администратора при запуске
+// если администратор уже есть, ничего не делает: так переменные окружения не перезаписывают пароль
+func (s *Service) BootstrapAdmin(ctx context.Context, login, password string) error {
+	if err := validateLogin(login); err != nil {
+		s.log.Error("BootstrapAdmin", "invalid login", login)
+		return err
+	}
+	if err := validatePassword(password); err != nil {
+		s.log.Error("BootstrapAdmin", "error", "invalid password")
+		return err
+	}
+
+	hash, err := HashPassword(password, s.params)
+	if err != nil {
+		s.log.Error("BootstrapAdmin", "failed to hash password", err)
+		return err
+	}
+
+	created, err := s.storage.CreateFirstAdmin(ctx, login, hash)
+	if err != nil {
+		s.log.Error("BootstrapAdmin", "failed to create admin", err)
+		return err
+	}
+	if created {
+		s.log.Info("BootstrapAdmin", "status", "admin created", "login", login)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_SetRole(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		role    string
+		mockUp  func(m *Mockstorage)
+		wantErr error
+	}{
+		{
+			name: "success_set_role",
+			role: "read-only",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().SetRole(gomock.Any(), "Document", "read-only").Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "err_invalid_role",
+			role:    "root",
+			mockUp:  func(m *Mockstorage) {},
+			wantErr: ErrorRole,
+		},
+		{
+			name: "err_storage",
+			role: "admin",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().SetRole(gomock.Any(), "Document", "admin").Return(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := NewMockstorage(ctrl)
+			tt.mockUp(mockStorage)
+			s := &Service{
+				storage: mockStorage,
+				log:     log,
+			}
+			if err := s.SetRole(context.Background(), "Document", tt.role); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Service.SetRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_BootstrapAdmin(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		mockUp   func(m *Mockstorage)
+		wantErr  error
+	}{
+		{
+			name:     "success_created",
+			login:    "Document",
+			password: "DocumenT1@",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().CreateFirstAdmin(gomock.Any(), "Document", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, hash string) (bool, error) {
+						if ok, _ := checkPassword(hash, "DocumenT1@", testPasswordParams); !ok {
+							t.Errorf("BootstrapAdmin() must store password hash")
+						}
+						return true, nil
+					})
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "success_admin_exists",
+			login:    "Document",
+			password: "DocumenT1@",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().CreateFirstAdmin(gomock.Any(), "Document", gomock.Any()).Return(false, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "err_invalid_login",
+			login:    "Docu",
+			password: "DocumenT1@",
+			mockUp:   func(m *Mockstorage) {},
+			wantErr:  ErrorLogin,
+		},
+		{
+			name:     "err_invalid_password",
+			login:    "Document",
+			password: "Document",
+			mockUp:   func(m *Mockstorage) {},
+			wantErr:  ErrorPassword,
+		},
+		{
+			name:     "err_storage",
+			login:    "Document",
+			password: "DocumenT1@",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().CreateFirstAdmin(gomock.Any(), "Document", gomock.Any()).Return(false, errStorage)
+			},
+			wantErr: errStorage,
+		},
+		{
+			name:     "err_login_taken",
+			login:    "Document",
+			password: "DocumenT1@",
+			mockUp: func(m *Mockstorage) {
+				m.EXPECT().CreateFirstAdmin(gomock.Any(), "Document", gomock.Any()).Return(false, pq.ErrAdminLoginTaken)
+			},
+			wantErr: pq.ErrAdminLoginTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := NewMockstorage(ctrl)
+			tt.mockUp(mockStorage)
+			s := &Service{
+				storage: mockStorage,
+				log:     log,
+				params:  testPasswordParams,
+			}
+			if err := s.BootstrapAdmin(context.Background(), tt.login, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Service.BootstrapAdmin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
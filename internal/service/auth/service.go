@@ -1,52 +1,116 @@
 package auth
 
 import (
+	"caching_web_server/internal/models"
 	"context"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 var (
 	reLogin = regexp.MustCompile(`^[A-Za-z0-9]{8,}$`)
 
-	ErrorLogin    = errors.New("invalid login")
-	ErrorPassword = errors.New("invalid password")
+	ErrorLogin     = errors.New("invalid login")
+	ErrorPassword  = errors.New("invalid password")
+	ErrorToken     = errors.New("invalid token")
+	ErrorForbidden = errors.New("token belongs to another user")
+	ErrorRole      = errors.New("invalid role")
+
+	ErrorCurrentPassword = errors.New("invalid current password")
+
+	ErrorRefreshToken = errors.New("invalid refresh token")
+
+	ErrorMFAToken       = errors.New("invalid mfa token")
+	ErrorMFACode        = errors.New("invalid two-factor code")
+	ErrorMFAEnabled     = errors.New("two-factor authentication already enabled")
+	ErrorMFANotEnrolled = errors.New("two-factor authentication not enrolled")
 )
 
 const NameCookie = "token"
 
 //go:generate mockgen -source=service.go -destination=service_mock.go -package=auth
 type storage interface {
-	SaveUser(ctx context.Context, login, password string) error
+	SaveUser(ctx context.Context, login, password, role string) error
 	GetHashPass(ctx context.Context, login string) (string, error)
+	UpdatePasswordHash(ctx context.Context, login, passwordHash string) error
+	GetRole(ctx context.Context, login string) (string, error)
+	SetRole(ctx context.Context, login, role string) error
+	CreateFirstAdmin(ctx context.Context, login, passwordHash string) (bool, error)
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	CutOffTokens(ctx context.Context, login string) (time.Time, error)
+	GetRevokedTokens(ctx context.Context) (map[string]time.Time, error)
+	DeleteExpiredRevocations(ctx context.Context) (int64, error)
+	SaveRefreshToken(ctx context.Context, login, tokenHash string, family uuid.UUID, expiresAt time.Time) error
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (string, error)
+	RevokeRefreshFamily(ctx context.Context, login, tokenHash string) error
+	SaveAPIKey(ctx context.Context, login, keyHash string, key *models.APIKey) error
+	GetAPIKeys(ctx context.Context, login string) ([]models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, login string, id uuid.UUID) error
+	UseAPIKey(ctx context.Context, keyHash string) (models.Identity, *time.Time, error)
+	ChangePassword(ctx context.Context, login, passwordHash string) (time.Time, error)
+	GetTokenCutoffs(ctx context.Context, since time.Time) (map[string]time.Time, error)
+	DeleteUser(ctx context.Context, login string) (time.Time, error)
+	GetAccount(ctx context.Context, login string) (models.Account, error)
+	GetTOTP(ctx context.Context, login string) (string, bool, error)
+	SetTOTPSecret(ctx context.Context, login, secret string) error
+	EnableTOTP(ctx context.Context, login string, counter int64, codeHashes []string) error
+	UseTOTPCounter(ctx context.Context, login string, counter int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, login, codeHash string) (bool, error)
+	ResetTOTP(ctx context.Context, login string) error
 }
 
 type Service struct {
-	storage   storage
-	log       *slog.Logger
-	tokenSalt string
+	storage storage
+	log     *slog.Logger
+	keys    *KeySet
+	// accessTTL - время жизни access-токена, refreshTTL - refresh-токена
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	// params - параметры argon2id для новых хешей паролей
+	params PasswordParams
+	// dummy - хеш с теми же параметрами, по нему проверяется пароль неизвестного логина
+	dummyOnce sync.Once
+	dummy     string
+
+	// revoked - отозванные токены по jti со сроком действия, копия таблицы revoked_tokens
+	// cutoffs - токены пользователя, выданные раньше этого момента, не действуют: смена пароля и удаление учетной записи
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+	cutoffs map[string]time.Time
 }
 
 // NewService - создает новый сервис
-func NewService(storage storage, log *slog.Logger, tokenSalt string) *Service {
+func NewService(storage storage, log *slog.Logger, keys *KeySet, accessTTL, refreshTTL time.Duration, params PasswordParams) *Service {
 	return &Service{
-		storage:   storage,
-		log:       log,
-		tokenSalt: tokenSalt,
+		storage:    storage,
+		log:        log,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		params:     params,
+		revoked:    make(map[string]time.Time),
+		cutoffs:    make(map[string]time.Time),
 	}
 }
 
-// RegisterUser - регистрирует пользователя
-func (s *Service) RegisterUser(ctx context.Context, login, password string) error {
+// RegisterUser - регистрирует пользователя, без роли - обычный пользователь
+func (s *Service) RegisterUser(ctx context.Context, login, password, role string) error {
+	if role == "" {
+		role = models.RoleUser
+	}
+	if !models.ValidRole(role) {
+		s.log.Error("RegisterUser", "invalid role", role)
+		return ErrorRole
+	}
+
 	err := validateLogin(login)
 	if err != nil {
 		s.log.Error("RegisterUser", "invalid login", login)
@@ -59,13 +123,13 @@ func (s *Service) RegisterUser(ctx context.Context, login, password string) erro
 		return err
 	}
 
-	hash, err := HashPassword(password)
+	hash, err := HashPassword(password, s.params)
 	if err != nil {
 		s.log.Error("RegisterUser", "failed to hash password", err)
 		return err
 	}
 
-	err = s.storage.SaveUser(ctx, login, hash)
+	err = s.storage.SaveUser(ctx, login, hash, role)
 	if err != nil {
 		s.log.Error("RegisterUser", "failed to create user", err)
 		return err
@@ -113,116 +177,154 @@ func validatePassword(password string) error {
 	return nil
 }
 
-type passHash struct {
-	Salt string `json:"salt"`
-	Sum  string `json:"sum"`
-}
-
-// HashPassword - хеширует пароль
-func HashPassword(password string) (string, error) {
-	salt := make([]byte, 16)
-	if _, err := rand.Read(salt); err != nil {
-		return "", err
+// AuthUser - авторизует пользователя, при двухфакторной аутентификации возвращает только токен второго шага
+func (s *Service) AuthUser(ctx context.Context, login, password string) (models.Tokens, error) {
+	hashPass, err := s.storage.GetHashPass(ctx, login)
+	if err != nil {
+		// неизвестный логин неотличим от неверного пароля, в том числе по времени ответа
+		if errors.Is(err, sql.ErrNoRows) {
+			checkPassword(s.dummyHash(), password, s.params)
+			return models.Tokens{}, ErrorPassword
+		}
+		return models.Tokens{}, err
 	}
-
-	h := sha256.Sum256(append(salt, []byte(password)...))
-	ph := passHash{
-		Salt: base64.StdEncoding.EncodeToString(salt),
-		Sum:  base64.StdEncoding.EncodeToString(h[:]),
+	ok, rehash := checkPassword(hashPass, password, s.params)
+	if !ok {
+		return models.Tokens{}, ErrorPassword
 	}
-
-	result, err := json.Marshal(ph)
-	if err != nil {
-		return "", err
+	if rehash {
+		s.upgradeHash(ctx, login, password)
 	}
 
-	return string(result), nil
-}
-
-// AuthUser - авторизует пользователя
-func (s *Service) AuthUser(ctx context.Context, login, password string) (string, error) {
-	hashPass, err := s.storage.GetHashPass(ctx, login)
+	// с двухфакторной аутентификацией токены выдаются только после кода
+	_, enabled, err := s.storage.GetTOTP(ctx, login)
 	if err != nil {
-		return "", err
+		s.log.Error("AuthUser", "failed to get totp", err)
+		return models.Tokens{}, err
 	}
-	if !checkPassword(hashPass, password) {
-		return "", ErrorPassword
+	if enabled {
+		return s.mfaChallenge(login)
 	}
 
-	token, err := s.generateToken(login)
+	// каждый вход открывает новую семью refresh-токенов
+	refresh, err := s.issueRefreshToken(ctx, login, uuid.New())
 	if err != nil {
-		return "", err
+		return models.Tokens{}, err
 	}
-	return token, nil
 
+	return s.tokens(ctx, login, refresh)
 }
 
-// CheckPassword - проверяет пароль
-func checkPassword(hashJSON, pw string) bool {
-	var ph passHash
-	if err := json.Unmarshal([]byte(hashJSON), &ph); err != nil {
-		return false
-	}
-	salt, err := base64.StdEncoding.DecodeString(ph.Salt)
-	if err != nil {
-		return false
-	}
-	h := sha256.Sum256(append(salt, []byte(pw)...))
-	return ph.Sum == base64.StdEncoding.EncodeToString(h[:])
+// dummyHash - хеш случайного пароля, строится один раз при первом неизвестном логине
+func (s *Service) dummyHash() string {
+	s.dummyOnce.Do(func() {
+		hash, err := HashPassword(uuid.NewString(), s.params)
+		if err != nil {
+			s.log.Error("dummyHash", "failed to hash password", err)
+			return
+		}
+		s.dummy = hash
+	})
+	return s.dummy
 }
 
 // generateToken - генерирует токен
-func (s *Service) generateToken(login string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func (s *Service) generateToken(login, role string) (string, error) {
+	return s.keys.sign(jwt.MapClaims{
 		"login": login,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
+		"role":  role,
+		"jti":   uuid.NewString(),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(s.accessTTL).Unix(),
 	})
-	tokenString, err := token.SignedString([]byte(s.tokenSalt))
-	if err != nil {
-		return "", err
-	}
-	return tokenString, nil
 }
 
-// VerifyToken - проверяет токен
-func (s *Service) VerifyToken(token string) (string, error) {
-	login, err := s.checkToken(token)
+// JWKS - открытые ключи для проверки токенов другими сервисами
+func (s *Service) JWKS() JWKS {
+	return s.keys.JWKS()
+}
+
+// VerifyToken - проверяет токен и возвращает его владельца с ролью
+func (s *Service) VerifyToken(token string) (models.Identity, error) {
+	claims, err := s.checkToken(token)
 	if err != nil {
-		return "", err
+		return models.Identity{}, err
+	}
+	if claims.typ != "" || claims.aud != "" {
+		return models.Identity{}, fmt.Errorf("not an access token")
 	}
-	return login, nil
+	if s.isRevoked(claims.jti) || s.isCutOff(claims.login, claims.iat) {
+		return models.Identity{}, fmt.Errorf("token revoked")
+	}
+	return models.Identity{Login: claims.login, Role: claims.role}, nil
+}
+
+// tokenClaims - проверенные поля токена
+type tokenClaims struct {
+	login string
+	role  string
+	// jti - идентификатор токена, у выданных до появления отзыва его нет
+	jti string
+	// iat - время выдачи, у старых токенов нулевое
+	iat time.Time
+	// typ - пустой у access-токенов, mfa у токенов второго шага входа
+	typ string
+	// aud - пустой у access-токенов, audMFA у токенов второго шага входа
+	aud string
+	exp time.Time
 }
 
 // checkToken - проверяет токен
-func (s *Service) checkToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(s.tokenSalt), nil
-	})
+func (s *Service) checkToken(tokenString string) (tokenClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keys.verificationKey)
 
 	if err != nil {
-		return "", fmt.Errorf("invalid token: %v", err)
+		return tokenClaims{}, fmt.Errorf("invalid token: %v", err)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", fmt.Errorf("invalid token")
+		return tokenClaims{}, fmt.Errorf("invalid token")
 	}
 
-	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
-			return "", fmt.Errorf("token expired")
-		}
-	} else {
-		return "", fmt.Errorf("exp claim missing")
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return tokenClaims{}, fmt.Errorf("exp claim missing")
+	}
+	if time.Now().Unix() > int64(exp) {
+		return tokenClaims{}, fmt.Errorf("token expired")
 	}
 
 	login, ok := claims["login"].(string)
 	if !ok {
-		return "", fmt.Errorf("login not found in token claims")
+		return tokenClaims{}, fmt.Errorf("login not found in token claims")
+	}
+
+	jti, _ := claims["jti"].(string)
+	typ, _ := claims["typ"].(string)
+	aud, _ := claims["aud"].(string)
+
+	var iat time.Time
+	if v, ok := claims["iat"].(float64); ok {
+		iat = time.Unix(int64(v), 0)
+	}
+
+	// токены, выданные до появления ролей, живут недолго, считаем их владельцев обычными пользователями
+	role, ok := claims["role"].(string)
+	if !ok {
+		role = models.RoleUser
+	}
+	if s.keys.isLegacy(token) {
+		role = models.RoleReadOnly
 	}
 
-	return login, nil
+	return tokenClaims{
+		login: login,
+		role:  role,
+		jti:   jti,
+		iat:   iat,
+		typ:   typ,
+		aud:   aud,
+		exp:   time.Unix(int64(exp), 0),
+	}, nil
 }
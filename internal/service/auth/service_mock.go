@@ -5,10 +5,13 @@
 package auth
 
 import (
+	models "caching_web_server/internal/models"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // Mockstorage is a mock of storage interface.
@@ -34,6 +37,139 @@ func (m *Mockstorage) EXPECT() *MockstorageMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *Mockstorage) ChangePassword(ctx context.Context, login, passwordHash string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, login, passwordHash)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockstorageMockRecorder) ChangePassword(ctx, login, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*Mockstorage)(nil).ChangePassword), ctx, login, passwordHash)
+}
+
+// CreateFirstAdmin mocks base method.
+func (m *Mockstorage) CreateFirstAdmin(ctx context.Context, login, passwordHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFirstAdmin", ctx, login, passwordHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFirstAdmin indicates an expected call of CreateFirstAdmin.
+func (mr *MockstorageMockRecorder) CreateFirstAdmin(ctx, login, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFirstAdmin", reflect.TypeOf((*Mockstorage)(nil).CreateFirstAdmin), ctx, login, passwordHash)
+}
+
+// CutOffTokens mocks base method.
+func (m *Mockstorage) CutOffTokens(ctx context.Context, login string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CutOffTokens", ctx, login)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CutOffTokens indicates an expected call of CutOffTokens.
+func (mr *MockstorageMockRecorder) CutOffTokens(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CutOffTokens", reflect.TypeOf((*Mockstorage)(nil).CutOffTokens), ctx, login)
+}
+
+// DeleteAPIKey mocks base method.
+func (m *Mockstorage) DeleteAPIKey(ctx context.Context, login string, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKey", ctx, login, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIKey indicates an expected call of DeleteAPIKey.
+func (mr *MockstorageMockRecorder) DeleteAPIKey(ctx, login, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*Mockstorage)(nil).DeleteAPIKey), ctx, login, id)
+}
+
+// DeleteExpiredRevocations mocks base method.
+func (m *Mockstorage) DeleteExpiredRevocations(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRevocations", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredRevocations indicates an expected call of DeleteExpiredRevocations.
+func (mr *MockstorageMockRecorder) DeleteExpiredRevocations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevocations", reflect.TypeOf((*Mockstorage)(nil).DeleteExpiredRevocations), ctx)
+}
+
+// DeleteUser mocks base method.
+func (m *Mockstorage) DeleteUser(ctx context.Context, login string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, login)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockstorageMockRecorder) DeleteUser(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*Mockstorage)(nil).DeleteUser), ctx, login)
+}
+
+// EnableTOTP mocks base method.
+func (m *Mockstorage) EnableTOTP(ctx context.Context, login string, counter int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, login, counter, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockstorageMockRecorder) EnableTOTP(ctx, login, counter, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*Mockstorage)(nil).EnableTOTP), ctx, login, counter, codeHashes)
+}
+
+// GetAPIKeys mocks base method.
+func (m *Mockstorage) GetAPIKeys(ctx context.Context, login string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx, login)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockstorageMockRecorder) GetAPIKeys(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*Mockstorage)(nil).GetAPIKeys), ctx, login)
+}
+
+// GetAccount mocks base method.
+func (m *Mockstorage) GetAccount(ctx context.Context, login string) (models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, login)
+	ret0, _ := ret[0].(models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockstorageMockRecorder) GetAccount(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*Mockstorage)(nil).GetAccount), ctx, login)
+}
+
 // GetHashPass mocks base method.
 func (m *Mockstorage) GetHashPass(ctx context.Context, login string) (string, error) {
 	m.ctrl.T.Helper()
@@ -49,16 +185,250 @@ func (mr *MockstorageMockRecorder) GetHashPass(ctx, login interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHashPass", reflect.TypeOf((*Mockstorage)(nil).GetHashPass), ctx, login)
 }
 
+// GetRevokedTokens mocks base method.
+func (m *Mockstorage) GetRevokedTokens(ctx context.Context) (map[string]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevokedTokens", ctx)
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevokedTokens indicates an expected call of GetRevokedTokens.
+func (mr *MockstorageMockRecorder) GetRevokedTokens(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevokedTokens", reflect.TypeOf((*Mockstorage)(nil).GetRevokedTokens), ctx)
+}
+
+// GetRole mocks base method.
+func (m *Mockstorage) GetRole(ctx context.Context, login string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, login)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockstorageMockRecorder) GetRole(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*Mockstorage)(nil).GetRole), ctx, login)
+}
+
+// GetTOTP mocks base method.
+func (m *Mockstorage) GetTOTP(ctx context.Context, login string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", ctx, login)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockstorageMockRecorder) GetTOTP(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*Mockstorage)(nil).GetTOTP), ctx, login)
+}
+
+// GetTokenCutoffs mocks base method.
+func (m *Mockstorage) GetTokenCutoffs(ctx context.Context, since time.Time) (map[string]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenCutoffs", ctx, since)
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenCutoffs indicates an expected call of GetTokenCutoffs.
+func (mr *MockstorageMockRecorder) GetTokenCutoffs(ctx, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenCutoffs", reflect.TypeOf((*Mockstorage)(nil).GetTokenCutoffs), ctx, since)
+}
+
+// ResetTOTP mocks base method.
+func (m *Mockstorage) ResetTOTP(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTOTP", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTOTP indicates an expected call of ResetTOTP.
+func (mr *MockstorageMockRecorder) ResetTOTP(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTOTP", reflect.TypeOf((*Mockstorage)(nil).ResetTOTP), ctx, login)
+}
+
+// RevokeRefreshFamily mocks base method.
+func (m *Mockstorage) RevokeRefreshFamily(ctx context.Context, login, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshFamily", ctx, login, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshFamily indicates an expected call of RevokeRefreshFamily.
+func (mr *MockstorageMockRecorder) RevokeRefreshFamily(ctx, login, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshFamily", reflect.TypeOf((*Mockstorage)(nil).RevokeRefreshFamily), ctx, login, tokenHash)
+}
+
+// RevokeToken mocks base method.
+func (m *Mockstorage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockstorageMockRecorder) RevokeToken(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*Mockstorage)(nil).RevokeToken), ctx, jti, expiresAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *Mockstorage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, oldHash, newHash, expiresAt)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockstorageMockRecorder) RotateRefreshToken(ctx, oldHash, newHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*Mockstorage)(nil).RotateRefreshToken), ctx, oldHash, newHash, expiresAt)
+}
+
+// SaveAPIKey mocks base method.
+func (m *Mockstorage) SaveAPIKey(ctx context.Context, login, keyHash string, key *models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIKey", ctx, login, keyHash, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAPIKey indicates an expected call of SaveAPIKey.
+func (mr *MockstorageMockRecorder) SaveAPIKey(ctx, login, keyHash, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIKey", reflect.TypeOf((*Mockstorage)(nil).SaveAPIKey), ctx, login, keyHash, key)
+}
+
+// SaveRefreshToken mocks base method.
+func (m *Mockstorage) SaveRefreshToken(ctx context.Context, login, tokenHash string, family uuid.UUID, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, login, tokenHash, family, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRefreshToken indicates an expected call of SaveRefreshToken.
+func (mr *MockstorageMockRecorder) SaveRefreshToken(ctx, login, tokenHash, family, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*Mockstorage)(nil).SaveRefreshToken), ctx, login, tokenHash, family, expiresAt)
+}
+
 // SaveUser mocks base method.
-func (m *Mockstorage) SaveUser(ctx context.Context, login, password string) error {
+func (m *Mockstorage) SaveUser(ctx context.Context, login, password, role string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveUser", ctx, login, password)
+	ret := m.ctrl.Call(m, "SaveUser", ctx, login, password, role)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveUser indicates an expected call of SaveUser.
-func (mr *MockstorageMockRecorder) SaveUser(ctx, login, password interface{}) *gomock.Call {
+func (mr *MockstorageMockRecorder) SaveUser(ctx, login, password, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*Mockstorage)(nil).SaveUser), ctx, login, password, role)
+}
+
+// SetRole mocks base method.
+func (m *Mockstorage) SetRole(ctx context.Context, login, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, login, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockstorageMockRecorder) SetRole(ctx, login, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*Mockstorage)(nil).SetRole), ctx, login, role)
+}
+
+// SetTOTPSecret mocks base method.
+func (m *Mockstorage) SetTOTPSecret(ctx context.Context, login, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTOTPSecret", ctx, login, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTOTPSecret indicates an expected call of SetTOTPSecret.
+func (mr *MockstorageMockRecorder) SetTOTPSecret(ctx, login, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTPSecret", reflect.TypeOf((*Mockstorage)(nil).SetTOTPSecret), ctx, login, secret)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *Mockstorage) UpdatePasswordHash(ctx context.Context, login, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, login, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockstorageMockRecorder) UpdatePasswordHash(ctx, login, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*Mockstorage)(nil).UpdatePasswordHash), ctx, login, passwordHash)
+}
+
+// UseAPIKey mocks base method.
+func (m *Mockstorage) UseAPIKey(ctx context.Context, keyHash string) (models.Identity, *time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAPIKey", ctx, keyHash)
+	ret0, _ := ret[0].(models.Identity)
+	ret1, _ := ret[1].(*time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UseAPIKey indicates an expected call of UseAPIKey.
+func (mr *MockstorageMockRecorder) UseAPIKey(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAPIKey", reflect.TypeOf((*Mockstorage)(nil).UseAPIKey), ctx, keyHash)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *Mockstorage) UseRecoveryCode(ctx context.Context, login, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, login, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockstorageMockRecorder) UseRecoveryCode(ctx, login, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*Mockstorage)(nil).UseRecoveryCode), ctx, login, codeHash)
+}
+
+// UseTOTPCounter mocks base method.
+func (m *Mockstorage) UseTOTPCounter(ctx context.Context, login string, counter int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPCounter", ctx, login, counter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPCounter indicates an expected call of UseTOTPCounter.
+func (mr *MockstorageMockRecorder) UseTOTPCounter(ctx, login, counter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*Mockstorage)(nil).SaveUser), ctx, login, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPCounter", reflect.TypeOf((*Mockstorage)(nil).UseTOTPCounter), ctx, login, counter)
 }
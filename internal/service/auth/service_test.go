@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 )
@@ -19,7 +22,7 @@ func TestNewService(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 
-	newService := NewService(mockStorage, log, "")
+	newService := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
 
 	if newService == nil {
 		t.Errorf("NewService() = %v, want %v", newService, "not nil")
@@ -33,6 +36,8 @@ func TestService_RegisterUser(t *testing.T) {
 		name       string
 		login      string
 		password   string
+		role       string
+		wantRole   string
 		errStorage error
 		wantErr    error
 	}{
@@ -40,8 +45,24 @@ func TestService_RegisterUser(t *testing.T) {
 			name:     "success_register",
 			login:    "Document",
 			password: "DocumenT1@",
+			wantRole: "user",
 			wantErr:  nil,
 		},
+		{
+			name:     "success_register_admin",
+			login:    "Document",
+			password: "DocumenT1@",
+			role:     "admin",
+			wantRole: "admin",
+			wantErr:  nil,
+		},
+		{
+			name:     "err_invalid_role",
+			login:    "Document",
+			password: "DocumenT1@",
+			role:     "root",
+			wantErr:  ErrorRole,
+		},
 		{
 			name:     "err_invalid_login",
 			login:    "Docu",
@@ -64,6 +85,7 @@ func TestService_RegisterUser(t *testing.T) {
 			name:       "err_storage",
 			login:      "Document",
 			password:   "DocumenT1@",
+			wantRole:   "user",
 			errStorage: errStorage,
 			wantErr:    errStorage,
 		},
@@ -75,12 +97,13 @@ func TestService_RegisterUser(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockStorage := NewMockstorage(ctrl)
-			mockStorage.EXPECT().SaveUser(gomock.Any(), tt.login, gomock.Any()).Return(tt.errStorage).AnyTimes()
+			mockStorage.EXPECT().SaveUser(gomock.Any(), tt.login, gomock.Any(), tt.wantRole).Return(tt.errStorage).AnyTimes()
 			s := &Service{
 				storage: mockStorage,
 				log:     log,
+				params:  testPasswordParams,
 			}
-			if err := s.RegisterUser(nil, tt.login, tt.password); !errors.Is(err, tt.wantErr) {
+			if err := s.RegisterUser(nil, tt.login, tt.password, tt.role); !errors.Is(err, tt.wantErr) {
 				t.Errorf("Service.RegisterUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -89,7 +112,7 @@ func TestService_RegisterUser(t *testing.T) {
 
 func TestService_AuthUser(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
-	hpw, err := HashPassword("DocumenT1@")
+	hpw, err := HashPassword("DocumenT1@", testPasswordParams)
 	if err != nil {
 		log.Error("TestService_AuthUser", "failed to hash password", err)
 	}
@@ -114,6 +137,11 @@ func TestService_AuthUser(t *testing.T) {
 			errStorage: errStorage,
 			wantErr:    errStorage,
 		},
+		{
+			name:       "unknown_login",
+			errStorage: sql.ErrNoRows,
+			wantErr:    ErrorPassword,
+		},
 		{
 			name:    "bad_hash_pass",
 			hpw:     "bad_hash_pass",
@@ -128,17 +156,46 @@ func TestService_AuthUser(t *testing.T) {
 
 			newMockStorage := NewMockstorage(ctrl)
 			newMockStorage.EXPECT().GetHashPass(gomock.Any(), gomock.Any()).Return(tt.hpw, tt.errStorage).AnyTimes()
+			newMockStorage.EXPECT().SaveRefreshToken(gomock.Any(), tt.login, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			newMockStorage.EXPECT().GetRole(gomock.Any(), tt.login).Return("user", nil).AnyTimes()
+			newMockStorage.EXPECT().GetTOTP(gomock.Any(), tt.login).Return("", false, nil).AnyTimes()
 
-			s := NewService(newMockStorage, log, "")
+			s := NewService(newMockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
 
 			_, err := s.AuthUser(nil, tt.login, tt.password)
 			if !errors.Is(err, tt.wantErr) {
-				log.Error("TestService_AuthUser", "failed to save user", err)
+				t.Errorf("Service.AuthUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+// неизвестный логин проверяется по фиктивному хешу с теми же параметрами, что и настоящие
+func TestService_AuthUser_UnknownLoginHashes(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Unknown1").Return("", sql.ErrNoRows).Times(2)
+
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	for range 2 {
+		if _, err := s.AuthUser(context.Background(), "Unknown1", "DocumenT1@"); !errors.Is(err, ErrorPassword) {
+			t.Errorf("Service.AuthUser() error = %v, wantErr %v", err, ErrorPassword)
+		}
+	}
+
+	params, _, _, err := decodeArgon2(s.dummy)
+	if err != nil {
+		t.Fatalf("dummy hash = %q, error = %v", s.dummy, err)
+	}
+	if params != testPasswordParams {
+		t.Errorf("dummy hash params = %+v, want %+v", params, testPasswordParams)
+	}
+}
+
 func TestService_VerifyToken(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
 	ctrl := gomock.NewController(t)
@@ -147,11 +204,13 @@ func TestService_VerifyToken(t *testing.T) {
 	mockStorage := NewMockstorage(ctrl)
 
 	service := &Service{
-		storage: mockStorage,
-		log:     log,
+		storage:   mockStorage,
+		log:       log,
+		keys:      NewHMACKeySet("salt"),
+		accessTTL: time.Hour,
 	}
 
-	token, err := service.generateToken("Document")
+	token, err := service.generateToken("Document", "user")
 	if err != nil {
 		log.Error("TestService_VerifyToken", "failed to generate token", err)
 		return
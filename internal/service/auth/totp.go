@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// параметры TOTP по RFC 6238, их понимают все приложения-аутентификаторы
+const (
+	totpIssuer = "CachingWebServer"
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew - сколько соседних шагов принимается из-за расхождения часов
+	totpSkew = 1
+
+	// mfaTokenTTL - сколько действует токен второго шага входа
+	mfaTokenTTL = 5 * time.Minute
+	// typMFA - тип токена второго шага, как access-токен он не принимается
+	typMFA = "mfa"
+	// audMFA - получатель токена второго шага: токен подписан ключами из JWKS, и другие сервисы, проверяющие aud, его не примут
+	audMFA = "caching_web_server/mfa"
+
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP - выдает новый секрет, двухфакторная аутентификация включится после подтверждения кодом
+// повторный вызов до подтверждения заменяет секрет
+func (s *Service) EnrollTOTP(ctx context.Context, login string) (models.TOTPEnrollment, error) {
+	_, enabled, err := s.storage.GetTOTP(ctx, login)
+	if err != nil {
+		s.log.Error("EnrollTOTP", "failed to get totp", err)
+		return models.TOTPEnrollment{}, err
+	}
+	if enabled {
+		return models.TOTPEnrollment{}, ErrorMFAEnabled
+	}
+
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		s.log.Error("EnrollTOTP", "failed to generate secret", err)
+		return models.TOTPEnrollment{}, err
+	}
+	secret := totpEncoding.EncodeToString(key)
+
+	if err := s.storage.SetTOTPSecret(ctx, login, secret); err != nil {
+		if errors.Is(err, pq.ErrTOTPEnabled) {
+			return models.TOTPEnrollment{}, ErrorMFAEnabled
+		}
+		s.log.Error("EnrollTOTP", "failed to save secret", err)
+		return models.TOTPEnrollment{}, err
+	}
+
+	return models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totpURI(login, secret),
+	}, nil
+}
+
+// ConfirmTOTP - включает двухфакторную аутентификацию по первому коду из приложения
+// возвращает коды восстановления, в базе остаются только их хеши
+func (s *Service) ConfirmTOTP(ctx context.Context, login, code string) ([]string, error) {
+	secret, enabled, err := s.storage.GetTOTP(ctx, login)
+	if err != nil {
+		s.log.Error("ConfirmTOTP", "failed to get totp", err)
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrorMFAEnabled
+	}
+	if secret == "" {
+		return nil, ErrorMFANotEnrolled
+	}
+
+	counter, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrorMFACode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			s.log.Error("ConfirmTOTP", "failed to generate recovery code", err)
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashOpaqueToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.storage.EnableTOTP(ctx, login, counter, hashes); err != nil {
+		if errors.Is(err, pq.ErrTOTPEnabled) {
+			return nil, ErrorMFAEnabled
+		}
+		s.log.Error("ConfirmTOTP", "failed to enable totp", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// ResetTOTP - выключает двухфакторную аутентификацию пользователя, например после потери телефона
+func (s *Service) ResetTOTP(ctx context.Context, login string) error {
+	if err := s.storage.ResetTOTP(ctx, login); err != nil {
+		s.log.Error("ResetTOTP", "failed to reset totp", err)
+		return err
+	}
+	return nil
+}
+
+// MFALogin - логин из токена второго шага, нужен до проверки кода для защиты от перебора
+func (s *Service) MFALogin(mfaToken string) (string, error) {
+	claims, err := s.checkMFAToken(mfaToken)
+	if err != nil {
+		return "", err
+	}
+	return claims.login, nil
+}
+
+// AuthMFA - второй шаг входа: код из приложения или неиспользованный код восстановления
+// после успешного входа токен второго шага отзывается, повторно им не войти
+func (s *Service) AuthMFA(ctx context.Context, mfaToken, code string) (models.Tokens, error) {
+	claims, err := s.checkMFAToken(mfaToken)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	login := claims.login
+
+	secret, enabled, err := s.storage.GetTOTP(ctx, login)
+	if err != nil {
+		if errors.Is(err, pq.ErrUserNotFound) {
+			return models.Tokens{}, ErrorMFAToken
+		}
+		s.log.Error("AuthMFA", "failed to get totp", err)
+		return models.Tokens{}, err
+	}
+	// администратор мог сбросить двухфакторную аутентификацию, пока шел вход
+	if !enabled {
+		return models.Tokens{}, ErrorMFAToken
+	}
+
+	if err := s.checkMFACode(ctx, login, secret, code); err != nil {
+		return models.Tokens{}, err
+	}
+
+	if err := s.storage.RevokeToken(ctx, claims.jti, claims.exp); err != nil {
+		s.log.Error("AuthMFA", "failed to revoke mfa token", err)
+		return models.Tokens{}, err
+	}
+	s.markRevoked(claims.jti, claims.exp)
+
+	refresh, err := s.issueRefreshToken(ctx, login, uuid.New())
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	return s.tokens(ctx, login, refresh)
+}
+
+// checkMFAToken - проверяет токен второго шага: тип, получателя и отзыв, как у access-токенов
+func (s *Service) checkMFAToken(mfaToken string) (tokenClaims, error) {
+	claims, err := s.checkToken(mfaToken)
+	if err != nil {
+		return tokenClaims{}, fmt.Errorf("%w: %v", ErrorMFAToken, err)
+	}
+	if claims.typ != typMFA || claims.aud != audMFA || claims.jti == "" {
+		return tokenClaims{}, ErrorMFAToken
+	}
+	if s.isRevoked(claims.jti) || s.isCutOff(claims.login, claims.iat) {
+		return tokenClaims{}, fmt.Errorf("%w: token revoked", ErrorMFAToken)
+	}
+	return claims, nil
+}
+
+// checkMFACode - принимает каждый код TOTP один раз, код восстановления гасится
+func (s *Service) checkMFACode(ctx context.Context, login, secret, code string) error {
+	if counter, ok := matchTOTP(secret, code, time.Now()); ok {
+		fresh, err := s.storage.UseTOTPCounter(ctx, login, counter)
+		if err != nil {
+			s.log.Error("checkMFACode", "failed to use totp counter", err)
+			return err
+		}
+		if !fresh {
+			return ErrorMFACode
+		}
+		return nil
+	}
+
+	used, err := s.storage.UseRecoveryCode(ctx, login, hashOpaqueToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		s.log.Error("checkMFACode", "failed to use recovery code", err)
+		return err
+	}
+	if !used {
+		return ErrorMFACode
+	}
+	s.log.Info("checkMFACode", "status", "recovery code used", "login", login)
+	return nil
+}
+
+// mfaChallenge - ответ на вход по паролю, когда нужен второй шаг
+func (s *Service) mfaChallenge(login string) (models.Tokens, error) {
+	token, err := s.keys.sign(jwt.MapClaims{
+		"login": login,
+		"typ":   typMFA,
+		"aud":   audMFA,
+		"jti":   uuid.NewString(),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(mfaTokenTTL).Unix(),
+	})
+	if err != nil {
+		s.log.Error("mfaChallenge", "failed to sign mfa token", err)
+		return models.Tokens{}, err
+	}
+
+	return models.Tokens{
+		MFAToken:  token,
+		ExpiresIn: int64(mfaTokenTTL / time.Second),
+	}, nil
+}
+
+// totpURI - ссылка otpauth:// для QR-кода
+func totpURI(login, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s?%s", url.PathEscape(totpIssuer+":"+login), q.Encode())
+}
+
+// matchTOTP - проверяет код с допуском в totpSkew шагов и возвращает шаг, которому он соответствует
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		counter := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(counter))), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode - HOTP по RFC 4226 для номера шага
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range totpDigits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// newRecoveryCode - одноразовый код вида xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode - код принимается без дефиса, пробелов и в любом регистре
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,403 @@
+package auth
+
+import (
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// testTOTPSecret - ключ "12345678901234567890" из приложения B RFC 6238
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode_RFC6238(t *testing.T) {
+	key, err := totpEncoding.DecodeString(testTOTPSecret)
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+
+	// последние 6 цифр 8-значных кодов из RFC
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, uint64(tt.unix/totpPeriod)); got != tt.want {
+			t.Errorf("totpCode(%d) = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+	}{
+		{name: "current_step", secret: testTOTPSecret, code: "081804", want: true},
+		{name: "previous_step", secret: testTOTPSecret, code: currentCode(t, now.Add(-totpPeriod*time.Second)), want: true},
+		{name: "too_old", secret: testTOTPSecret, code: currentCode(t, now.Add(-3*totpPeriod*time.Second)), want: false},
+		{name: "wrong_code", secret: testTOTPSecret, code: "000000", want: false},
+		{name: "short_code", secret: testTOTPSecret, code: "0818", want: false},
+		{name: "bad_secret", secret: "!!!", code: "081804", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := matchTOTP(tt.secret, tt.code, now); got != tt.want {
+				t.Errorf("matchTOTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotpURI(t *testing.T) {
+	u, err := url.Parse(totpURI("Document", testTOTPSecret))
+	if err != nil {
+		t.Fatalf("failed to parse uri: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/"+totpIssuer+":Document" {
+		t.Errorf("totpURI() = %v", u)
+	}
+	if u.Query().Get("secret") != testTOTPSecret || u.Query().Get("issuer") != totpIssuer {
+		t.Errorf("totpURI() query = %v", u.Query())
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	code, err := newRecoveryCode()
+	if err != nil {
+		t.Fatalf("newRecoveryCode() error = %v", err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("newRecoveryCode() = %v", code)
+	}
+	if normalizeRecoveryCode(" "+strings.ToUpper(code)) != strings.ReplaceAll(code, "-", "") {
+		t.Errorf("normalizeRecoveryCode() must ignore case, spaces and dash")
+	}
+}
+
+func TestService_EnrollTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		wantErr error
+	}{
+		{
+			name: "error_enabled",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+			},
+			wantErr: ErrorMFAEnabled,
+		},
+		{
+			name: "error_enabled_concurrently",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+				mockStorage.EXPECT().SetTOTPSecret(gomock.Any(), "Document", gomock.Any()).Return(pq.ErrTOTPEnabled)
+			},
+			wantErr: ErrorMFAEnabled,
+		},
+		{
+			name: "error_storage",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, errStorage)
+			},
+			wantErr: errStorage,
+		},
+		{
+			name: "success_enroll",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+				mockStorage.EXPECT().SetTOTPSecret(gomock.Any(), "Document", gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			got, err := s.EnrollTOTP(context.Background(), "Document")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnrollTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (len(got.Secret) != 32 || !strings.Contains(got.URI, got.Secret)) {
+				t.Errorf("EnrollTOTP() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestService_ConfirmTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		code    string
+		wantErr error
+	}{
+		{
+			name: "error_not_enrolled",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+			},
+			code:    "123456",
+			wantErr: ErrorMFANotEnrolled,
+		},
+		{
+			name: "error_enabled",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+			},
+			code:    "123456",
+			wantErr: ErrorMFAEnabled,
+		},
+		{
+			name: "error_code",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, false, nil)
+			},
+			code:    "bad",
+			wantErr: ErrorMFACode,
+		},
+		{
+			name: "success_confirm",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, false, nil)
+				mockStorage.EXPECT().EnableTOTP(gomock.Any(), "Document", gomock.Any(), gomock.Len(recoveryCodeCount)).Return(nil)
+			},
+			code:    currentCode(t, time.Now()),
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			codes, err := s.ConfirmTOTP(context.Background(), "Document", tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ConfirmTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(codes) != recoveryCodeCount {
+				t.Errorf("ConfirmTOTP() returned %d recovery codes", len(codes))
+			}
+		})
+	}
+}
+
+func TestService_AuthMFA(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hpw, err := HashPassword("DocumenT1@", testPasswordParams)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	// первый шаг: вместо токенов - токен второго шага
+	mockStorage.EXPECT().GetHashPass(gomock.Any(), "Document").Return(hpw, nil).Times(2)
+	mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil).Times(2)
+	challenge, err := s.AuthUser(context.Background(), "Document", "DocumenT1@")
+	if err != nil {
+		t.Fatalf("AuthUser() error = %v", err)
+	}
+	if challenge.MFAToken == "" || challenge.AccessToken != "" || challenge.RefreshToken != "" {
+		t.Fatalf("AuthUser() = %+v, want only mfa token", challenge)
+	}
+	if _, err := s.VerifyToken(challenge.MFAToken); err == nil {
+		t.Errorf("VerifyToken() must reject mfa token")
+	}
+	if claims, err := s.checkToken(challenge.MFAToken); err != nil || claims.aud != audMFA {
+		t.Errorf("mfa token aud = %q, %v, want %q", claims.aud, err, audMFA)
+	}
+
+	// второй шаг после отзыва всех токенов пользователя, например смены пароля, не проходит
+	cutOff, err := s.AuthUser(context.Background(), "Document", "DocumenT1@")
+	if err != nil {
+		t.Fatalf("AuthUser() error = %v", err)
+	}
+	s.markCutOff("Document", time.Now().Add(time.Second))
+	if _, err := s.MFALogin(cutOff.MFAToken); !errors.Is(err, ErrorMFAToken) {
+		t.Errorf("MFALogin() error = %v, want %v after cutoff", err, ErrorMFAToken)
+	}
+	s.cutoffs = nil
+
+	second, err := s.mfaChallenge("Document")
+	if err != nil {
+		t.Fatalf("mfaChallenge() error = %v", err)
+	}
+
+	access, err := s.generateToken("Document", "user")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		token   string
+		code    string
+		wantErr error
+	}{
+		{
+			name:    "error_access_token",
+			mockUp:  func() {},
+			token:   access,
+			code:    "123456",
+			wantErr: ErrorMFAToken,
+		},
+		{
+			name: "error_reset_by_admin",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return("", false, nil)
+			},
+			token:   challenge.MFAToken,
+			code:    "123456",
+			wantErr: ErrorMFAToken,
+		},
+		{
+			name: "error_replayed_code",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+				mockStorage.EXPECT().UseTOTPCounter(gomock.Any(), "Document", gomock.Any()).Return(false, nil)
+			},
+			token:   challenge.MFAToken,
+			code:    currentCode(t, time.Now()),
+			wantErr: ErrorMFACode,
+		},
+		{
+			name: "error_unknown_recovery_code",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+				mockStorage.EXPECT().UseRecoveryCode(gomock.Any(), "Document", hashOpaqueToken("abcdefghij")).Return(false, nil)
+			},
+			token:   challenge.MFAToken,
+			code:    "ABCDE-FGHIJ",
+			wantErr: ErrorMFACode,
+		},
+		{
+			name: "success_totp",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+				mockStorage.EXPECT().UseTOTPCounter(gomock.Any(), "Document", gomock.Any()).Return(true, nil)
+				mockStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), "Document", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil)
+			},
+			token:   challenge.MFAToken,
+			code:    currentCode(t, time.Now()),
+			wantErr: nil,
+		},
+		{
+			// токен второго шага одноразовый
+			name:    "error_reused_mfa_token",
+			mockUp:  func() {},
+			token:   challenge.MFAToken,
+			code:    "abcde-fghij",
+			wantErr: ErrorMFAToken,
+		},
+		{
+			name: "error_revoke_mfa_token",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+				mockStorage.EXPECT().UseRecoveryCode(gomock.Any(), "Document", hashOpaqueToken("abcdefghij")).Return(true, nil)
+				mockStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(errStorage)
+			},
+			token:   second.MFAToken,
+			code:    "abcde-fghij",
+			wantErr: errStorage,
+		},
+		{
+			name: "success_recovery_code",
+			mockUp: func() {
+				mockStorage.EXPECT().GetTOTP(gomock.Any(), "Document").Return(testTOTPSecret, true, nil)
+				mockStorage.EXPECT().UseRecoveryCode(gomock.Any(), "Document", hashOpaqueToken("abcdefghij")).Return(true, nil)
+				mockStorage.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockStorage.EXPECT().SaveRefreshToken(gomock.Any(), "Document", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockStorage.EXPECT().GetRole(gomock.Any(), "Document").Return("user", nil)
+			},
+			token:   second.MFAToken,
+			code:    "abcde-fghij",
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			tokens, err := s.AuthMFA(context.Background(), tt.token, tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthMFA() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if _, err := s.VerifyToken(tokens.AccessToken); err != nil {
+					t.Errorf("VerifyToken() must accept token after second step: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestService_ResetTOTP(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, log, NewHMACKeySet("salt"), time.Hour, 24*time.Hour, testPasswordParams)
+
+	mockStorage.EXPECT().ResetTOTP(gomock.Any(), "Document").Return(nil)
+	if err := s.ResetTOTP(context.Background(), "Document"); err != nil {
+		t.Errorf("ResetTOTP() error = %v", err)
+	}
+
+	mockStorage.EXPECT().ResetTOTP(gomock.Any(), "Document").Return(pq.ErrUserNotFound)
+	if err := s.ResetTOTP(context.Background(), "Document"); !errors.Is(err, pq.ErrUserNotFound) {
+		t.Errorf("ResetTOTP() error = %v, wantErr %v", err, pq.ErrUserNotFound)
+	}
+}
+
+// currentCode - код для тестового секрета на момент now
+func currentCode(t *testing.T, now time.Time) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(testTOTPSecret)
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+	return totpCode(key, uint64(now.Unix()/totpPeriod))
+}
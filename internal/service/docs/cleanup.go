@@ -0,0 +1,45 @@
+package docs
+
+import (
+	"context"
+	"time"
+)
+
+// cleanupBatch - сколько файлов удаляется из хранилища за один проход
+const cleanupBatch = 100
+
+// RunCleanup - периодически удаляет из хранилища файлы удаленных учетных записей
+func (s *Service) RunCleanup(ctx context.Context, interval time.Duration) {
+	s.cleanup(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup - один проход по очереди удаления, файл с ошибкой остается до следующего прохода
+func (s *Service) cleanup(ctx context.Context) {
+	paths, err := s.storage.GetCleanupPaths(ctx, cleanupBatch)
+	if err != nil {
+		s.log.Error("cleanup", "failed to get cleanup paths", err)
+		return
+	}
+
+	for _, path := range paths {
+		if err := s.s3.DeleteFile(path); err != nil {
+			s.log.Error("cleanup", "failed to delete file", err)
+			continue
+		}
+		if err := s.storage.DeleteCleanupPath(ctx, path); err != nil {
+			s.log.Error("cleanup", "failed to delete cleanup path", err)
+		}
+	}
+}
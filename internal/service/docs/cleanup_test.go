@@ -0,0 +1,58 @@
+package docs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_cleanup(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockS3 := NewMocks3(ctrl)
+
+	s := NewService(mockStorage, mockS3, NewMockcache(ctrl), NewMocklistCache(ctrl), NewMockpurger(ctrl), log)
+
+	tests := []struct {
+		name string
+		mock func()
+	}{
+		{
+			name: "error_get_paths",
+			mock: func() {
+				mockStorage.EXPECT().GetCleanupPaths(gomock.Any(), cleanupBatch).Return(nil, errors.New("db error"))
+			},
+		},
+		{
+			name: "file_stays_in_queue_on_s3_error",
+			mock: func() {
+				mockStorage.EXPECT().GetCleanupPaths(gomock.Any(), cleanupBatch).Return([]string{"a.pdf", "b.pdf"}, nil)
+				mockS3.EXPECT().DeleteFile("a.pdf").Return(errors.New("s3 error"))
+				mockS3.EXPECT().DeleteFile("b.pdf").Return(nil)
+				mockStorage.EXPECT().DeleteCleanupPath(gomock.Any(), "b.pdf").Return(nil)
+			},
+		},
+		{
+			name: "success_cleanup",
+			mock: func() {
+				mockStorage.EXPECT().GetCleanupPaths(gomock.Any(), cleanupBatch).Return([]string{"a.pdf"}, nil)
+				mockS3.EXPECT().DeleteFile("a.pdf").Return(nil)
+				mockStorage.EXPECT().DeleteCleanupPath(gomock.Any(), "a.pdf").Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s.cleanup(context.Background())
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGrantLogins     = errors.New("logins or groups required")
+	ErrGrantPermission = errors.New("invalid permission")
+)
+
+// GetGrants - кому открыт доступ к документу, видят владелец и пользователи с правом manage
+func (s *Service) GetGrants(ctx context.Context, login, docID string) ([]models.Grant, error) {
+	id, err := uuid.Parse(docID)
+	if err != nil {
+		s.log.Error("GetGrants", "failed to parse document id", err)
+		return nil, err
+	}
+
+	grants, err := s.storage.GetGrants(ctx, login, id)
+	if err != nil {
+		s.log.Error("GetGrants", "failed to get grants", err)
+		return nil, err
+	}
+	return grants, nil
+}
+
+// AddGrants - открывает доступ к документу пользователям и группам или меняет его уровень и возвращает новый список
+// без уровня выдается доступ на чтение
+func (s *Service) AddGrants(ctx context.Context, login, docID string, logins, groups []string, permission string) ([]models.Grant, error) {
+	id, err := uuid.Parse(docID)
+	if err != nil {
+		s.log.Error("AddGrants", "failed to parse document id", err)
+		return nil, err
+	}
+	if permission == "" {
+		permission = models.PermissionRead
+	}
+	if !models.ValidPermission(permission) {
+		return nil, ErrGrantPermission
+	}
+	logins = normalizeNames(logins, login)
+	groups = normalizeNames(groups, "")
+	if len(logins) == 0 && len(groups) == 0 {
+		return nil, ErrGrantLogins
+	}
+
+	if err := s.storage.AddGrants(ctx, login, id, logins, groups, permission); err != nil {
+		s.log.Error("AddGrants", "failed to add grants", err)
+		return nil, err
+	}
+	s.grantsChanged(id, logins)
+
+	return s.GetGrants(ctx, login, docID)
+}
+
+// DeleteGrants - закрывает доступ к документу пользователям и группам и возвращает новый список
+func (s *Service) DeleteGrants(ctx context.Context, login, docID string, logins, groups []string) ([]models.Grant, error) {
+	id, err := uuid.Parse(docID)
+	if err != nil {
+		s.log.Error("DeleteGrants", "failed to parse document id", err)
+		return nil, err
+	}
+	logins = normalizeNames(logins, login)
+	groups = normalizeNames(groups, "")
+	if len(logins) == 0 && len(groups) == 0 {
+		return nil, ErrGrantLogins
+	}
+
+	if err := s.storage.DeleteGrants(ctx, login, id, logins, groups); err != nil {
+		s.log.Error("DeleteGrants", "failed to delete grants", err)
+		return nil, err
+	}
+	s.grantsChanged(id, logins)
+
+	return s.GetGrants(ctx, login, docID)
+}
+
+// grantsChanged - сбрасывает кеш на этом экземпляре сразу, не дожидаясь уведомления
+// списки участников групп сбрасываются по уведомлению: их логины знает только хранилище
+func (s *Service) grantsChanged(id uuid.UUID, logins []string) {
+	s.cache.Delete(id.String())
+	s.invalidateLists(logins...)
+	s.purger.PurgeDocument(id.String())
+}
+
+// normalizeNames - убирает пустые имена, повторы и self: свой уровень себе не меняют
+func normalizeNames(names []string, self string) []string {
+	var out []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || name == self || slices.Contains(out, name) {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
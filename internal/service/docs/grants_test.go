@@ -0,0 +1,240 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func TestService_AddGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), mockCache, mockListCache, mockPurger, log)
+
+	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
+	id := uuid.MustParse(docID)
+
+	tests := []struct {
+		name       string
+		mock       func()
+		id         string
+		logins     []string
+		groups     []string
+		permission string
+		want       []models.Grant
+		wantErr    error
+	}{
+		{
+			name: "success_add_grants",
+			mock: func() {
+				// повторы, пустые логины и сам владелец отбрасываются
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, []string{"alice", "bob"}, nil, models.PermissionRead).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("alice\x00")
+				mockListCache.EXPECT().DeletePrefix("bob\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+				mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", id).Return([]models.Grant{{Login: "alice"}, {Login: "bob"}}, nil)
+			},
+			id:     docID,
+			logins: []string{"alice", " bob ", "alice", "", "owner"},
+			want:   []models.Grant{{Login: "alice"}, {Login: "bob"}},
+		},
+		{
+			name: "success_add_grants_with_permission",
+			mock: func() {
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, []string{"alice"}, nil, models.PermissionManage).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("alice\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+				mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", id).Return([]models.Grant{{Login: "alice", Permission: models.PermissionManage}}, nil)
+			},
+			id:         docID,
+			logins:     []string{"alice"},
+			permission: models.PermissionManage,
+			want:       []models.Grant{{Login: "alice", Permission: models.PermissionManage}},
+		},
+		{
+			name: "success_add_group_grants",
+			mock: func() {
+				// участников группы сбросит уведомление хранилища
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, nil, []string{"team"}, models.PermissionWrite).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockPurger.EXPECT().PurgeDocument(docID)
+				mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", id).Return([]models.Grant{{Group: "team", Permission: models.PermissionWrite}}, nil)
+			},
+			id:         docID,
+			groups:     []string{"team", " team", ""},
+			permission: models.PermissionWrite,
+			want:       []models.Grant{{Group: "team", Permission: models.PermissionWrite}},
+		},
+		{
+			name: "error_unknown_group",
+			mock: func() {
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, nil, []string{"ghosts"}, models.PermissionRead).Return(pq.ErrGrantGroupNotFound)
+			},
+			id:      docID,
+			groups:  []string{"ghosts"},
+			wantErr: pq.ErrGrantGroupNotFound,
+		},
+		{
+			name:       "error_permission",
+			mock:       func() {},
+			id:         docID,
+			logins:     []string{"alice"},
+			permission: models.PermissionOwner,
+			wantErr:    ErrGrantPermission,
+		},
+		{
+			name:    "error_no_logins",
+			mock:    func() {},
+			id:      docID,
+			logins:  []string{"owner", ""},
+			wantErr: ErrGrantLogins,
+		},
+		{
+			name: "error_unknown_login",
+			mock: func() {
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, []string{"ghost"}, nil, models.PermissionRead).Return(pq.ErrGrantUserNotFound)
+			},
+			id:      docID,
+			logins:  []string{"ghost"},
+			wantErr: pq.ErrGrantUserNotFound,
+		},
+		{
+			name: "error_not_owner",
+			mock: func() {
+				mockStorage.EXPECT().AddGrants(gomock.Any(), "owner", id, []string{"alice"}, nil, models.PermissionRead).Return(pq.ErrDocumentNotFound)
+			},
+			id:      docID,
+			logins:  []string{"alice"},
+			wantErr: pq.ErrDocumentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.AddGrants(context.Background(), "owner", tt.id, tt.logins, tt.groups, tt.permission)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddGrants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.AddGrants(context.Background(), "owner", "1", []string{"alice"}, nil, ""); err == nil {
+		t.Errorf("AddGrants() must reject invalid document id")
+	}
+}
+
+func TestService_DeleteGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), mockCache, mockListCache, mockPurger, log)
+
+	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
+	id := uuid.MustParse(docID)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		logins  []string
+		groups  []string
+		want    []models.Grant
+		wantErr error
+	}{
+		{
+			name: "success_delete_grants",
+			mock: func() {
+				mockStorage.EXPECT().DeleteGrants(gomock.Any(), "owner", id, []string{"alice"}, nil).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("alice\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+				mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", id).Return([]models.Grant{}, nil)
+			},
+			logins: []string{"alice"},
+			want:   []models.Grant{},
+		},
+		{
+			name: "success_delete_group_grants",
+			mock: func() {
+				mockStorage.EXPECT().DeleteGrants(gomock.Any(), "owner", id, []string{"alice"}, []string{"team"}).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("alice\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+				mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", id).Return([]models.Grant{}, nil)
+			},
+			logins: []string{"alice"},
+			groups: []string{"team"},
+			want:   []models.Grant{},
+		},
+		{
+			name:    "error_no_logins",
+			mock:    func() {},
+			logins:  nil,
+			wantErr: ErrGrantLogins,
+		},
+		{
+			name: "error_delete_grants",
+			mock: func() {
+				mockStorage.EXPECT().DeleteGrants(gomock.Any(), "owner", id, []string{"alice"}, nil).Return(errStorage)
+			},
+			logins:  []string{"alice"},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.DeleteGrants(context.Background(), "owner", docID, tt.logins, tt.groups)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeleteGrants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_GetGrants(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), NewMockcache(ctrl), NewMocklistCache(ctrl), NewMockpurger(ctrl), log)
+
+	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
+
+	mockStorage.EXPECT().GetGrants(gomock.Any(), "owner", uuid.MustParse(docID)).Return(nil, pq.ErrDocumentNotFound)
+	if _, err := s.GetGrants(context.Background(), "owner", docID); !errors.Is(err, pq.ErrDocumentNotFound) {
+		t.Errorf("GetGrants() error = %v, wantErr %v", err, pq.ErrDocumentNotFound)
+	}
+	if _, err := s.GetGrants(context.Background(), "owner", "1"); err == nil {
+		t.Errorf("GetGrants() must reject invalid document id")
+	}
+}
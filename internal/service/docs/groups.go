@@ -0,0 +1,83 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrGroupName    = errors.New("group name must be 3-64 characters: letters, digits, '_' or '-'")
+	ErrGroupMembers = errors.New("logins required")
+)
+
+var groupNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// CreateGroup - создает группу и возвращает ее, создатель становится владельцем и участником
+func (s *Service) CreateGroup(ctx context.Context, login, name string) (models.Group, error) {
+	name = strings.TrimSpace(name)
+	if !groupNameRe.MatchString(name) {
+		return models.Group{}, ErrGroupName
+	}
+
+	if err := s.storage.CreateGroup(ctx, login, name); err != nil {
+		s.log.Error("CreateGroup", "failed to create group", err)
+		return models.Group{}, err
+	}
+	return s.GetGroup(ctx, login, name)
+}
+
+// GetGroups - группы, которыми пользователь владеет или в которых состоит
+func (s *Service) GetGroups(ctx context.Context, login string) ([]models.Group, error) {
+	groups, err := s.storage.GetGroups(ctx, login)
+	if err != nil {
+		s.log.Error("GetGroups", "failed to get groups", err)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetGroup - группа по имени
+func (s *Service) GetGroup(ctx context.Context, login, name string) (models.Group, error) {
+	group, err := s.storage.GetGroup(ctx, login, name)
+	if err != nil {
+		s.log.Error("GetGroup", "failed to get group", err)
+		return models.Group{}, err
+	}
+	return group, nil
+}
+
+// AddGroupMembers - добавляет участников в группу и возвращает ее
+// новые участники получают доступ ко всем документам группы, их списки сбрасываются
+func (s *Service) AddGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error) {
+	logins = normalizeNames(logins, login)
+	if len(logins) == 0 {
+		return models.Group{}, ErrGroupMembers
+	}
+
+	if err := s.storage.AddGroupMembers(ctx, login, name, logins); err != nil {
+		s.log.Error("AddGroupMembers", "failed to add members", err)
+		return models.Group{}, err
+	}
+	s.invalidateLists(logins...)
+
+	return s.GetGroup(ctx, login, name)
+}
+
+// DeleteGroupMembers - исключает участников из группы и возвращает ее, владелец остается в группе всегда
+func (s *Service) DeleteGroupMembers(ctx context.Context, login, name string, logins []string) (models.Group, error) {
+	logins = normalizeNames(logins, login)
+	if len(logins) == 0 {
+		return models.Group{}, ErrGroupMembers
+	}
+
+	if err := s.storage.DeleteGroupMembers(ctx, login, name, logins); err != nil {
+		s.log.Error("DeleteGroupMembers", "failed to delete members", err)
+		return models.Group{}, err
+	}
+	s.invalidateLists(logins...)
+
+	return s.GetGroup(ctx, login, name)
+}
@@ -0,0 +1,150 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_CreateGroup(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), NewMockcache(ctrl), NewMocklistCache(ctrl), NewMockpurger(ctrl), log)
+
+	tests := []struct {
+		name      string
+		mock      func()
+		groupName string
+		want      models.Group
+		wantErr   error
+	}{
+		{
+			name: "success_create_group",
+			mock: func() {
+				mockStorage.EXPECT().CreateGroup(gomock.Any(), "owner", "team").Return(nil)
+				mockStorage.EXPECT().GetGroup(gomock.Any(), "owner", "team").Return(models.Group{Name: "team", Owner: "owner", Members: []string{"owner"}}, nil)
+			},
+			groupName: " team ",
+			want:      models.Group{Name: "team", Owner: "owner", Members: []string{"owner"}},
+		},
+		{
+			name:      "error_group_name",
+			mock:      func() {},
+			groupName: "a b",
+			wantErr:   ErrGroupName,
+		},
+		{
+			name: "error_group_exists",
+			mock: func() {
+				mockStorage.EXPECT().CreateGroup(gomock.Any(), "owner", "team").Return(pq.ErrGroupExists)
+			},
+			groupName: "team",
+			wantErr:   pq.ErrGroupExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.CreateGroup(context.Background(), "owner", tt.groupName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CreateGroup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_AddGroupMembers(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), NewMockcache(ctrl), mockListCache, NewMockpurger(ctrl), log)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		logins  []string
+		want    models.Group
+		wantErr error
+	}{
+		{
+			name: "success_add_members",
+			mock: func() {
+				// повторы, пустые логины и сам владелец отбрасываются
+				mockStorage.EXPECT().AddGroupMembers(gomock.Any(), "owner", "team", []string{"alice"}).Return(nil)
+				mockListCache.EXPECT().DeletePrefix("alice\x00")
+				mockStorage.EXPECT().GetGroup(gomock.Any(), "owner", "team").Return(models.Group{Name: "team", Members: []string{"alice", "owner"}}, nil)
+			},
+			logins: []string{"alice", " alice", "", "owner"},
+			want:   models.Group{Name: "team", Members: []string{"alice", "owner"}},
+		},
+		{
+			name:    "error_no_logins",
+			mock:    func() {},
+			logins:  []string{"owner"},
+			wantErr: ErrGroupMembers,
+		},
+		{
+			name: "error_not_group_owner",
+			mock: func() {
+				mockStorage.EXPECT().AddGroupMembers(gomock.Any(), "owner", "team", []string{"alice"}).Return(pq.ErrGroupNotFound)
+			},
+			logins:  []string{"alice"},
+			wantErr: pq.ErrGroupNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := s.AddGroupMembers(context.Background(), "owner", "team", tt.logins)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddGroupMembers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddGroupMembers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_DeleteGroupMembers(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	s := NewService(mockStorage, NewMocks3(ctrl), NewMockcache(ctrl), mockListCache, NewMockpurger(ctrl), log)
+
+	mockStorage.EXPECT().DeleteGroupMembers(gomock.Any(), "owner", "team", []string{"alice"}).Return(nil)
+	mockListCache.EXPECT().DeletePrefix("alice\x00")
+	mockStorage.EXPECT().GetGroup(gomock.Any(), "owner", "team").Return(models.Group{Name: "team", Members: []string{"owner"}}, nil)
+
+	got, err := s.DeleteGroupMembers(context.Background(), "owner", "team", []string{"alice"})
+	if err != nil {
+		t.Fatalf("DeleteGroupMembers() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Members, []string{"owner"}) {
+		t.Errorf("DeleteGroupMembers() members = %v, want [owner]", got.Members)
+	}
+	if _, err := s.DeleteGroupMembers(context.Background(), "owner", "team", nil); !errors.Is(err, ErrGroupMembers) {
+		t.Errorf("DeleteGroupMembers() error = %v, wantErr %v", err, ErrGroupMembers)
+	}
+}
@@ -2,10 +2,14 @@ package docs
 
 import (
 	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"path/filepath"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,29 +18,66 @@ import (
 type storage interface {
 	GetUserID(ctx context.Context, login string) (int, error)
 	SaveDocument(ctx context.Context, doc *models.Document, grants []string) error
-	GetDocuments(ctx context.Context, login, filterKey, filterValue string, limit int) ([]models.DocsData, error)
+	GetDocuments(ctx context.Context, login, scope, filterKey, filterValue string, limit int) ([]models.DocsData, error)
 	DeleteDocument(ctx context.Context, login string, id uuid.UUID) error
+	UpdateDocument(ctx context.Context, login string, doc *models.Document, prevUpdatedAt time.Time) error
 	GetDocumentByID(ctx context.Context, docID uuid.UUID, login string) (*models.Document, error)
+	HasAccess(ctx context.Context, docID uuid.UUID, login string) (bool, error)
+	GetDocsVersion(ctx context.Context, login string) (int64, error)
+	GetCleanupPaths(ctx context.Context, limit int) ([]string, error)
+	DeleteCleanupPath(ctx context.Context, path string) error
+	GetGrants(ctx context.Context, login string, docID uuid.UUID) ([]models.Grant, error)
+	AddGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string, permission string) error
+	DeleteGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string) error
+	CreateGroup(ctx context.Context, login, name string) error
+	GetGroups(ctx context.Context, login string) ([]models.Group, error)
+	GetGroup(ctx context.Context, login, name string) (models.Group, error)
+	AddGroupMembers(ctx context.Context, login, name string, logins []string) error
+	DeleteGroupMembers(ctx context.Context, login, name string, logins []string) error
 }
 
 type s3 interface {
-	SaveFile(ctx context.Context,key string, data []byte, contentType string) (string, error)
+	SaveFile(ctx context.Context, key string, data []byte, contentType string) (string, error)
 	DeleteFile(key string) error
-	GetFile(key string) ([]byte, error)
+	GetFile(ctx context.Context, key string) ([]byte, error)
+}
+
+type cache interface {
+	Get(key string) (models.DocumentContent, bool)
+	Set(key string, value models.DocumentContent)
+	Delete(key string)
+	Purge()
+}
+
+type listCache interface {
+	Get(key string) ([]models.DocsData, bool)
+	Set(key string, value []models.DocsData)
+	DeletePrefix(prefix string)
+	Purge()
+}
+
+type purger interface {
+	PurgeDocument(docID string)
 }
 
 type Service struct {
-	storage storage
-	s3      s3
-	log     *slog.Logger
+	storage   storage
+	s3        s3
+	cache     cache
+	listCache listCache
+	purger    purger
+	log       *slog.Logger
 }
 
 // NewService - создает новый сервис
-func NewService(storage storage, s3 s3, log *slog.Logger) *Service {
+func NewService(storage storage, s3 s3, cache cache, listCache listCache, purger purger, log *slog.Logger) *Service {
 	return &Service{
-		storage: storage,
-		s3:      s3,
-		log:     log,
+		storage:   storage,
+		s3:        s3,
+		cache:     cache,
+		listCache: listCache,
+		purger:    purger,
+		log:       log,
 	}
 }
 
@@ -46,7 +87,7 @@ func (s *Service) SaveDocument(ctx context.Context, login string, meta models.Me
 	ext := filepath.Ext(meta.Name)
 	key := fmt.Sprintf("%s%s", uuid.New().String(), ext)
 
-	_, err := s.s3.SaveFile(ctx,key, file, meta.Mime)
+	_, err := s.s3.SaveFile(ctx, key, file, meta.Mime)
 	if err != nil {
 		s.log.Error("SaveDocument", "failed to save file", err)
 		return err
@@ -60,10 +101,11 @@ func (s *Service) SaveDocument(ctx context.Context, login string, meta models.Me
 	}
 
 	// создаем запрос
-	doc := s.createDocument(meta, key, jsonData, userID)
+	doc := s.createDocument(meta, key, jsonData, file, userID)
 
 	// сохрани в БД
-	err = s.storage.SaveDocument(ctx, doc, meta.Grants)
+	grants := normalizeNames(meta.Grants, login)
+	err = s.storage.SaveDocument(ctx, doc, grants)
 	if err != nil {
 		s.log.Error("SaveDocument", "failed to save document", err)
 		errs3 := s.s3.DeleteFile(key)
@@ -72,12 +114,14 @@ func (s *Service) SaveDocument(ctx context.Context, login string, meta models.Me
 		}
 		return err
 	}
+	s.invalidateLists(append([]string{login}, grants...)...)
+	s.purger.PurgeDocument(doc.ID)
 
 	return nil
 }
 
 // Создаем единый документ
-func (s *Service) createDocument(meta models.Meta, path string, jsonData []byte, userID int) *models.Document {
+func (s *Service) createDocument(meta models.Meta, path string, jsonData, file []byte, userID int) *models.Document {
 	var doc models.Document
 
 	doc.Name = meta.Name
@@ -87,57 +131,106 @@ func (s *Service) createDocument(meta models.Meta, path string, jsonData []byte,
 	doc.Public = meta.Public
 	doc.JsonDate = jsonData
 	doc.StoragePath = path
+	doc.ContentHash = contentHash(file, jsonData)
 
 	return &doc
 }
 
-// GetDocuments - возвращает список документов
-func (s *Service) GetDocuments(ctx context.Context, login, filterKey, filterValue string, limit int) ([]models.DocsData, error) {
-	allowedKeys := map[string]bool{
-		"name": true,
-		"mime": true,
+// contentHash - хеш содержимого документа, используется как ETag
+func contentHash(file, jsonData []byte) string {
+	h := sha256.New()
+	h.Write(file)
+	h.Write(jsonData)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetDocuments - возвращает список документов в области scope и признак того, что он взят из кеша
+// без области - свои документы; version - счетчик изменений из ListVersion, входит в ключ кеша:
+// список, собранный до изменения, не попадет под новый ETag, даже если сброс по уведомлению еще не дошел
+// без версии (список всех публичных или счетчик не прочитан) кеш не используется
+func (s *Service) GetDocuments(ctx context.Context, login, scope, filterKey, filterValue string, limit int, version int64) ([]models.DocsData, bool, error) {
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return nil, false, err
 	}
+	key := normalizeFilterKey(filterKey)
 
-	key := ""
-	if allowedKeys[filterKey] {
-		key = filterKey
+	cacheKey := fmt.Sprintf("%s\x00%d", listKey(login, scope, key, filterValue, limit), version)
+	if version != NoListVersion {
+		if docs, ok := s.listCache.Get(cacheKey); ok {
+			return docs, true, nil
+		}
 	}
 
-	docs, err := s.storage.GetDocuments(ctx, login, key, filterValue, limit)
+	docs, err := s.storage.GetDocuments(ctx, login, scope, key, filterValue, limit)
 	if err != nil {
 		s.log.Error("GetDocuments", "failed to get documents", err)
-		return nil, err
+		return nil, false, err
+	}
+	if version != NoListVersion {
+		s.listCache.Set(cacheKey, docs)
 	}
 
-	return docs, nil
+	return docs, false, nil
 
 }
 
 // GetDocument - возвращает документ
-func (s *Service) GetDocument(ctx context.Context, login, docID string) ([]byte, []byte, string, error) {
+// notModified проверяет условный запрос по метаданным из базы: если копия клиента свежая, файл не загружается и File остается nil
+func (s *Service) GetDocument(ctx context.Context, login, docID string, notModified func(models.DocumentContent) bool) (models.DocumentContent, error) {
 	// превращаем в UUID
 	id, err := uuid.Parse(docID)
 	if err != nil {
 		s.log.Error("GetDocument", "failed to parse document id", err)
-		return nil, nil, "", err
+		return models.DocumentContent{}, err
+	}
+
+	// в кеше лежит только содержимое, права проверяем на каждый запрос
+	// публичный документ доступен всем, в том числе анонимам с пустым логином
+	if content, ok := s.cache.Get(id.String()); ok {
+		if content.Public {
+			return content, nil
+		}
+		access, err := s.storage.HasAccess(ctx, id, login)
+		if err != nil {
+			s.log.Error("GetDocument", "failed to check access", err)
+			return models.DocumentContent{}, err
+		}
+		if !access {
+			return models.DocumentContent{}, pq.ErrDocumentNotFound
+		}
+		return content, nil
 	}
 
 	doc, err := s.storage.GetDocumentByID(ctx, id, login)
 	if err != nil {
 		s.log.Error("GetDocument", "failed to get document", err)
-		return nil, nil, "", err
+		return models.DocumentContent{}, err
+	}
+	if doc == nil {
+		return models.DocumentContent{}, pq.ErrDocumentNotFound
+	}
+
+	content := models.DocumentContent{
+		JSON:    doc.JsonDate,
+		Mime:    doc.Mime,
+		Hash:    doc.ContentHash,
+		ModTime: doc.UpdatedAt,
+		Public:  doc.Public,
+	}
+	// копия у клиента совпадает с content_hash из базы - файл из MinIO не нужен
+	if notModified != nil && notModified(content) {
+		return content, nil
 	}
-	s.log.Info(doc.StoragePath)
-	file, err := s.s3.GetFile(doc.StoragePath)
+
+	content.File, err = s.s3.GetFile(ctx, doc.StoragePath)
 	if err != nil {
 		s.log.Error("GetDocument", "failed to get file", err)
-		return nil, nil, "", err
+		return models.DocumentContent{}, err
 	}
+	s.cache.Set(id.String(), content)
 
-	json := doc.JsonDate
-	mime := doc.Mime
-
-	return file, json, mime, nil
+	return content, nil
 }
 
 // DeleteDocument - удаляет документ
@@ -154,6 +247,98 @@ func (s *Service) DeleteDocument(ctx context.Context, login, docID string) error
 		s.log.Error("DeleteDocument", "failed to delete document", err)
 		return err
 	}
+	s.cache.Delete(id.String())
+	s.invalidateLists(login)
+	s.purger.PurgeDocument(id.String())
 
 	return nil
 }
+
+// HandleChange - сбрасывает кеш по уведомлению об изменении с любого экземпляра сервера
+func (s *Service) HandleChange(ev models.ChangeEvent) {
+	if ev.Resync {
+		s.cache.Purge()
+		s.listCache.Purge()
+		return
+	}
+	if ev.DocID != "" {
+		s.cache.Delete(ev.DocID)
+	}
+	if ev.ListsResync {
+		s.listCache.Purge()
+		return
+	}
+	s.invalidateLists(ev.Logins...)
+}
+
+// NoListVersion - у списка нет версии: его нельзя кешировать и отдавать с ETag
+const NoListVersion int64 = -1
+
+// ListVersion - версия списка документов: счетчик изменений, видимых пользователю
+// читается до выборки, чтобы изменение во время запроса дало новую версию
+// у списка всех публичных документов версии нет - NoListVersion
+func (s *Service) ListVersion(ctx context.Context, login, scope string) (int64, error) {
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return NoListVersion, err
+	}
+	if scope == models.ListScopePublic {
+		return NoListVersion, nil
+	}
+
+	version, err := s.storage.GetDocsVersion(ctx, login)
+	if err != nil {
+		s.log.Error("ListVersion", "failed to get docs version", err)
+		return NoListVersion, err
+	}
+	return version, nil
+}
+
+// ListETag - ETag списка документов: версия и параметры выборки, без версии - пустая строка
+func (s *Service) ListETag(login, scope, filterKey, filterValue string, limit int, version int64) string {
+	if version == NoListVersion {
+		return ""
+	}
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(listKey(login, scope, normalizeFilterKey(filterKey), filterValue, limit)))
+	return fmt.Sprintf(`"%d-%s"`, version, hex.EncodeToString(sum[:8]))
+}
+
+// normalizeScope - область выборки списка, по умолчанию свои документы
+func normalizeScope(scope string) (string, error) {
+	if scope == "" {
+		return models.ListScopeOwned, nil
+	}
+	if !models.ValidListScope(scope) {
+		return "", pq.ErrListScope
+	}
+	return scope, nil
+}
+
+func normalizeFilterKey(filterKey string) string {
+	allowedKeys := map[string]bool{
+		"name": true,
+		"mime": true,
+	}
+
+	if allowedKeys[filterKey] {
+		return filterKey
+	}
+	return ""
+}
+
+// listKey - параметры выборки списка документов, начинается с логина: по нему сбрасываются списки пользователя
+func listKey(login, scope, filterKey, filterValue string, limit int) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%d", login, scope, filterKey, filterValue, limit)
+}
+
+// invalidateLists - сбрасывает закешированные списки документов пользователей
+func (s *Service) invalidateLists(logins ...string) {
+	for _, login := range logins {
+		s.listCache.DeletePrefix(login + "\x00")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	models "caching_web_server/internal/models"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,6 +37,62 @@ func (m *Mockstorage) EXPECT() *MockstorageMockRecorder {
 	return m.recorder
 }
 
+// AddGrants mocks base method.
+func (m *Mockstorage) AddGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string, permission string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGrants", ctx, login, docID, logins, groups, permission)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGrants indicates an expected call of AddGrants.
+func (mr *MockstorageMockRecorder) AddGrants(ctx, login, docID, logins, groups, permission interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGrants", reflect.TypeOf((*Mockstorage)(nil).AddGrants), ctx, login, docID, logins, groups, permission)
+}
+
+// AddGroupMembers mocks base method.
+func (m *Mockstorage) AddGroupMembers(ctx context.Context, login, name string, logins []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMembers", ctx, login, name, logins)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroupMembers indicates an expected call of AddGroupMembers.
+func (mr *MockstorageMockRecorder) AddGroupMembers(ctx, login, name, logins interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMembers", reflect.TypeOf((*Mockstorage)(nil).AddGroupMembers), ctx, login, name, logins)
+}
+
+// CreateGroup mocks base method.
+func (m *Mockstorage) CreateGroup(ctx context.Context, login, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", ctx, login, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockstorageMockRecorder) CreateGroup(ctx, login, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*Mockstorage)(nil).CreateGroup), ctx, login, name)
+}
+
+// DeleteCleanupPath mocks base method.
+func (m *Mockstorage) DeleteCleanupPath(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCleanupPath", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCleanupPath indicates an expected call of DeleteCleanupPath.
+func (mr *MockstorageMockRecorder) DeleteCleanupPath(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCleanupPath", reflect.TypeOf((*Mockstorage)(nil).DeleteCleanupPath), ctx, path)
+}
+
 // DeleteDocument mocks base method.
 func (m *Mockstorage) DeleteDocument(ctx context.Context, login string, id uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -50,6 +107,64 @@ func (mr *MockstorageMockRecorder) DeleteDocument(ctx, login, id interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDocument", reflect.TypeOf((*Mockstorage)(nil).DeleteDocument), ctx, login, id)
 }
 
+// DeleteGrants mocks base method.
+func (m *Mockstorage) DeleteGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrants", ctx, login, docID, logins, groups)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGrants indicates an expected call of DeleteGrants.
+func (mr *MockstorageMockRecorder) DeleteGrants(ctx, login, docID, logins, groups interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrants", reflect.TypeOf((*Mockstorage)(nil).DeleteGrants), ctx, login, docID, logins, groups)
+}
+
+// DeleteGroupMembers mocks base method.
+func (m *Mockstorage) DeleteGroupMembers(ctx context.Context, login, name string, logins []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupMembers", ctx, login, name, logins)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroupMembers indicates an expected call of DeleteGroupMembers.
+func (mr *MockstorageMockRecorder) DeleteGroupMembers(ctx, login, name, logins interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupMembers", reflect.TypeOf((*Mockstorage)(nil).DeleteGroupMembers), ctx, login, name, logins)
+}
+
+// GetCleanupPaths mocks base method.
+func (m *Mockstorage) GetCleanupPaths(ctx context.Context, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCleanupPaths", ctx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCleanupPaths indicates an expected call of GetCleanupPaths.
+func (mr *MockstorageMockRecorder) GetCleanupPaths(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCleanupPaths", reflect.TypeOf((*Mockstorage)(nil).GetCleanupPaths), ctx, limit)
+}
+
+// GetDocsVersion mocks base method.
+func (m *Mockstorage) GetDocsVersion(ctx context.Context, login string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocsVersion", ctx, login)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocsVersion indicates an expected call of GetDocsVersion.
+func (mr *MockstorageMockRecorder) GetDocsVersion(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocsVersion", reflect.TypeOf((*Mockstorage)(nil).GetDocsVersion), ctx, login)
+}
+
 // GetDocumentByID mocks base method.
 func (m *Mockstorage) GetDocumentByID(ctx context.Context, docID uuid.UUID, login string) (*models.Document, error) {
 	m.ctrl.T.Helper()
@@ -66,18 +181,63 @@ func (mr *MockstorageMockRecorder) GetDocumentByID(ctx, docID, login interface{}
 }
 
 // GetDocuments mocks base method.
-func (m *Mockstorage) GetDocuments(ctx context.Context, login, filterKey, filterValue string, limit int) ([]models.DocsData, error) {
+func (m *Mockstorage) GetDocuments(ctx context.Context, login, scope, filterKey, filterValue string, limit int) ([]models.DocsData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDocuments", ctx, login, filterKey, filterValue, limit)
+	ret := m.ctrl.Call(m, "GetDocuments", ctx, login, scope, filterKey, filterValue, limit)
 	ret0, _ := ret[0].([]models.DocsData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDocuments indicates an expected call of GetDocuments.
-func (mr *MockstorageMockRecorder) GetDocuments(ctx, login, filterKey, filterValue, limit interface{}) *gomock.Call {
+func (mr *MockstorageMockRecorder) GetDocuments(ctx, login, scope, filterKey, filterValue, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*Mockstorage)(nil).GetDocuments), ctx, login, scope, filterKey, filterValue, limit)
+}
+
+// GetGrants mocks base method.
+func (m *Mockstorage) GetGrants(ctx context.Context, login string, docID uuid.UUID) ([]models.Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrants", ctx, login, docID)
+	ret0, _ := ret[0].([]models.Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrants indicates an expected call of GetGrants.
+func (mr *MockstorageMockRecorder) GetGrants(ctx, login, docID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocuments", reflect.TypeOf((*Mockstorage)(nil).GetDocuments), ctx, login, filterKey, filterValue, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrants", reflect.TypeOf((*Mockstorage)(nil).GetGrants), ctx, login, docID)
+}
+
+// GetGroup mocks base method.
+func (m *Mockstorage) GetGroup(ctx context.Context, login, name string) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", ctx, login, name)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockstorageMockRecorder) GetGroup(ctx, login, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*Mockstorage)(nil).GetGroup), ctx, login, name)
+}
+
+// GetGroups mocks base method.
+func (m *Mockstorage) GetGroups(ctx context.Context, login string) ([]models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", ctx, login)
+	ret0, _ := ret[0].([]models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockstorageMockRecorder) GetGroups(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*Mockstorage)(nil).GetGroups), ctx, login)
 }
 
 // GetUserID mocks base method.
@@ -95,6 +255,21 @@ func (mr *MockstorageMockRecorder) GetUserID(ctx, login interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*Mockstorage)(nil).GetUserID), ctx, login)
 }
 
+// HasAccess mocks base method.
+func (m *Mockstorage) HasAccess(ctx context.Context, docID uuid.UUID, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasAccess", ctx, docID, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasAccess indicates an expected call of HasAccess.
+func (mr *MockstorageMockRecorder) HasAccess(ctx, docID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasAccess", reflect.TypeOf((*Mockstorage)(nil).HasAccess), ctx, docID, login)
+}
+
 // SaveDocument mocks base method.
 func (m *Mockstorage) SaveDocument(ctx context.Context, doc *models.Document, grants []string) error {
 	m.ctrl.T.Helper()
@@ -109,6 +284,20 @@ func (mr *MockstorageMockRecorder) SaveDocument(ctx, doc, grants interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDocument", reflect.TypeOf((*Mockstorage)(nil).SaveDocument), ctx, doc, grants)
 }
 
+// UpdateDocument mocks base method.
+func (m *Mockstorage) UpdateDocument(ctx context.Context, login string, doc *models.Document, prevUpdatedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDocument", ctx, login, doc, prevUpdatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDocument indicates an expected call of UpdateDocument.
+func (mr *MockstorageMockRecorder) UpdateDocument(ctx, login, doc, prevUpdatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDocument", reflect.TypeOf((*Mockstorage)(nil).UpdateDocument), ctx, login, doc, prevUpdatedAt)
+}
+
 // Mocks3 is a mock of s3 interface.
 type Mocks3 struct {
 	ctrl     *gomock.Controller
@@ -147,18 +336,18 @@ func (mr *Mocks3MockRecorder) DeleteFile(key interface{}) *gomock.Call {
 }
 
 // GetFile mocks base method.
-func (m *Mocks3) GetFile(key string) ([]byte, error) {
+func (m *Mocks3) GetFile(ctx context.Context, key string) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFile", key)
+	ret := m.ctrl.Call(m, "GetFile", ctx, key)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFile indicates an expected call of GetFile.
-func (mr *Mocks3MockRecorder) GetFile(key interface{}) *gomock.Call {
+func (mr *Mocks3MockRecorder) GetFile(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*Mocks3)(nil).GetFile), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*Mocks3)(nil).GetFile), ctx, key)
 }
 
 // SaveFile mocks base method.
@@ -175,3 +364,186 @@ func (mr *Mocks3MockRecorder) SaveFile(ctx, key, data, contentType interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFile", reflect.TypeOf((*Mocks3)(nil).SaveFile), ctx, key, data, contentType)
 }
+
+// Mockcache is a mock of cache interface.
+type Mockcache struct {
+	ctrl     *gomock.Controller
+	recorder *MockcacheMockRecorder
+}
+
+// MockcacheMockRecorder is the mock recorder for Mockcache.
+type MockcacheMockRecorder struct {
+	mock *Mockcache
+}
+
+// NewMockcache creates a new mock instance.
+func NewMockcache(ctrl *gomock.Controller) *Mockcache {
+	mock := &Mockcache{ctrl: ctrl}
+	mock.recorder = &MockcacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockcache) EXPECT() *MockcacheMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *Mockcache) Delete(key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", key)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockcacheMockRecorder) Delete(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*Mockcache)(nil).Delete), key)
+}
+
+// Get mocks base method.
+func (m *Mockcache) Get(key string) (models.DocumentContent, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].(models.DocumentContent)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockcacheMockRecorder) Get(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockcache)(nil).Get), key)
+}
+
+// Purge mocks base method.
+func (m *Mockcache) Purge() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Purge")
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockcacheMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*Mockcache)(nil).Purge))
+}
+
+// Set mocks base method.
+func (m *Mockcache) Set(key string, value models.DocumentContent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", key, value)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockcacheMockRecorder) Set(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*Mockcache)(nil).Set), key, value)
+}
+
+// MocklistCache is a mock of listCache interface.
+type MocklistCache struct {
+	ctrl     *gomock.Controller
+	recorder *MocklistCacheMockRecorder
+}
+
+// MocklistCacheMockRecorder is the mock recorder for MocklistCache.
+type MocklistCacheMockRecorder struct {
+	mock *MocklistCache
+}
+
+// NewMocklistCache creates a new mock instance.
+func NewMocklistCache(ctrl *gomock.Controller) *MocklistCache {
+	mock := &MocklistCache{ctrl: ctrl}
+	mock.recorder = &MocklistCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklistCache) EXPECT() *MocklistCacheMockRecorder {
+	return m.recorder
+}
+
+// DeletePrefix mocks base method.
+func (m *MocklistCache) DeletePrefix(prefix string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeletePrefix", prefix)
+}
+
+// DeletePrefix indicates an expected call of DeletePrefix.
+func (mr *MocklistCacheMockRecorder) DeletePrefix(prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MocklistCache)(nil).DeletePrefix), prefix)
+}
+
+// Get mocks base method.
+func (m *MocklistCache) Get(key string) ([]models.DocsData, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].([]models.DocsData)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MocklistCacheMockRecorder) Get(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MocklistCache)(nil).Get), key)
+}
+
+// Purge mocks base method.
+func (m *MocklistCache) Purge() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Purge")
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MocklistCacheMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MocklistCache)(nil).Purge))
+}
+
+// Set mocks base method.
+func (m *MocklistCache) Set(key string, value []models.DocsData) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", key, value)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MocklistCacheMockRecorder) Set(key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MocklistCache)(nil).Set), key, value)
+}
+
+// Mockpurger is a mock of purger interface.
+type Mockpurger struct {
+	ctrl     *gomock.Controller
+	recorder *MockpurgerMockRecorder
+}
+
+// MockpurgerMockRecorder is the mock recorder for Mockpurger.
+type MockpurgerMockRecorder struct {
+	mock *Mockpurger
+}
+
+// NewMockpurger creates a new mock instance.
+func NewMockpurger(ctrl *gomock.Controller) *Mockpurger {
+	mock := &Mockpurger{ctrl: ctrl}
+	mock.recorder = &MockpurgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockpurger) EXPECT() *MockpurgerMockRecorder {
+	return m.recorder
+}
+
+// PurgeDocument mocks base method.
+func (m *Mockpurger) PurgeDocument(docID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PurgeDocument", docID)
+}
+
+// PurgeDocument indicates an expected call of PurgeDocument.
+func (mr *MockpurgerMockRecorder) PurgeDocument(docID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDocument", reflect.TypeOf((*Mockpurger)(nil).PurgeDocument), docID)
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -23,11 +24,17 @@ func TestNewService(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 	mockS3 := NewMocks3(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
 
 	type args struct {
-		storage storage
-		s3      s3
-		log     *slog.Logger
+		storage   storage
+		s3        s3
+		cache     cache
+		listCache listCache
+		purger    purger
+		log       *slog.Logger
 	}
 	tests := []struct {
 		name string
@@ -37,20 +44,26 @@ func TestNewService(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				cache:     mockCache,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			want: &Service{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				cache:     mockCache,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewService(tt.args.storage, tt.args.s3, tt.args.log); !reflect.DeepEqual(got, tt.want) {
+			if got := NewService(tt.args.storage, tt.args.s3, tt.args.cache, tt.args.listCache, tt.args.purger, tt.args.log); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewService() = %v, want %v", got, tt.want)
 			}
 		})
@@ -64,11 +77,15 @@ func TestService_SaveDocument(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 	mockS3 := NewMocks3(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
 
 	type fields struct {
-		storage storage
-		s3      s3
-		log     *slog.Logger
+		storage   storage
+		s3        s3
+		listCache listCache
+		purger    purger
+		log       *slog.Logger
 	}
 	type args struct {
 		ctx      context.Context
@@ -90,16 +107,21 @@ func TestService_SaveDocument(t *testing.T) {
 				mockS3.EXPECT().SaveFile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("url", nil)
 				mockStorage.EXPECT().GetUserID(gomock.Any(), gomock.Any()).Return(1, nil)
 				mockStorage.EXPECT().SaveDocument(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockListCache.EXPECT().DeletePrefix("test\x00")
+				mockListCache.EXPECT().DeletePrefix("login2\x00")
+				mockPurger.EXPECT().PurgeDocument(gomock.Any())
 			},
 			fields: fields{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			args: args{
 				ctx:      context.Background(),
 				login:    "test",
-				meta:     models.Meta{Name: "test"},
+				meta:     models.Meta{Name: "test", Grants: []string{"login2"}},
 				jsonData: []byte{},
 				file:     []byte{},
 			},
@@ -111,9 +133,11 @@ func TestService_SaveDocument(t *testing.T) {
 				mockS3.EXPECT().SaveFile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("s3 error"))
 			},
 			fields: fields{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			args: args{
 				ctx:      context.Background(),
@@ -131,9 +155,11 @@ func TestService_SaveDocument(t *testing.T) {
 				mockStorage.EXPECT().GetUserID(gomock.Any(), gomock.Any()).Return(0, errors.New("storage error"))
 			},
 			fields: fields{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			args: args{
 				ctx:      context.Background(),
@@ -153,9 +179,11 @@ func TestService_SaveDocument(t *testing.T) {
 				mockS3.EXPECT().DeleteFile(gomock.Any()).Return(nil)
 			},
 			fields: fields{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			args: args{
 				ctx:      context.Background(),
@@ -175,9 +203,11 @@ func TestService_SaveDocument(t *testing.T) {
 				mockS3.EXPECT().DeleteFile(gomock.Any()).Return(errors.New("s3 error"))
 			},
 			fields: fields{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			},
 			args: args{
 				ctx:      context.Background(),
@@ -194,9 +224,11 @@ func TestService_SaveDocument(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 			s := &Service{
-				storage: tt.fields.storage,
-				s3:      tt.fields.s3,
-				log:     tt.fields.log,
+				storage:   tt.fields.storage,
+				s3:        tt.fields.s3,
+				listCache: tt.fields.listCache,
+				purger:    tt.fields.purger,
+				log:       tt.fields.log,
 			}
 			if err := s.SaveDocument(tt.args.ctx, tt.args.login, tt.args.meta, tt.args.jsonData, tt.args.file); (err != nil) != tt.wantErr {
 				t.Errorf("SaveDocument() error = %v, wantErr %v", err, tt.wantErr)
@@ -212,38 +244,94 @@ func TestService_GetDocuments(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 	mockS3 := NewMocks3(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
 
 	type args struct {
 		ctx   context.Context
 		login string
+		scope string
 		key   string
 		value string
 		limit int
+		// version - версия списка из ListVersion
+		version int64
 	}
 	tests := []struct {
 		name    string
 		mock    func()
 		args    args
+		wantHit bool
 		wantErr error
 	}{
 		{
 			name: "success_get_documents",
 			mock: func() {
-				mockStorage.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.DocsData{}, nil)
+				// без области выбираются свои документы
+				mockListCache.EXPECT().Get("test\x00owned\x00\x00\x0010\x003").Return(nil, false)
+				mockStorage.EXPECT().GetDocuments(gomock.Any(), "test", models.ListScopeOwned, "", "", 10).Return([]models.DocsData{}, nil)
+				mockListCache.EXPECT().Set("test\x00owned\x00\x00\x0010\x003", []models.DocsData{})
 			},
 			args: args{
-				ctx:   context.Background(),
-				login: "test",
-				key:   "",
-				value: "",
-				limit: 10,
+				ctx:     context.Background(),
+				login:   "test",
+				key:     "",
+				value:   "",
+				limit:   10,
+				version: 3,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "success_get_documents_from_cache",
+			mock: func() {
+				mockListCache.EXPECT().Get("test\x00shared\x00name\x00doc\x0010\x003").Return([]models.DocsData{{Id: "id1"}}, true)
+			},
+			args: args{
+				ctx:     context.Background(),
+				login:   "test",
+				scope:   models.ListScopeShared,
+				key:     "name",
+				value:   "doc",
+				limit:   10,
+				version: 3,
+			},
+			wantHit: true,
+			wantErr: nil,
+		},
+		{
+			// список, закешированный до изменения, под новой версией не отдается
+			name: "success_get_documents_new_version",
+			mock: func() {
+				mockListCache.EXPECT().Get("test\x00owned\x00\x00\x0010\x004").Return(nil, false)
+				mockStorage.EXPECT().GetDocuments(gomock.Any(), "test", models.ListScopeOwned, "", "", 10).Return([]models.DocsData{{Id: "id2"}}, nil)
+				mockListCache.EXPECT().Set("test\x00owned\x00\x00\x0010\x004", []models.DocsData{{Id: "id2"}})
+			},
+			args: args{
+				ctx:     context.Background(),
+				login:   "test",
+				limit:   10,
+				version: 4,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "success_get_documents_without_version",
+			mock: func() {
+				mockStorage.EXPECT().GetDocuments(gomock.Any(), "test", models.ListScopeOwned, "", "", 10).Return([]models.DocsData{}, nil)
+			},
+			args: args{
+				ctx:     context.Background(),
+				login:   "test",
+				limit:   10,
+				version: NoListVersion,
 			},
 			wantErr: nil,
 		},
 		{
 			name: "error_get_documents",
 			mock: func() {
-				mockStorage.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errStorage)
+				mockListCache.EXPECT().Get(gomock.Any()).Return(nil, false)
+				mockStorage.EXPECT().GetDocuments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errStorage)
 			},
 			args: args{
 				ctx:   context.Background(),
@@ -254,23 +342,114 @@ func TestService_GetDocuments(t *testing.T) {
 			},
 			wantErr: errStorage,
 		},
+		{
+			name: "success_get_public_documents_without_cache",
+			mock: func() {
+				mockStorage.EXPECT().GetDocuments(gomock.Any(), "test", models.ListScopePublic, "", "", 10).Return([]models.DocsData{}, nil)
+			},
+			args: args{
+				ctx:     context.Background(),
+				login:   "test",
+				scope:   models.ListScopePublic,
+				limit:   10,
+				version: NoListVersion,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_scope",
+			mock: func() {},
+			args: args{
+				ctx:   context.Background(),
+				login: "test",
+				scope: "everything",
+				limit: 10,
+			},
+			wantErr: pq.ErrListScope,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 			s := &Service{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				listCache: mockListCache,
+				log:       log,
 			}
-			if _, err := s.GetDocuments(tt.args.ctx, tt.args.login, tt.args.key, tt.args.value, tt.args.limit); err != tt.wantErr {
+			_, hit, err := s.GetDocuments(tt.args.ctx, tt.args.login, tt.args.scope, tt.args.key, tt.args.value, tt.args.limit, tt.args.version)
+			if err != tt.wantErr {
 				t.Errorf("GetDocuments() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if hit != tt.wantHit {
+				t.Errorf("GetDocuments() hit = %v, want %v", hit, tt.wantHit)
+			}
 		})
 	}
 }
 
+func TestService_ListETag(t *testing.T) {
+	s := &Service{}
+
+	first := s.ListETag("test", "", "name", "doc", 10, 3)
+	if !strings.HasPrefix(first, `"3-`) {
+		t.Errorf("ListETag() = %v, want version prefix", first)
+	}
+	if first == s.ListETag("test", "", "name", "other", 10, 3) {
+		t.Errorf("ListETag() must depend on filter value")
+	}
+	// неизвестный ключ фильтра отбрасывается так же, как при выборке
+	if s.ListETag("test", "", "unknown", "", 10, 3) != s.ListETag("test", "", "", "", 10, 3) {
+		t.Errorf("ListETag() must ignore unknown filter key")
+	}
+	if first == s.ListETag("test", "", "name", "doc", 10, 4) {
+		t.Errorf("ListETag() must change with version")
+	}
+	// без области - свои документы
+	if first != s.ListETag("test", models.ListScopeOwned, "name", "doc", 10, 3) {
+		t.Errorf("ListETag() must default to owned scope")
+	}
+	if first == s.ListETag("test", models.ListScopeAll, "name", "doc", 10, 3) {
+		t.Errorf("ListETag() must depend on scope")
+	}
+	// без версии тега нет
+	if got := s.ListETag("test", "", "", "", 10, NoListVersion); got != "" {
+		t.Errorf("ListETag() = %v, want no etag without version", got)
+	}
+}
+
+func TestService_ListVersion(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	s := &Service{
+		storage: mockStorage,
+		log:     log,
+	}
+	ctx := context.Background()
+
+	mockStorage.EXPECT().GetDocsVersion(ctx, "test").Return(int64(3), nil)
+	if got, err := s.ListVersion(ctx, "test", ""); got != 3 || err != nil {
+		t.Errorf("ListVersion() = %v, %v, want 3", got, err)
+	}
+
+	// у списка всех публичных документов версии нет
+	if got, err := s.ListVersion(ctx, "test", models.ListScopePublic); got != NoListVersion || err != nil {
+		t.Errorf("ListVersion() = %v, %v, want no version for public scope", got, err)
+	}
+	if _, err := s.ListVersion(ctx, "test", "everything"); !errors.Is(err, pq.ErrListScope) {
+		t.Errorf("ListVersion() error = %v, wantErr %v", err, pq.ErrListScope)
+	}
+
+	mockStorage.EXPECT().GetDocsVersion(ctx, "test").Return(int64(0), errStorage)
+	if got, err := s.ListVersion(ctx, "test", ""); got != NoListVersion || !errors.Is(err, errStorage) {
+		t.Errorf("ListVersion() = %v, %v, wantErr %v", got, err, errStorage)
+	}
+}
+
 func TestService_GetDocument(t *testing.T) {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
 	ctrl := gomock.NewController(t)
@@ -278,17 +457,37 @@ func TestService_GetDocument(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 	mockS3 := NewMocks3(ctrl)
+	mockCache := NewMockcache(ctrl)
 
 	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
 
 	tests := []struct {
-		name string
-		mock func()
-		want error
+		name        string
+		mock        func()
+		notModified func(models.DocumentContent) bool
+		want        error
 	}{
+		{
+			// копия клиента свежая: файл из MinIO не загружается и в кеш не попадает
+			name: "success_not_modified",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, false)
+				mockStorage.EXPECT().
+					GetDocumentByID(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&models.Document{
+						Name:        "test.pdf",
+						Mime:        "application/pdf",
+						StoragePath: "url",
+						ContentHash: "abc",
+					}, nil)
+			},
+			notModified: func(content models.DocumentContent) bool { return content.Hash == "abc" },
+			want:        nil,
+		},
 		{
 			name: "success_get_document",
 			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, false)
 				mockStorage.EXPECT().
 					GetDocumentByID(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(&models.Document{
@@ -297,14 +496,58 @@ func TestService_GetDocument(t *testing.T) {
 						StoragePath: "url",
 					}, nil)
 				mockS3.EXPECT().
-					GetFile(gomock.Any()).
+					GetFile(gomock.Any(), gomock.Any()).
 					Return([]byte("file content"), nil)
+				mockCache.EXPECT().Set(docID, gomock.Any())
+			},
+			notModified: func(models.DocumentContent) bool { return false },
+			want:        nil,
+		},
+		{
+			name: "success_get_document_from_cache",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{File: []byte("file content")}, true)
+				mockStorage.EXPECT().HasAccess(gomock.Any(), gomock.Any(), "test").Return(true, nil)
 			},
 			want: nil,
 		},
+		{
+			name: "success_get_public_document_from_cache",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{File: []byte("file content"), Public: true}, true)
+			},
+			want: nil,
+		},
+		{
+			name: "error_cache_no_access",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{File: []byte("file content")}, true)
+				mockStorage.EXPECT().HasAccess(gomock.Any(), gomock.Any(), "test").Return(false, nil)
+			},
+			want: pq.ErrDocumentNotFound,
+		},
+		{
+			name: "error_cache_has_access",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, true)
+				mockStorage.EXPECT().HasAccess(gomock.Any(), gomock.Any(), "test").Return(false, errStorage)
+			},
+			want: errStorage,
+		},
+		{
+			name: "error_document_not_found",
+			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, false)
+				mockStorage.EXPECT().
+					GetDocumentByID(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil)
+			},
+			want: pq.ErrDocumentNotFound,
+		},
 		{
 			name: "error_get_document",
 			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, false)
 				mockStorage.EXPECT().
 					GetDocumentByID(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, errStorage)
@@ -314,6 +557,7 @@ func TestService_GetDocument(t *testing.T) {
 		{
 			name: "error_get_file",
 			mock: func() {
+				mockCache.EXPECT().Get(docID).Return(models.DocumentContent{}, false)
 				mockStorage.EXPECT().
 					GetDocumentByID(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(&models.Document{
@@ -322,7 +566,7 @@ func TestService_GetDocument(t *testing.T) {
 						StoragePath: "url",
 					}, nil)
 				mockS3.EXPECT().
-					GetFile(gomock.Any()).
+					GetFile(gomock.Any(), gomock.Any()).
 					Return(nil, errStorage)
 			},
 			want: errStorage,
@@ -335,9 +579,10 @@ func TestService_GetDocument(t *testing.T) {
 			s := &Service{
 				storage: mockStorage,
 				s3:      mockS3,
+				cache:   mockCache,
 				log:     log,
 			}
-			_, _, _, err := s.GetDocument(context.Background(), "test", docID)
+			_, err := s.GetDocument(context.Background(), "test", docID, tt.notModified)
 			if !errors.Is(err, tt.want) {
 				t.Errorf("GetDocument() error = %v, wantErr %v", err, tt.want)
 			}
@@ -351,6 +596,9 @@ func TestService_DeleteDocument(t *testing.T) {
 
 	mockStorage := NewMockstorage(ctrl)
 	mockS3 := NewMocks3(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
 
 	_, err := uuid.Parse("1")
 
@@ -369,6 +617,9 @@ func TestService_DeleteDocument(t *testing.T) {
 			name: "success_delete_document",
 			mock: func() {
 				mockStorage.EXPECT().DeleteDocument(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete("4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d")
+				mockListCache.EXPECT().DeletePrefix("test\x00")
+				mockPurger.EXPECT().PurgeDocument("4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d")
 			},
 			args: args{
 				ctx:   context.Background(),
@@ -405,9 +656,12 @@ func TestService_DeleteDocument(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 			s := &Service{
-				storage: mockStorage,
-				s3:      mockS3,
-				log:     log,
+				storage:   mockStorage,
+				s3:        mockS3,
+				cache:     mockCache,
+				listCache: mockListCache,
+				purger:    mockPurger,
+				log:       log,
 			}
 			if err := s.DeleteDocument(tt.args.ctx, tt.args.login, tt.args.id); !errors.Is(err, tt.wantErr) {
 				t.Errorf("DeleteDocument() error = %v, wantErr %v", err, tt.wantErr)
@@ -415,3 +669,82 @@ func TestService_DeleteDocument(t *testing.T) {
 		})
 	}
 }
+
+func TestService_HandleChange(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+
+	tests := []struct {
+		name string
+		ev   models.ChangeEvent
+		mock func()
+	}{
+		{
+			name: "delete_document",
+			ev:   models.ChangeEvent{Kind: models.ChangeDocument, DocID: "id1", Logins: []string{"login1"}},
+			mock: func() {
+				mockCache.EXPECT().Delete("id1")
+				mockListCache.EXPECT().DeletePrefix("login1\x00")
+			},
+		},
+		{
+			name: "lists_resync",
+			ev:   models.ChangeEvent{Kind: models.ChangeDocument, DocID: "id1", ListsResync: true},
+			mock: func() {
+				mockCache.EXPECT().Delete("id1")
+				mockListCache.EXPECT().Purge()
+			},
+		},
+		{
+			name: "resync",
+			ev:   models.ChangeEvent{Resync: true},
+			mock: func() {
+				mockCache.EXPECT().Purge()
+				mockListCache.EXPECT().Purge()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Service{
+				cache:     mockCache,
+				listCache: mockListCache,
+				log:       log,
+			}
+			s.HandleChange(tt.ev)
+		})
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     []byte
+		jsonData []byte
+		want     string
+	}{
+		{
+			name: "empty",
+			want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:     "file_and_json",
+			file:     []byte("hello "),
+			jsonData: []byte("world"),
+			want:     "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentHash(tt.file, tt.jsonData); got != tt.want {
+				t.Errorf("contentHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
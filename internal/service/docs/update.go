@@ -0,0 +1,150 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDocumentContent = errors.New("file or json required")
+	ErrDocumentPatch   = errors.New("nothing to update")
+	ErrDocumentName    = errors.New("document name required")
+)
+
+// UpdateDocument - заменяет содержимое документа: файл и JSON целиком, имя и тип - если заданы в meta
+// новый файл сохраняется под новым ключом, старый удалит фоновая очистка
+func (s *Service) UpdateDocument(ctx context.Context, login, docID string, meta models.Meta, jsonData, file []byte) error {
+	id, err := uuid.Parse(docID)
+	if err != nil {
+		s.log.Error("UpdateDocument", "failed to parse document id", err)
+		return err
+	}
+	if file == nil && jsonData == nil {
+		return ErrDocumentContent
+	}
+
+	doc, err := s.writableDocument(ctx, id, login)
+	if err != nil {
+		return err
+	}
+	prevUpdatedAt := doc.UpdatedAt
+
+	if name := strings.TrimSpace(meta.Name); name != "" {
+		doc.Name = name
+	}
+	if meta.Mime != "" {
+		doc.Mime = meta.Mime
+	}
+
+	key := fmt.Sprintf("%s%s", uuid.New().String(), filepath.Ext(doc.Name))
+	if _, err := s.s3.SaveFile(ctx, key, file, doc.Mime); err != nil {
+		s.log.Error("UpdateDocument", "failed to save file", err)
+		return err
+	}
+
+	doc.HashFile = file != nil
+	doc.JsonDate = jsonData
+	doc.StoragePath = key
+	doc.ContentHash = contentHash(file, jsonData)
+
+	if err := s.storage.UpdateDocument(ctx, login, doc, prevUpdatedAt); err != nil {
+		s.log.Error("UpdateDocument", "failed to update document", err)
+		if errs3 := s.s3.DeleteFile(key); errs3 != nil {
+			s.log.Error("UpdateDocument", "failed to delete new file", errs3)
+		}
+		return err
+	}
+	s.documentChanged(id, login)
+
+	return nil
+}
+
+// PatchDocument - меняет имя, тип, публичность и JSON документа, файл остается прежним
+func (s *Service) PatchDocument(ctx context.Context, login, docID string, patch models.DocumentPatch) error {
+	id, err := uuid.Parse(docID)
+	if err != nil {
+		s.log.Error("PatchDocument", "failed to parse document id", err)
+		return err
+	}
+	if patch.Name == nil && patch.Mime == nil && patch.Public == nil && patch.JSON == nil {
+		return ErrDocumentPatch
+	}
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		return ErrDocumentName
+	}
+
+	doc, err := s.writableDocument(ctx, id, login)
+	if err != nil {
+		return err
+	}
+	// публичность открывает документ всем, менять ее может только тот, кто управляет доступом
+	if patch.Public != nil && !models.PermissionAllows(doc.Permission, models.PermissionManage) {
+		return pq.ErrPermissionDenied
+	}
+	prevUpdatedAt := doc.UpdatedAt
+
+	if patch.Name != nil {
+		doc.Name = strings.TrimSpace(*patch.Name)
+	}
+	if patch.Mime != nil {
+		doc.Mime = *patch.Mime
+	}
+	if patch.Public != nil {
+		doc.Public = *patch.Public
+	}
+	if patch.JSON != nil {
+		// хеш считается по файлу и JSON, как при загрузке
+		var file []byte
+		if doc.HashFile {
+			file, err = s.s3.GetFile(ctx, doc.StoragePath)
+			if err != nil {
+				s.log.Error("PatchDocument", "failed to get file", err)
+				return err
+			}
+		}
+		doc.JsonDate = patch.JSON
+		if string(patch.JSON) == "null" {
+			doc.JsonDate = nil
+		}
+		doc.ContentHash = contentHash(file, doc.JsonDate)
+	}
+
+	if err := s.storage.UpdateDocument(ctx, login, doc, prevUpdatedAt); err != nil {
+		s.log.Error("PatchDocument", "failed to update document", err)
+		return err
+	}
+	s.documentChanged(id, login)
+
+	return nil
+}
+
+// writableDocument - документ, который пользователь может изменять
+// проверка до загрузки файла избавляет от лишней записи в хранилище, окончательно право проверяется в транзакции
+func (s *Service) writableDocument(ctx context.Context, id uuid.UUID, login string) (*models.Document, error) {
+	doc, err := s.storage.GetDocumentByID(ctx, id, login)
+	if err != nil {
+		s.log.Error("writableDocument", "failed to get document", err)
+		return nil, err
+	}
+	if doc == nil {
+		return nil, pq.ErrDocumentNotFound
+	}
+	if !models.PermissionAllows(doc.Permission, models.PermissionWrite) {
+		return nil, pq.ErrPermissionDenied
+	}
+	return doc, nil
+}
+
+// documentChanged - сбрасывает кеш на этом экземпляре сразу, остальные получат уведомление
+func (s *Service) documentChanged(id uuid.UUID, login string) {
+	s.cache.Delete(id.String())
+	s.invalidateLists(login)
+	s.purger.PurgeDocument(id.String())
+}
@@ -0,0 +1,252 @@
+package docs
+
+import (
+	"caching_web_server/internal/models"
+	"caching_web_server/internal/storage/pq"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+)
+
+func TestService_UpdateDocument(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockS3 := NewMocks3(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
+	s := NewService(mockStorage, mockS3, mockCache, mockListCache, mockPurger, log)
+
+	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
+	id := uuid.MustParse(docID)
+	version := time.Now()
+	current := func(permission string) *models.Document {
+		return &models.Document{
+			ID:          docID,
+			Name:        "report.txt",
+			Mime:        "text/plain",
+			HashFile:    true,
+			StoragePath: "old.txt",
+			ContentHash: "old",
+			UpdatedAt:   version,
+			Permission:  permission,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		meta    models.Meta
+		json    []byte
+		file    []byte
+		wantErr error
+	}{
+		{
+			name: "success_replace_content",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "editor").Return(current(models.PermissionWrite), nil)
+				mockS3.EXPECT().SaveFile(gomock.Any(), gomock.Any(), []byte("new"), "text/markdown").Return("", nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "editor", gomock.Any(), version).
+					DoAndReturn(func(_ context.Context, _ string, doc *models.Document, _ time.Time) error {
+						if doc.Name != "report.md" || doc.StoragePath == "old.txt" || doc.ContentHash != contentHash([]byte("new"), nil) {
+							t.Errorf("UpdateDocument() stored %+v", doc)
+						}
+						return nil
+					})
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("editor\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+			},
+			meta: models.Meta{Name: "report.md", Mime: "text/markdown"},
+			file: []byte("new"),
+		},
+		{
+			name:    "error_no_content",
+			mock:    func() {},
+			wantErr: ErrDocumentContent,
+		},
+		{
+			name: "error_read_only",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "editor").Return(current(models.PermissionRead), nil)
+			},
+			file:    []byte("new"),
+			wantErr: pq.ErrPermissionDenied,
+		},
+		{
+			name: "error_document_not_found",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "editor").Return(nil, nil)
+			},
+			file:    []byte("new"),
+			wantErr: pq.ErrDocumentNotFound,
+		},
+		{
+			name: "error_conflict_removes_new_file",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "editor").Return(current(models.PermissionWrite), nil)
+				mockS3.EXPECT().SaveFile(gomock.Any(), gomock.Any(), []byte("new"), "text/plain").Return("", nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "editor", gomock.Any(), version).Return(pq.ErrDocumentConflict)
+				mockS3.EXPECT().DeleteFile(gomock.Any()).Return(nil)
+			},
+			file:    []byte("new"),
+			wantErr: pq.ErrDocumentConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.UpdateDocument(context.Background(), "editor", docID, tt.meta, tt.json, tt.file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_PatchDocument(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := NewMockstorage(ctrl)
+	mockS3 := NewMocks3(ctrl)
+	mockCache := NewMockcache(ctrl)
+	mockListCache := NewMocklistCache(ctrl)
+	mockPurger := NewMockpurger(ctrl)
+	s := NewService(mockStorage, mockS3, mockCache, mockListCache, mockPurger, log)
+
+	docID := "4ebcbb61-8d0f-4c5c-a366-a65464bb9e5d"
+	id := uuid.MustParse(docID)
+	version := time.Now()
+	current := func(permission string) *models.Document {
+		return &models.Document{
+			ID:          docID,
+			Name:        "report.txt",
+			Mime:        "text/plain",
+			HashFile:    true,
+			StoragePath: "old.txt",
+			ContentHash: "old",
+			UpdatedAt:   version,
+			Permission:  permission,
+		}
+	}
+	name := "notes.txt"
+	blank := " "
+	public := true
+
+	tests := []struct {
+		name    string
+		mock    func()
+		patch   models.DocumentPatch
+		wantErr error
+	}{
+		{
+			name: "success_patch_metadata",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionOwner), nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "owner", gomock.Any(), version).
+					DoAndReturn(func(_ context.Context, _ string, doc *models.Document, _ time.Time) error {
+						// файл и хеш не меняются
+						if doc.Name != name || !doc.Public || doc.StoragePath != "old.txt" || doc.ContentHash != "old" {
+							t.Errorf("PatchDocument() stored %+v", doc)
+						}
+						return nil
+					})
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("owner\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+			},
+			patch: models.DocumentPatch{Name: &name, Public: &public},
+		},
+		{
+			name: "success_patch_json",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionOwner), nil)
+				mockS3.EXPECT().GetFile(gomock.Any(), "old.txt").Return([]byte("file"), nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "owner", gomock.Any(), version).
+					DoAndReturn(func(_ context.Context, _ string, doc *models.Document, _ time.Time) error {
+						if doc.ContentHash != contentHash([]byte("file"), []byte(`{"a":1}`)) {
+							t.Errorf("PatchDocument() content hash = %s", doc.ContentHash)
+						}
+						return nil
+					})
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("owner\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+			},
+			patch: models.DocumentPatch{JSON: json.RawMessage(`{"a":1}`)},
+		},
+		{
+			name: "success_manager_publishes",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionManage), nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "owner", gomock.Any(), version).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("owner\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+			},
+			patch: models.DocumentPatch{Public: &public},
+		},
+		{
+			name: "error_write_grantee_publishes",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionWrite), nil)
+			},
+			patch:   models.DocumentPatch{Public: &public},
+			wantErr: pq.ErrPermissionDenied,
+		},
+		{
+			name: "success_write_grantee_renames",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionWrite), nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "owner", gomock.Any(), version).Return(nil)
+				mockCache.EXPECT().Delete(docID)
+				mockListCache.EXPECT().DeletePrefix("owner\x00")
+				mockPurger.EXPECT().PurgeDocument(docID)
+			},
+			patch: models.DocumentPatch{Name: &name},
+		},
+		{
+			name:    "error_empty_patch",
+			mock:    func() {},
+			wantErr: ErrDocumentPatch,
+		},
+		{
+			name:    "error_blank_name",
+			mock:    func() {},
+			patch:   models.DocumentPatch{Name: &blank},
+			wantErr: ErrDocumentName,
+		},
+		{
+			name: "error_update_document",
+			mock: func() {
+				mockStorage.EXPECT().GetDocumentByID(gomock.Any(), id, "owner").Return(current(models.PermissionOwner), nil)
+				mockStorage.EXPECT().UpdateDocument(gomock.Any(), "owner", gomock.Any(), version).Return(errStorage)
+			},
+			patch:   models.DocumentPatch{Public: &public},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := s.PatchDocument(context.Background(), "owner", docID, tt.patch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PatchDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package disk
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tmpPrefix = ".tmp-"
+
+//go:generate mockgen -source=cache.go -destination=cache_mock.go -package=disk
+type backend interface {
+	SaveFile(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	GetFile(ctx context.Context, key string) ([]byte, error)
+	DeleteFile(key string) error
+}
+
+type entry struct {
+	name string
+	size int64
+}
+
+// Cache - кеш объектов на локальном диске перед основным хранилищем файлов
+type Cache struct {
+	backend  backend
+	dir      string
+	maxBytes int64
+	log      *slog.Logger
+
+	mu    sync.Mutex
+	size  int64
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// NewCache - конструктор, восстанавливает индекс по файлам в каталоге
+func NewCache(backend backend, dir string, maxBytes int64, log *slog.Logger) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		log.Error("NewCache", "failed to create cache dir", err)
+		return nil, err
+	}
+
+	c := &Cache{
+		backend:  backend,
+		dir:      dir,
+		maxBytes: maxBytes,
+		log:      log,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+
+	if err := c.loadIndex(); err != nil {
+		log.Error("NewCache", "failed to load index", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SaveFile - сохранение файла, в кеш файл попадает только при первом чтении
+func (c *Cache) SaveFile(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	return c.backend.SaveFile(ctx, key, data, contentType)
+}
+
+// GetFile - получение файла, в хранилище идем только при промахе
+func (c *Cache) GetFile(ctx context.Context, key string) ([]byte, error) {
+	name := fileName(key)
+
+	if c.touch(name) {
+		data, err := os.ReadFile(c.path(name))
+		if err == nil {
+			return data, nil
+		}
+		// файл мог быть вытеснен между проверкой и чтением
+		c.log.Error("GetFile", "failed to read cached file", err)
+		c.remove(name)
+	}
+
+	data, err := c.backend.GetFile(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store(name, data); err != nil {
+		c.log.Error("GetFile", "failed to store file in disk cache", err)
+	}
+
+	return data, nil
+}
+
+// DeleteFile - удаление файла
+func (c *Cache) DeleteFile(key string) error {
+	if err := c.backend.DeleteFile(key); err != nil {
+		return err
+	}
+	c.remove(fileName(key))
+	return nil
+}
+
+// touch - отмечает использование файла, время изменения файла хранит порядок LRU между перезапусками
+func (c *Cache) touch(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[name]
+	if !ok {
+		return false
+	}
+	c.ll.MoveToFront(el)
+
+	now := time.Now()
+	if err := os.Chtimes(c.path(name), now, now); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.log.Error("touch", "failed to update file time", err)
+	}
+	return true
+}
+
+// store - атомарно записывает файл: сначала во временный, затем переименование
+func (c *Cache) store(name string, data []byte) error {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(c.dir, tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			c.log.Error("store", "failed to remove temp file", err)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), c.path(name)); err != nil {
+		return err
+	}
+	if el, ok := c.items[name]; ok {
+		c.removeElement(el)
+	}
+	c.add(name, size)
+	return nil
+}
+
+func (c *Cache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[name]; ok {
+		c.removeElement(el)
+		c.deleteFile(name)
+	}
+}
+
+// add - добавляет запись в начало списка и вытесняет старые, пока не уложимся в лимит
+// вызывается под мьютексом
+func (c *Cache) add(name string, size int64) {
+	c.items[name] = c.ll.PushFront(&entry{name: name, size: size})
+	c.size += size
+
+	for c.size > c.maxBytes {
+		el := c.ll.Back()
+		e := el.Value.(*entry)
+		c.removeElement(el)
+		c.deleteFile(e.name)
+	}
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, e.name)
+	c.size -= e.size
+}
+
+func (c *Cache) deleteFile(name string) {
+	if err := os.Remove(c.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.log.Error("deleteFile", "failed to remove cached file", err)
+	}
+}
+
+// loadIndex - восстанавливает индекс по каталогу, самые старые по времени изменения вытесняются первыми
+func (c *Cache) loadIndex() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type fileInfo struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []fileInfo
+
+	for _, de := range dirEntries {
+		if !de.Type().IsRegular() {
+			continue
+		}
+		// недописанные файлы остаются после аварийного завершения
+		if strings.HasPrefix(de.Name(), tmpPrefix) {
+			c.deleteFile(de.Name())
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, fileInfo{name: de.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, f := range files {
+		c.add(f.name, f.size)
+	}
+	return nil
+}
+
+func (c *Cache) path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// fileName - имя файла в кеше, ключ хешируем, чтобы не зависеть от символов в нем
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cache.go
+
+// Package disk is a generated GoMock package.
+package disk
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockbackend is a mock of backend interface.
+type Mockbackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockbackendMockRecorder
+}
+
+// MockbackendMockRecorder is the mock recorder for Mockbackend.
+type MockbackendMockRecorder struct {
+	mock *Mockbackend
+}
+
+// NewMockbackend creates a new mock instance.
+func NewMockbackend(ctrl *gomock.Controller) *Mockbackend {
+	mock := &Mockbackend{ctrl: ctrl}
+	mock.recorder = &MockbackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbackend) EXPECT() *MockbackendMockRecorder {
+	return m.recorder
+}
+
+// DeleteFile mocks base method.
+func (m *Mockbackend) DeleteFile(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockbackendMockRecorder) DeleteFile(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*Mockbackend)(nil).DeleteFile), key)
+}
+
+// GetFile mocks base method.
+func (m *Mockbackend) GetFile(ctx context.Context, key string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", ctx, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockbackendMockRecorder) GetFile(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*Mockbackend)(nil).GetFile), ctx, key)
+}
+
+// SaveFile mocks base method.
+func (m *Mockbackend) SaveFile(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFile", ctx, key, data, contentType)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveFile indicates an expected call of SaveFile.
+func (mr *MockbackendMockRecorder) SaveFile(ctx, key, data, contentType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFile", reflect.TypeOf((*Mockbackend)(nil).SaveFile), ctx, key, data, contentType)
+}
@@ -0,0 +1,168 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend error")
+
+func TestCache_GetFile(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, t.TempDir(), 1024, log)
+	require.NoError(t, err)
+
+	// промах - идем в хранилище и кладем файл на диск
+	mockBackend.EXPECT().GetFile(gomock.Any(), "file.txt").Return([]byte("hello"), nil).Times(1)
+
+	data, err := c.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+
+	// попадание - хранилище больше не вызывается
+	data, err = c.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+
+	cached, err := os.ReadFile(c.path(fileName("file.txt")))
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), cached)
+}
+
+func TestCache_GetFileError(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, t.TempDir(), 1024, log)
+	require.NoError(t, err)
+
+	mockBackend.EXPECT().GetFile(gomock.Any(), "file.txt").Return(nil, errBackend)
+
+	_, err = c.GetFile(context.Background(), "file.txt")
+	require.ErrorIs(t, err, errBackend)
+	require.Equal(t, 0, c.ll.Len())
+}
+
+func TestCache_Evict(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, t.TempDir(), 10, log)
+	require.NoError(t, err)
+
+	mockBackend.EXPECT().GetFile(gomock.Any(), "a").Return([]byte("12345"), nil).Times(1)
+	mockBackend.EXPECT().GetFile(gomock.Any(), "b").Return([]byte("12345"), nil).Times(1)
+	mockBackend.EXPECT().GetFile(gomock.Any(), "c").Return([]byte("12345"), nil).Times(1)
+	mockBackend.EXPECT().GetFile(gomock.Any(), "big").Return([]byte("12345678901"), nil).Times(1)
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, err := c.GetFile(context.Background(), key)
+		require.NoError(t, err)
+	}
+
+	// "b" использовался раньше всех и вытеснен, "a" прочитан повторно и остался
+	require.Equal(t, int64(10), c.size)
+	_, err = os.Stat(c.path(fileName("b")))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(c.path(fileName("a")))
+	require.NoError(t, err)
+
+	// слишком большой файл в кеш не попадает
+	_, err = c.GetFile(context.Background(), "big")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), c.size)
+	require.Equal(t, 2, c.ll.Len())
+}
+
+func TestCache_DeleteFile(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, t.TempDir(), 1024, log)
+	require.NoError(t, err)
+
+	mockBackend.EXPECT().GetFile(gomock.Any(), "file.txt").Return([]byte("hello"), nil)
+	_, err = c.GetFile(context.Background(), "file.txt")
+	require.NoError(t, err)
+
+	mockBackend.EXPECT().DeleteFile("file.txt").Return(nil)
+	require.NoError(t, c.DeleteFile("file.txt"))
+
+	_, err = os.Stat(c.path(fileName("file.txt")))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Equal(t, int64(0), c.size)
+
+	mockBackend.EXPECT().DeleteFile("other.txt").Return(errBackend)
+	require.ErrorIs(t, c.DeleteFile("other.txt"), errBackend)
+}
+
+func TestCache_SaveFile(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, t.TempDir(), 1024, log)
+	require.NoError(t, err)
+
+	mockBackend.EXPECT().SaveFile(gomock.Any(), "file.txt", []byte("hello"), "text/plain").Return("url", nil)
+	url, err := c.SaveFile(context.Background(), "file.txt", []byte("hello"), "text/plain")
+	require.NoError(t, err)
+	require.Equal(t, "url", url)
+	require.Equal(t, 0, c.ll.Len())
+}
+
+func TestNewCache_LoadIndex(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	now := time.Now()
+
+	write := func(name, data string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write(fileName("old"), "12345", now.Add(-2*time.Hour))
+	write(fileName("mid"), "12345", now.Add(-time.Hour))
+	write(fileName("new"), "12345", now)
+	write(tmpPrefix+"broken", "123", now)
+
+	mockBackend := NewMockbackend(ctrl)
+	c, err := NewCache(mockBackend, dir, 10, log)
+	require.NoError(t, err)
+
+	// самый старый файл не помещается в лимит и удаляется, временный файл убран
+	require.Equal(t, 2, c.ll.Len())
+	require.Equal(t, int64(10), c.size)
+	_, err = os.Stat(filepath.Join(dir, fileName("old")))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(filepath.Join(dir, tmpPrefix+"broken"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// восстановленные файлы отдаются без обращения к хранилищу
+	data, err := c.GetFile(context.Background(), "new")
+	require.NoError(t, err)
+	require.Equal(t, []byte("12345"), data)
+}
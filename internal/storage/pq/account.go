@@ -0,0 +1,225 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangePassword - меняет хеш пароля и отзывает все выданные до этого токены и API-ключи пользователя
+// возвращает момент, раньше которого выданные access-токены больше не действуют
+func (s *Storage) ChangePassword(ctx context.Context, login, passwordHash string) (cutoff time.Time, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("ChangePassword", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("ChangePassword", "commit failed", err)
+		}
+	}()
+
+	query := `
+UPDATE users SET password_hash = $2, tokens_valid_after = now()
+WHERE login = $1 AND deleted_at IS NULL
+RETURNING id, tokens_valid_after
+`
+	var userID int64
+	err = tx.QueryRowContext(ctx, query, login, passwordHash).Scan(&userID, &cutoff)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrUserNotFound
+		}
+		s.log.Error("ChangePassword", "failed to update password", err)
+		return time.Time{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = true WHERE user_id = $1 AND NOT revoked`, userID)
+	if err != nil {
+		s.log.Error("ChangePassword", "failed to revoke refresh tokens", err)
+		return time.Time{}, err
+	}
+
+	// ключ мог выпустить тот, кто знал старый пароль, как и при удалении учетной записи
+	_, err = tx.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID)
+	if err != nil {
+		s.log.Error("ChangePassword", "failed to delete api keys", err)
+		return time.Time{}, err
+	}
+
+	return cutoff, nil
+}
+
+// GetTokenCutoffs - пользователи, чьи токены были отозваны целиком после since
+func (s *Storage) GetTokenCutoffs(ctx context.Context, since time.Time) (map[string]time.Time, error) {
+	query := `SELECT login, tokens_valid_after FROM users WHERE tokens_valid_after > $1`
+	rows, err := s.db.QueryContext(ctx, query, since)
+	if err != nil {
+		s.log.Error("GetTokenCutoffs", "failed to get token cutoffs", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	cutoffs := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			login  string
+			cutoff time.Time
+		)
+		if err := rows.Scan(&login, &cutoff); err != nil {
+			s.log.Error("GetTokenCutoffs", "failed to scan token cutoff", err)
+			return nil, err
+		}
+		cutoffs[login] = cutoff
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("GetTokenCutoffs", "failed to read token cutoffs", err)
+		return nil, err
+	}
+
+	return cutoffs, nil
+}
+
+// DeleteUser - удаляет учетную запись: документы помечаются удаленными, их файлы встают в очередь на удаление из хранилища
+// сама строка пользователя остается, иначе каскад удалил бы документы физически; логин повторно не выдается
+func (s *Storage) DeleteUser(ctx context.Context, login string) (cutoff time.Time, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("DeleteUser", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("DeleteUser", "commit failed", err)
+		}
+	}()
+
+	query := `
+UPDATE users SET deleted_at = now(), tokens_valid_after = now()
+WHERE login = $1 AND deleted_at IS NULL
+RETURNING id, tokens_valid_after
+`
+	var userID int64
+	err = tx.QueryRowContext(ctx, query, login).Scan(&userID, &cutoff)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrUserNotFound
+		}
+		s.log.Error("DeleteUser", "failed to delete user", err)
+		return time.Time{}, err
+	}
+
+	docIDs, err := s.userDocuments(ctx, tx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// уведомляем до снятия грантов, чтобы сбросились списки всех, кому документы были видны,
+	// и списки владельцев документов, открытых удаляемому: в них пропадает его доступ
+	for _, docID := range docIDs {
+		if err = s.changed(ctx, tx, models.ChangeDocument, docID); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"schedule storage cleanup", `
+INSERT INTO storage_cleanup (storage_path)
+SELECT storage_path FROM documents
+WHERE owner_id = $1 AND NOT is_deleted AND storage_path IS NOT NULL
+ON CONFLICT (storage_path) DO NOTHING`},
+		{"delete documents", `UPDATE documents SET is_deleted = true WHERE owner_id = $1 AND NOT is_deleted`},
+		{"delete grants", `DELETE FROM grants WHERE user_id = $1`},
+		{"delete group memberships", `DELETE FROM group_members WHERE user_id = $1`},
+		{"delete api keys", `DELETE FROM api_keys WHERE user_id = $1`},
+		{"revoke refresh tokens", `UPDATE refresh_tokens SET revoked = true WHERE user_id = $1 AND NOT revoked`},
+	}
+	for _, q := range queries {
+		if _, err = tx.ExecContext(ctx, q.query, userID); err != nil {
+			s.log.Error("DeleteUser", "failed to "+q.name, err)
+			return time.Time{}, err
+		}
+	}
+
+	return cutoff, nil
+}
+
+// userDocuments - неудаленные документы пользователя и документы, открытые ему напрямую или через группы
+func (s *Storage) userDocuments(ctx context.Context, tx *sql.Tx, userID int64) ([]uuid.UUID, error) {
+	query := `
+SELECT id FROM documents
+WHERE NOT is_deleted
+  AND (owner_id = $1 OR id IN (SELECT doc_id FROM document_access WHERE user_id = $1))
+`
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		s.log.Error("userDocuments", "failed to get documents", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			s.log.Error("userDocuments", "failed to scan document id", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("userDocuments", "failed to read documents", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetAccount - профиль пользователя и сколько он занимает
+func (s *Storage) GetAccount(ctx context.Context, login string) (models.Account, error) {
+	query := `
+SELECT u.login, u.role, u.created_at, u.totp_enabled,
+       (SELECT count(*) FROM documents d WHERE d.owner_id = u.id AND NOT d.is_deleted),
+       (SELECT count(DISTINCT a.doc_id) FROM document_access a JOIN documents d ON d.id = a.doc_id
+        WHERE a.user_id = u.id AND d.owner_id <> u.id AND NOT d.is_deleted),
+       (SELECT count(*) FROM api_keys a WHERE a.user_id = u.id)
+FROM users u
+WHERE u.login = $1 AND u.deleted_at IS NULL
+`
+	var account models.Account
+	err := s.db.QueryRowContext(ctx, query, login).Scan(
+		&account.Login,
+		&account.Role,
+		&account.CreatedAt,
+		&account.TwoFactor,
+		&account.Usage.Documents,
+		&account.Usage.Shared,
+		&account.Usage.APIKeys,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Account{}, ErrUserNotFound
+		}
+		s.log.Error("GetAccount", "failed to get account", err)
+		return models.Account{}, err
+	}
+	return account, nil
+}
@@ -0,0 +1,354 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestStorage_ChangePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	cutoff := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_change_password",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_valid_after"}).AddRow(1, cutoff))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM api_keys").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_revoke_refresh_tokens",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_valid_after"}).AddRow(1, cutoff))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+		{
+			name: "error_delete_api_keys",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_valid_after"}).AddRow(1, cutoff))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM api_keys").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.ChangePassword(context.Background(), "test", "hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(cutoff) {
+				t.Errorf("ChangePassword() = %v, want %v", got, cutoff)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetTokenCutoffs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	since := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "success_get_token_cutoffs",
+			mock: func() {
+				mock.ExpectQuery("SELECT login, tokens_valid_after FROM users").
+					WithArgs(since).
+					WillReturnRows(sqlmock.NewRows([]string{"login", "tokens_valid_after"}).
+						AddRow("test1", time.Now()).
+						AddRow("test2", time.Now()))
+			},
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "error_get_token_cutoffs",
+			mock: func() {
+				mock.ExpectQuery("SELECT login, tokens_valid_after FROM users").
+					WithArgs(since).
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetTokenCutoffs(context.Background(), since)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTokenCutoffs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("GetTokenCutoffs() = %v, want %v entries", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	cutoff := time.Now()
+	docID := uuid.New()
+	sharedID := uuid.New()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_delete_user",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET deleted_at").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_valid_after"}).AddRow(1, cutoff))
+				// свой документ и чужой, открытый удаляемому
+				mock.ExpectQuery("(?s)SELECT id FROM documents.+document_access").
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID).AddRow(sharedID))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("test"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(sharedID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(sharedID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner").AddRow("test"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, changeEvent(models.ChangeDocument, sharedID.String(), "owner", "test")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO storage_cleanup").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE documents SET is_deleted = true").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM grants").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM group_members").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM api_keys").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET deleted_at").
+					WithArgs("test").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_schedule_cleanup",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET deleted_at").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_valid_after"}).AddRow(1, cutoff))
+				mock.ExpectQuery("SELECT id FROM documents").
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO storage_cleanup").
+					WithArgs(int64(1)).
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.DeleteUser(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(cutoff) {
+				t.Errorf("DeleteUser() = %v, want %v", got, cutoff)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	createdAt := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.Account
+		wantErr error
+	}{
+		{
+			name: "success_get_account",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users u").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"login", "role", "created_at", "totp_enabled", "documents", "shared", "api_keys"}).
+						AddRow("test", "user", createdAt, true, 3, 2, 1))
+			},
+			want: models.Account{
+				Login:     "test",
+				Role:      "user",
+				CreatedAt: createdAt,
+				TwoFactor: true,
+				Usage:     models.AccountUsage{Documents: 3, Shared: 2, APIKeys: 1},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users u").
+					WithArgs("test").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_get_account",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users u").
+					WithArgs("test").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetAccount(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetAccount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetAccount() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
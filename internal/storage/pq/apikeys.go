@@ -0,0 +1,139 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// codeUniqueViolation - код ошибки Postgres при нарушении уникальности
+const codeUniqueViolation = "23505"
+
+// apiKeyTouchInterval - чаще этого время последнего использования ключа не пишем, чтобы не нагружать базу на каждом запросе
+const apiKeyTouchInterval = "1 minute"
+
+// SaveAPIKey - сохраняет хеш API-ключа пользователя, id и время создания заполняются из базы
+func (s *Storage) SaveAPIKey(ctx context.Context, login, keyHash string, key *models.APIKey) error {
+	query := `
+INSERT INTO api_keys (id, user_id, name, key_hash, scopes, expires_at)
+SELECT $1, id, $3, $4, $5, $6 FROM users WHERE login = $2 AND deleted_at IS NULL
+RETURNING created_at
+`
+	key.ID = uuid.New()
+	err := s.db.QueryRowContext(ctx, query, key.ID, login, key.Name, keyHash, pq.Array(key.Scopes), key.ExpiresAt).
+		Scan(&key.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == codeUniqueViolation:
+			return ErrAPIKeyExists
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrUserNotFound
+		}
+		s.log.Error("SaveAPIKey", "failed to save api key", err)
+		return err
+	}
+	return nil
+}
+
+// GetAPIKeys - возвращает ключи пользователя без самих ключей
+func (s *Storage) GetAPIKeys(ctx context.Context, login string) ([]models.APIKey, error) {
+	query := `
+SELECT a.id, a.name, a.scopes, a.expires_at, a.last_used_at, a.created_at
+FROM api_keys a
+JOIN users u ON u.id = a.user_id
+WHERE u.login = $1
+ORDER BY a.created_at
+`
+	rows, err := s.db.QueryContext(ctx, query, login)
+	if err != nil {
+		s.log.Error("GetAPIKeys", "failed to get api keys", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		var (
+			key      models.APIKey
+			expires  sql.NullTime
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &expires, &lastUsed, &key.CreatedAt); err != nil {
+			s.log.Error("GetAPIKeys", "failed to scan api key", err)
+			return nil, err
+		}
+		if expires.Valid {
+			key.ExpiresAt = &expires.Time
+		}
+		if lastUsed.Valid {
+			key.LastUsedAt = &lastUsed.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("GetAPIKeys", "failed to read api keys", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey - отзывает ключ, чужой ключ не найдется
+func (s *Storage) DeleteAPIKey(ctx context.Context, login string, id uuid.UUID) error {
+	query := `DELETE FROM api_keys WHERE id = $1 AND user_id = (SELECT id FROM users WHERE login = $2)`
+	res, err := s.db.ExecContext(ctx, query, id, login)
+	if err != nil {
+		s.log.Error("DeleteAPIKey", "failed to delete api key", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("DeleteAPIKey", "failed to get rows affected", err)
+		return err
+	}
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// UseAPIKey - ищет ключ по хешу и отмечает его использование
+// роль берется из users, поэтому смена роли сразу действует и на ключи
+func (s *Storage) UseAPIKey(ctx context.Context, keyHash string) (models.Identity, *time.Time, error) {
+	query := `
+WITH k AS (
+	SELECT a.id, u.login, u.role, a.scopes, a.expires_at
+	FROM api_keys a
+	JOIN users u ON u.id = a.user_id
+	WHERE a.key_hash = $1
+), touch AS (
+	UPDATE api_keys SET last_used_at = now()
+	WHERE id = (SELECT id FROM k)
+	  AND (last_used_at IS NULL OR last_used_at < now() - interval '` + apiKeyTouchInterval + `')
+)
+SELECT login, role, scopes, expires_at FROM k
+`
+	var (
+		id      models.Identity
+		expires sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, query, keyHash).Scan(&id.Login, &id.Role, pq.Array(&id.Scopes), &expires)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Identity{}, nil, ErrAPIKeyNotFound
+		}
+		s.log.Error("UseAPIKey", "failed to get api key", err)
+		return models.Identity{}, nil, err
+	}
+
+	if !expires.Valid {
+		return id, nil, nil
+	}
+	return id, &expires.Time, nil
+}
@@ -0,0 +1,289 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestStorage_SaveAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	createdAt := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_save_api_key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(sqlmock.AnyArg(), "test", "ci", "hash", pq.Array([]string{"read", "write"}), nil).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_name_exists",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WillReturnError(&pq.Error{Code: codeUniqueViolation})
+			},
+			wantErr: ErrAPIKeyExists,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_save_api_key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			key := &models.APIKey{Name: "ci", Scopes: []string{"read", "write"}}
+			err := s.SaveAPIKey(context.Background(), "test", "hash", key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SaveAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (key.ID == uuid.Nil || !key.CreatedAt.Equal(createdAt)) {
+				t.Errorf("SaveAPIKey() key = %+v", key)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "success_get_api_keys",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_keys").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "expires_at", "last_used_at", "created_at"}).
+						AddRow(uuid.New(), "ci", "{read,write}", now, nil, now).
+						AddRow(uuid.New(), "backup", "{read}", nil, now, now))
+			},
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "error_get_api_keys",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_keys").
+					WithArgs("test").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetAPIKeys(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetAPIKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("GetAPIKeys() = %v, want %v keys", got, tt.want)
+			}
+			if tt.want > 0 && (got[0].ExpiresAt == nil || got[0].LastUsedAt != nil || len(got[0].Scopes) != 2) {
+				t.Errorf("GetAPIKeys() first key = %+v", got[0])
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_delete_api_key",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM api_keys").
+					WithArgs(id, "test").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_not_found",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM api_keys").
+					WithArgs(id, "test").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+		{
+			name: "error_delete_api_key",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM api_keys").
+					WithArgs(id, "test").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.DeleteAPIKey(context.Background(), "test", id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_UseAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		wantLogin   string
+		wantExpires bool
+		wantErr     error
+	}{
+		{
+			name: "success_use_api_key",
+			mock: func() {
+				mock.ExpectQuery("UPDATE api_keys SET last_used_at").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"login", "role", "scopes", "expires_at"}).
+						AddRow("test", "user", "{read}", expiresAt))
+			},
+			wantLogin:   "test",
+			wantExpires: true,
+			wantErr:     nil,
+		},
+		{
+			name: "success_without_expiry",
+			mock: func() {
+				mock.ExpectQuery("UPDATE api_keys SET last_used_at").
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"login", "role", "scopes", "expires_at"}).
+						AddRow("test", "user", "{read}", nil))
+			},
+			wantLogin: "test",
+			wantErr:   nil,
+		},
+		{
+			name: "error_not_found",
+			mock: func() {
+				mock.ExpectQuery("UPDATE api_keys SET last_used_at").
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+		{
+			name: "error_use_api_key",
+			mock: func() {
+				mock.ExpectQuery("UPDATE api_keys SET last_used_at").
+					WithArgs("hash").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			id, expires, err := s.UseAPIKey(context.Background(), "hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UseAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id.Login != tt.wantLogin {
+				t.Errorf("UseAPIKey() login = %v, want %v", id.Login, tt.wantLogin)
+			}
+			if (expires != nil) != tt.wantExpires {
+				t.Errorf("UseAPIKey() expires = %v, want %v", expires, tt.wantExpires)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
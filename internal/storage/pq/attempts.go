@@ -0,0 +1,145 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// AttemptStore - счетчики неудачных входов в Postgres, общие для всех экземпляров
+type AttemptStore struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+// NewAttemptStore - работает через соединение основного хранилища
+func NewAttemptStore(s *Storage) *AttemptStore {
+	return &AttemptStore{
+		db:  s.db,
+		log: s.log,
+	}
+}
+
+// Attempt - учитывает попытку входа под блокировкой строки, чтобы параллельные попытки считались по очереди
+// если ключ заблокирован, возвращает блокировку и ничего не считает; иначе увеличивает счетчик, если прошлой попытке больше window - начинает заново,
+// и блокирует ключ на delay от нового значения
+func (a *AttemptStore) Attempt(ctx context.Context, key string, window time.Duration, delay func(failures int) time.Duration) (until time.Time, err error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				a.log.Error("Attempt", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			a.log.Error("Attempt", "commit failed", err)
+		}
+	}()
+
+	query := `INSERT INTO login_attempts (key, failures, last_failure) VALUES ($1, 0, now()) ON CONFLICT (key) DO NOTHING`
+	if _, err = tx.ExecContext(ctx, query, key); err != nil {
+		a.log.Error("Attempt", "failed to create counter", err)
+		return time.Time{}, err
+	}
+
+	query = `
+SELECT CASE WHEN last_failure < now() - make_interval(secs => $2) THEN 0 ELSE failures END,
+       locked_until, now()
+FROM login_attempts
+WHERE key = $1
+FOR UPDATE
+`
+	var (
+		failures int
+		locked   sql.NullTime
+		now      time.Time
+	)
+	if err = tx.QueryRowContext(ctx, query, key, window.Seconds()).Scan(&failures, &locked, &now); err != nil {
+		a.log.Error("Attempt", "failed to get counter", err)
+		return time.Time{}, err
+	}
+	if locked.Time.After(now) {
+		return locked.Time, nil
+	}
+
+	failures++
+	var lock sql.NullTime
+	if d := delay(failures); d > 0 {
+		lock = sql.NullTime{Time: now.Add(d), Valid: true}
+	}
+
+	query = `
+UPDATE login_attempts
+SET failures = $2, last_failure = now(), locked_until = COALESCE($3, locked_until)
+WHERE key = $1
+`
+	if _, err = tx.ExecContext(ctx, query, key, failures, lock); err != nil {
+		a.log.Error("Attempt", "failed to register attempt", err)
+		return time.Time{}, err
+	}
+	return time.Time{}, nil
+}
+
+// Release - отменяет учтенную попытку, блокировка снимается, если счетчик стал меньше threshold
+func (a *AttemptStore) Release(ctx context.Context, key string, threshold int) error {
+	query := `
+UPDATE login_attempts
+SET failures = GREATEST(failures - 1, 0),
+    locked_until = CASE WHEN failures - 1 < $2 THEN NULL ELSE locked_until END
+WHERE key = $1
+`
+	_, err := a.db.ExecContext(ctx, query, key, threshold)
+	if err != nil {
+		a.log.Error("Release", "failed to release attempt", err)
+		return err
+	}
+	return nil
+}
+
+// LockedUntil - время окончания блокировки, нулевое - блокировки нет
+func (a *AttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	query := `SELECT locked_until FROM login_attempts WHERE key = $1`
+	var until sql.NullTime
+	err := a.db.QueryRowContext(ctx, query, key).Scan(&until)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		a.log.Error("LockedUntil", "failed to get lock", err)
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// Reset - удаляет счетчик вместе с блокировкой
+func (a *AttemptStore) Reset(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE key = $1`
+	_, err := a.db.ExecContext(ctx, query, key)
+	if err != nil {
+		a.log.Error("Reset", "failed to reset counter", err)
+		return err
+	}
+	return nil
+}
+
+// Cleanup - удаляет счетчики без попыток после before и без действующей блокировки
+func (a *AttemptStore) Cleanup(ctx context.Context, before time.Time) error {
+	query := `
+DELETE FROM login_attempts
+WHERE last_failure < $1
+  AND (locked_until IS NULL OR locked_until < now())
+`
+	_, err := a.db.ExecContext(ctx, query, before)
+	if err != nil {
+		a.log.Error("Cleanup", "failed to cleanup counters", err)
+		return err
+	}
+	return nil
+}
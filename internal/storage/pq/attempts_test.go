@@ -0,0 +1,202 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newTestAttemptStore(t *testing.T) (*AttemptStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	return NewAttemptStore(&Storage{db: db, log: log}), mock
+}
+
+func TestAttemptStore_Attempt(t *testing.T) {
+	a, mock := newTestAttemptStore(t)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	lockAt := func(failures int) time.Duration {
+		if failures < 3 {
+			return 0
+		}
+		return time.Minute
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name: "counted",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO login_attempts").
+					WithArgs("login:user").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("(?s)SELECT CASE WHEN last_failure.*FOR UPDATE").
+					WithArgs("login:user", float64(3600)).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "locked_until", "now"}).AddRow(1, nil, now))
+				mock.ExpectExec("UPDATE login_attempts").
+					WithArgs("login:user", 2, sql.NullTime{}).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			// попытка, на которой счетчик достиг порога, проходит, но сразу блокирует следующие
+			name: "counted_and_locked",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO login_attempts").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("(?s)SELECT CASE WHEN last_failure.*FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "locked_until", "now"}).AddRow(2, now.Add(-time.Minute), now))
+				mock.ExpectExec("UPDATE login_attempts").
+					WithArgs("login:user", 3, sql.NullTime{Time: now.Add(time.Minute), Valid: true}).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "locked",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO login_attempts").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("(?s)SELECT CASE WHEN last_failure.*FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "locked_until", "now"}).AddRow(3, now.Add(time.Minute), now))
+				mock.ExpectCommit()
+			},
+			want: now.Add(time.Minute),
+		},
+		{
+			name: "error",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO login_attempts").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := a.Attempt(context.Background(), "login:user", time.Hour, lockAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Attempt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Attempt() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestAttemptStore_LockedUntil(t *testing.T) {
+	a, mock := newTestAttemptStore(t)
+	until := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name: "locked",
+			mock: func() {
+				mock.ExpectQuery("SELECT locked_until").
+					WithArgs("login:user").
+					WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(until))
+			},
+			want: until,
+		},
+		{
+			name: "not_locked",
+			mock: func() {
+				mock.ExpectQuery("SELECT locked_until").
+					WithArgs("login:user").
+					WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(nil))
+			},
+		},
+		{
+			name: "no_counter",
+			mock: func() {
+				mock.ExpectQuery("SELECT locked_until").
+					WithArgs("login:user").
+					WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name: "error",
+			mock: func() {
+				mock.ExpectQuery("SELECT locked_until").
+					WithArgs("login:user").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := a.LockedUntil(context.Background(), "login:user")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LockedUntil() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("LockedUntil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttemptStore_ReleaseResetCleanup(t *testing.T) {
+	a, mock := newTestAttemptStore(t)
+	ctx := context.Background()
+	until := time.Now().Add(time.Minute)
+
+	mock.ExpectExec(`(?s)UPDATE login_attempts SET failures = GREATEST.*CASE WHEN failures - 1 < \$2 THEN NULL`).
+		WithArgs("ip:10.0.0.1", 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := a.Release(ctx, "ip:10.0.0.1", 20); err != nil {
+		t.Errorf("Release() error = %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE key").
+		WithArgs("login:user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := a.Reset(ctx, "login:user"); err != nil {
+		t.Errorf("Reset() error = %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM login_attempts").
+		WithArgs(until).
+		WillReturnError(errStorage)
+	if err := a.Cleanup(ctx, until); !errors.Is(err, errStorage) {
+		t.Errorf("Cleanup() error = %v, wantErr %v", err, errStorage)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package pq
+
+import (
+	"context"
+)
+
+// GetCleanupPaths - файлы, которые осталось удалить из хранилища, старые первыми
+func (s *Storage) GetCleanupPaths(ctx context.Context, limit int) ([]string, error) {
+	query := `SELECT storage_path FROM storage_cleanup ORDER BY created_at LIMIT $1`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		s.log.Error("GetCleanupPaths", "failed to get cleanup paths", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			s.log.Error("GetCleanupPaths", "failed to scan cleanup path", err)
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("GetCleanupPaths", "failed to read cleanup paths", err)
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// DeleteCleanupPath - убирает файл из очереди после удаления из хранилища
+func (s *Storage) DeleteCleanupPath(ctx context.Context, path string) error {
+	query := `DELETE FROM storage_cleanup WHERE storage_path = $1`
+	_, err := s.db.ExecContext(ctx, query, path)
+	if err != nil {
+		s.log.Error("DeleteCleanupPath", "failed to delete cleanup path", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package pq
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestStorage_GetCleanupPaths(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "success_get_cleanup_paths",
+			mock: func() {
+				mock.ExpectQuery("SELECT storage_path FROM storage_cleanup").
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path"}).AddRow("a.pdf").AddRow("b.json"))
+			},
+			want:    []string{"a.pdf", "b.json"},
+			wantErr: nil,
+		},
+		{
+			name: "error_get_cleanup_paths",
+			mock: func() {
+				mock.ExpectQuery("SELECT storage_path FROM storage_cleanup").
+					WithArgs(10).
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetCleanupPaths(context.Background(), 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetCleanupPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCleanupPaths() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteCleanupPath(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	s := &Storage{
+		db:  db,
+		log: log,
+	}
+
+	mock.ExpectExec("DELETE FROM storage_cleanup").
+		WithArgs("a.pdf").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.DeleteCleanupPath(context.Background(), "a.pdf"); err != nil {
+		t.Errorf("DeleteCleanupPath() error = %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM storage_cleanup").
+		WithArgs("a.pdf").
+		WillReturnError(errStorage)
+	if err := s.DeleteCleanupPath(context.Background(), "a.pdf"); !errors.Is(err, errStorage) {
+		t.Errorf("DeleteCleanupPath() error = %v, wantErr %v", err, errStorage)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetGrants - кому открыт доступ к документу: сначала пользователи, затем группы
+// видят владелец и пользователи с правом manage
+func (s *Storage) GetGrants(ctx context.Context, login string, docID uuid.UUID) ([]models.Grant, error) {
+	if err := s.requirePermission(ctx, s.db, login, docID, models.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	query := `
+SELECT u.login, NULL AS group_name, g.permission
+FROM grants g
+JOIN users u ON u.id = g.user_id
+WHERE g.doc_id = $1 AND u.deleted_at IS NULL
+UNION ALL
+SELECT NULL, gr.name, gg.permission
+FROM group_grants gg
+JOIN groups gr ON gr.id = gg.group_id
+WHERE gg.doc_id = $1
+ORDER BY 2 NULLS FIRST, 1
+`
+	rows, err := s.db.QueryContext(ctx, query, docID)
+	if err != nil {
+		s.log.Error("GetGrants", "failed to get grants", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.Grant{}
+	for rows.Next() {
+		var (
+			grant       models.Grant
+			user, group sql.NullString
+		)
+		if err := rows.Scan(&user, &group, &grant.Permission); err != nil {
+			s.log.Error("GetGrants", "failed to scan grant", err)
+			return nil, err
+		}
+		grant.Login = user.String
+		grant.Group = group.String
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("GetGrants", "failed to read grants", err)
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+// AddGrants - открывает доступ к документу пользователям и группам с уровнем permission, у уже получивших доступ уровень заменяется
+// если хотя бы одного логина или доступной login группы нет, ничего не меняется; владельцу доступ напрямую не выдается
+func (s *Storage) AddGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string, permission string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("AddGrants", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("AddGrants", "commit failed", err)
+		}
+	}()
+
+	if err = s.requirePermission(ctx, tx, login, docID, models.PermissionManage); err != nil {
+		return err
+	}
+
+	if len(logins) > 0 {
+		var unknown []string
+		unknown, err = s.missing(ctx, tx, `SELECT login FROM users WHERE login = ANY($1::text[]) AND deleted_at IS NULL`, logins)
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			err = fmt.Errorf("%w: %s", ErrGrantUserNotFound, strings.Join(unknown, ", "))
+			return err
+		}
+
+		query := `
+INSERT INTO grants (doc_id, user_id, permission)
+SELECT $1, id, $3 FROM users
+WHERE login = ANY($2::text[]) AND deleted_at IS NULL
+  AND id <> (SELECT owner_id FROM documents WHERE id = $1)
+ON CONFLICT (doc_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+`
+		_, err = tx.ExecContext(ctx, query, docID, pq.Array(logins), permission)
+		if err != nil {
+			s.log.Error("AddGrants", "failed to save grants", err)
+			return err
+		}
+	}
+
+	if len(groups) > 0 {
+		var unknown []string
+		// выдавать доступ можно только своим группам и тем, где login участник, как в Storage.groups
+		query := `
+SELECT gr.name
+FROM groups gr
+JOIN users o ON o.id = gr.owner_id
+WHERE gr.name = ANY($1::text[])
+  AND (o.login = $2
+       OR gr.id IN (SELECT gm.group_id
+                    FROM group_members gm
+                    JOIN users u ON u.id = gm.user_id
+                    WHERE u.login = $2))
+`
+		unknown, err = s.missing(ctx, tx, query, groups, login)
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			err = fmt.Errorf("%w: %s", ErrGrantGroupNotFound, strings.Join(unknown, ", "))
+			return err
+		}
+
+		query = `
+INSERT INTO group_grants (doc_id, group_id, permission)
+SELECT $1, id, $3 FROM groups
+WHERE name = ANY($2::text[])
+ON CONFLICT (doc_id, group_id) DO UPDATE SET permission = EXCLUDED.permission
+`
+		_, err = tx.ExecContext(ctx, query, docID, pq.Array(groups), permission)
+		if err != nil {
+			s.log.Error("AddGrants", "failed to save group grants", err)
+			return err
+		}
+	}
+
+	return s.changed(ctx, tx, models.ChangeGrant, docID)
+}
+
+// DeleteGrants - закрывает доступ к документу пользователям и группам
+// у лишенных доступа пользователей и участников групп тоже меняется счетчик и сбрасываются списки
+func (s *Storage) DeleteGrants(ctx context.Context, login string, docID uuid.UUID, logins, groups []string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("DeleteGrants", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("DeleteGrants", "commit failed", err)
+		}
+	}()
+
+	if err = s.requirePermission(ctx, tx, login, docID, models.PermissionManage); err != nil {
+		return err
+	}
+
+	affected := logins
+	if len(logins) > 0 {
+		query := `DELETE FROM grants WHERE doc_id = $1 AND user_id IN (SELECT id FROM users WHERE login = ANY($2::text[]))`
+		_, err = tx.ExecContext(ctx, query, docID, pq.Array(logins))
+		if err != nil {
+			s.log.Error("DeleteGrants", "failed to delete grants", err)
+			return err
+		}
+	}
+
+	if len(groups) > 0 {
+		var members []string
+		members, err = s.groupMembers(ctx, tx, groups)
+		if err != nil {
+			return err
+		}
+		affected = append(affected, members...)
+
+		query := `DELETE FROM group_grants WHERE doc_id = $1 AND group_id IN (SELECT id FROM groups WHERE name = ANY($2::text[]))`
+		_, err = tx.ExecContext(ctx, query, docID, pq.Array(groups))
+		if err != nil {
+			s.log.Error("DeleteGrants", "failed to delete group grants", err)
+			return err
+		}
+	}
+
+	return s.changed(ctx, tx, models.ChangeGrant, docID, affected...)
+}
+
+// missing - какие из имен не нашлись: query выбирает существующие из переданного массива, args идут после него
+func (s *Storage) missing(ctx context.Context, tx *sql.Tx, query string, names []string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, append([]any{pq.Array(names)}, args...)...)
+	if err != nil {
+		s.log.Error("missing", "failed to check names", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			s.log.Error("missing", "failed to scan name", err)
+			return nil, err
+		}
+		found = append(found, name)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("missing", "failed to read names", err)
+		return nil, err
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !slices.Contains(found, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown, nil
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// documentPermission - уровень доступа пользователя к документу: owner у владельца, иначе наибольший из прямых и групповых грантов
+// если документа нет или доступа к нему нет, ErrDocumentNotFound
+func (s *Storage) documentPermission(ctx context.Context, q querier, login string, docID uuid.UUID) (string, error) {
+	query := `
+SELECT CASE WHEN d.owner_id = u.id THEN 'owner' ELSE my.permission END
+FROM documents d
+JOIN users u ON u.login = $2
+LEFT JOIN LATERAL (
+    SELECT a.permission
+    FROM document_access a
+    WHERE a.doc_id = d.id AND a.user_id = u.id
+    ORDER BY a.rank DESC
+    LIMIT 1
+) my ON true
+WHERE d.id = $1 AND NOT d.is_deleted
+`
+	var permission sql.NullString
+	err := q.QueryRowContext(ctx, query, docID, login).Scan(&permission)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrDocumentNotFound
+		}
+		s.log.Error("documentPermission", "failed to get permission", err)
+		return "", err
+	}
+	if !permission.Valid {
+		return "", ErrDocumentNotFound
+	}
+	return permission.String, nil
+}
+
+// requirePermission - у пользователя есть доступ уровня want
+// без доступа документ неотличим от несуществующего, с недостаточным уровнем - ErrPermissionDenied
+func (s *Storage) requirePermission(ctx context.Context, q querier, login string, docID uuid.UUID, want string) error {
+	permission, err := s.documentPermission(ctx, q, login, docID)
+	if err != nil {
+		return err
+	}
+	if !models.PermissionAllows(permission, want) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestStorage_GetGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	docID := uuid.New()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.Grant
+		wantErr error
+	}{
+		{
+			name: "success_get_grants",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT u.login, NULL AS group_name, g.permission FROM grants g").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login", "group_name", "permission"}).
+						AddRow("alice", nil, "read").
+						AddRow("bob", nil, "manage").
+						AddRow(nil, "team", "write"))
+			},
+			want: []models.Grant{
+				{Login: "alice", Permission: "read"},
+				{Login: "bob", Permission: "manage"},
+				{Group: "team", Permission: "write"},
+			},
+		},
+		{
+			name: "success_get_grants_manager",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("manage"))
+				mock.ExpectQuery("SELECT u.login, NULL AS group_name, g.permission FROM grants g").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login", "group_name", "permission"}).AddRow("test", nil, "manage"))
+			},
+			want: []models.Grant{{Login: "test", Permission: "manage"}},
+		},
+		{
+			name: "error_permission_denied",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+			},
+			wantErr: ErrPermissionDenied,
+		},
+		{
+			name: "error_document_not_found",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name: "success_no_grants",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT u.login, NULL AS group_name, g.permission FROM grants g").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login", "group_name", "permission"}))
+			},
+			want: []models.Grant{},
+		},
+		{
+			name: "error_no_access",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(nil))
+			},
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name: "error_get_grants",
+			mock: func() {
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT u.login, NULL AS group_name, g.permission FROM grants g").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetGrants(context.Background(), "test", docID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetGrants() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_AddGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	docID := uuid.New()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		logins  []string
+		groups  []string
+		wantErr error
+	}{
+		{
+			name: "success_add_grants",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT login FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("alice").AddRow("bob"))
+				mock.ExpectExec("INSERT INTO grants").
+					WithArgs(docID, sqlmock.AnyArg(), models.PermissionWrite).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			logins: []string{"alice", "bob"},
+		},
+		{
+			name: "error_permission_denied",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice"},
+			wantErr: ErrPermissionDenied,
+		},
+		{
+			name: "error_no_access",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(nil))
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice"},
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name: "error_unknown_login",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT login FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("alice"))
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice", "ghost"},
+			wantErr: ErrGrantUserNotFound,
+		},
+		{
+			name: "success_add_group_grants",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("manage"))
+				mock.ExpectQuery("SELECT gr.name FROM groups gr").
+					WithArgs(sqlmock.AnyArg(), "test").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("team"))
+				mock.ExpectExec("INSERT INTO group_grants").
+					WithArgs(docID, sqlmock.AnyArg(), models.PermissionWrite).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			groups: []string{"team"},
+		},
+		{
+			name: "error_unknown_group",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT gr.name FROM groups gr").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}))
+				mock.ExpectRollback()
+			},
+			groups:  []string{"ghosts"},
+			wantErr: ErrGrantGroupNotFound,
+		},
+		{
+			name: "error_foreign_group",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery(`(?s)SELECT gr.name FROM groups gr.*o.login = \$2.*WHERE u.login = \$2`).
+					WithArgs(sqlmock.AnyArg(), "test").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("team"))
+				mock.ExpectRollback()
+			},
+			groups:  []string{"team", "strangers"},
+			wantErr: ErrGrantGroupNotFound,
+		},
+		{
+			name: "error_notify",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT login FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("alice"))
+				mock.ExpectExec("INSERT INTO grants").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice"},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.AddGrants(context.Background(), "test", docID, tt.logins, tt.groups, models.PermissionWrite)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	docID := uuid.New()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		groups  []string
+		wantErr error
+	}{
+		{
+			name: "success_delete_grants",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectExec("DELETE FROM grants").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				// лишенные доступа передаются явно: в grants их уже нет
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, "{\"alice\"}").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, changeEvent(models.ChangeGrant, docID.String(), "owner", "alice")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success_delete_group_grants",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectExec("DELETE FROM grants").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT DISTINCT u.login FROM group_members gm").
+					WithArgs("{\"team\"}").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("bob").AddRow("carol"))
+				mock.ExpectExec("DELETE FROM group_grants").
+					WithArgs(docID, "{\"team\"}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				// участники группы уже не видны через document_access, передаются явно
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, "{\"alice\",\"bob\",\"carol\"}").
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, changeEvent(models.ChangeGrant, docID.String(), "owner", "alice", "bob", "carol")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			groups: []string{"team"},
+		},
+		{
+			name: "error_no_access",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "test").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(nil))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name: "error_delete_grants",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectExec("DELETE FROM grants").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.DeleteGrants(context.Background(), "test", docID, []string{"alice"}, tt.groups)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// CreateGroup - создает группу, владелец сразу становится ее участником
+func (s *Storage) CreateGroup(ctx context.Context, login, name string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("CreateGroup", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("CreateGroup", "commit failed", err)
+		}
+	}()
+
+	query := `
+INSERT INTO groups (name, owner_id)
+SELECT $1, id FROM users WHERE login = $2 AND deleted_at IS NULL
+RETURNING id, owner_id
+`
+	var groupID, ownerID int64
+	err = tx.QueryRowContext(ctx, query, name, login).Scan(&groupID, &ownerID)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == codeUniqueViolation:
+			err = ErrGroupExists
+			return err
+		case errors.Is(err, sql.ErrNoRows):
+			err = ErrUserNotFound
+			return err
+		}
+		s.log.Error("CreateGroup", "failed to create group", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO group_members (group_id, user_id) VALUES ($1, $2)`, groupID, ownerID)
+	if err != nil {
+		s.log.Error("CreateGroup", "failed to add owner to group", err)
+		return err
+	}
+	return nil
+}
+
+// GetGroups - группы, которыми пользователь владеет или в которых состоит
+func (s *Storage) GetGroups(ctx context.Context, login string) ([]models.Group, error) {
+	return s.groups(ctx, login, "")
+}
+
+// GetGroup - группа по имени, если пользователь ей владеет или в ней состоит, иначе ErrGroupNotFound
+func (s *Storage) GetGroup(ctx context.Context, login, name string) (models.Group, error) {
+	groups, err := s.groups(ctx, login, name)
+	if err != nil {
+		return models.Group{}, err
+	}
+	if len(groups) == 0 {
+		return models.Group{}, ErrGroupNotFound
+	}
+	return groups[0], nil
+}
+
+// groups - группы пользователя, с непустым name - только одна
+func (s *Storage) groups(ctx context.Context, login, name string) ([]models.Group, error) {
+	query := `
+SELECT gr.name, o.login, gr.created_at,
+       ARRAY(SELECT mu.login
+             FROM group_members gm
+             JOIN users mu ON mu.id = gm.user_id
+             WHERE gm.group_id = gr.id AND mu.deleted_at IS NULL
+             ORDER BY mu.login) AS members
+FROM groups gr
+JOIN users o ON o.id = gr.owner_id
+WHERE ($2 = '' OR gr.name = $2)
+  AND (o.login = $1
+       OR gr.id IN (SELECT gm.group_id
+                    FROM group_members gm
+                    JOIN users u ON u.id = gm.user_id
+                    WHERE u.login = $1))
+ORDER BY gr.name
+`
+	rows, err := s.db.QueryContext(ctx, query, login, name)
+	if err != nil {
+		s.log.Error("groups", "failed to get groups", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.Name, &group.Owner, &group.CreatedAt, pq.Array(&group.Members)); err != nil {
+			s.log.Error("groups", "failed to scan group", err)
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("groups", "failed to read groups", err)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// AddGroupMembers - добавляет пользователей в группу, менять состав может только владелец
+// если хотя бы одного логина нет, ничего не меняется
+func (s *Storage) AddGroupMembers(ctx context.Context, login, name string, logins []string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("AddGroupMembers", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("AddGroupMembers", "commit failed", err)
+		}
+	}()
+
+	groupID, err := s.ownedGroup(ctx, tx, login, name)
+	if err != nil {
+		return err
+	}
+
+	unknown, err := s.missing(ctx, tx, `SELECT login FROM users WHERE login = ANY($1::text[]) AND deleted_at IS NULL`, logins)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		err = fmt.Errorf("%w: %s", ErrMemberNotFound, strings.Join(unknown, ", "))
+		return err
+	}
+
+	query := `
+INSERT INTO group_members (group_id, user_id)
+SELECT $1, id FROM users WHERE login = ANY($2::text[]) AND deleted_at IS NULL
+ON CONFLICT DO NOTHING
+`
+	_, err = tx.ExecContext(ctx, query, groupID, pq.Array(logins))
+	if err != nil {
+		s.log.Error("AddGroupMembers", "failed to add members", err)
+		return err
+	}
+
+	return s.membersChanged(ctx, tx, logins)
+}
+
+// DeleteGroupMembers - исключает пользователей из группы, менять состав может только владелец
+func (s *Storage) DeleteGroupMembers(ctx context.Context, login, name string, logins []string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("DeleteGroupMembers", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("DeleteGroupMembers", "commit failed", err)
+		}
+	}()
+
+	groupID, err := s.ownedGroup(ctx, tx, login, name)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM group_members WHERE group_id = $1 AND user_id IN (SELECT id FROM users WHERE login = ANY($2::text[]))`
+	_, err = tx.ExecContext(ctx, query, groupID, pq.Array(logins))
+	if err != nil {
+		s.log.Error("DeleteGroupMembers", "failed to delete members", err)
+		return err
+	}
+
+	return s.membersChanged(ctx, tx, logins)
+}
+
+// ownedGroup - id группы, которой владеет пользователь; чужая группа неотличима от несуществующей
+func (s *Storage) ownedGroup(ctx context.Context, tx *sql.Tx, login, name string) (int64, error) {
+	query := `
+SELECT gr.id
+FROM groups gr
+JOIN users u ON u.id = gr.owner_id
+WHERE gr.name = $1 AND u.login = $2
+`
+	var id int64
+	err := tx.QueryRowContext(ctx, query, name, login).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrGroupNotFound
+		}
+		s.log.Error("ownedGroup", "failed to get group", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// groupMembers - логины участников групп
+func (s *Storage) groupMembers(ctx context.Context, tx *sql.Tx, groups []string) ([]string, error) {
+	query := `
+SELECT DISTINCT u.login
+FROM group_members gm
+JOIN groups gr ON gr.id = gm.group_id
+JOIN users u ON u.id = gm.user_id
+WHERE gr.name = ANY($1::text[])
+`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(groups))
+	if err != nil {
+		s.log.Error("groupMembers", "failed to get members", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logins []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			s.log.Error("groupMembers", "failed to scan member", err)
+			return nil, err
+		}
+		logins = append(logins, login)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("groupMembers", "failed to read members", err)
+		return nil, err
+	}
+	return logins, nil
+}
+
+// membersChanged - состав группы изменился: у пользователей меняется набор видимых документов
+// счетчики увеличиваются, а уведомление без документа сбрасывает только их списки
+func (s *Storage) membersChanged(ctx context.Context, ex execer, logins []string) error {
+	_, err := ex.ExecContext(ctx, `UPDATE users SET docs_version = docs_version + 1 WHERE login = ANY($1::text[])`, pq.Array(logins))
+	if err != nil {
+		s.log.Error("membersChanged", "failed to bump docs version", err)
+		return err
+	}
+
+	return s.publish(ctx, ex, models.ChangeEvent{Kind: models.ChangeGroup, Logins: logins})
+}
@@ -0,0 +1,338 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestStorage_CreateGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_create_group",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO groups").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(7, 1))
+				mock.ExpectExec("INSERT INTO group_members").
+					WithArgs(int64(7), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_group_exists",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO groups").
+					WithArgs("team", "test").
+					WillReturnError(&pq.Error{Code: codeUniqueViolation})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrGroupExists,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO groups").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.CreateGroup(context.Background(), "test", "team")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	createdAt := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.Group
+		wantErr error
+	}{
+		{
+			name: "success_get_group",
+			mock: func() {
+				mock.ExpectQuery("SELECT gr.name, o.login, gr.created_at").
+					WithArgs("test", "team").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "login", "created_at", "members"}).
+						AddRow("team", "owner", createdAt, pq.Array([]string{"owner", "test"})))
+			},
+			want: models.Group{
+				Name:      "team",
+				Owner:     "owner",
+				Members:   []string{"owner", "test"},
+				CreatedAt: createdAt,
+			},
+		},
+		{
+			name: "error_group_not_found",
+			mock: func() {
+				mock.ExpectQuery("SELECT gr.name, o.login, gr.created_at").
+					WithArgs("test", "team").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "login", "created_at", "members"}))
+			},
+			wantErr: ErrGroupNotFound,
+		},
+		{
+			name: "error_get_group",
+			mock: func() {
+				mock.ExpectQuery("SELECT gr.name, o.login, gr.created_at").
+					WithArgs("test", "team").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetGroup(context.Background(), "test", "team")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetGroup() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetGroups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	mock.ExpectQuery("SELECT gr.name, o.login, gr.created_at").
+		WithArgs("test", "").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "login", "created_at", "members"}).
+			AddRow("team", "test", time.Now(), pq.Array([]string{"test"})).
+			AddRow("writers", "owner", time.Now(), pq.Array([]string{"owner", "test"})))
+
+	s := &Storage{
+		db:  db,
+		log: log,
+	}
+	got, err := s.GetGroups(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("GetGroups() error = %v", err)
+	}
+	if len(got) != 2 || got[1].Owner != "owner" || len(got[1].Members) != 2 {
+		t.Errorf("GetGroups() = %v, want 2 groups", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStorage_AddGroupMembers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		logins  []string
+		wantErr error
+	}{
+		{
+			name: "success_add_members",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectQuery("SELECT login FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("alice"))
+				mock.ExpectExec("INSERT INTO group_members").
+					WithArgs(int64(7), "{\"alice\"}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs("{\"alice\"}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, `{"kind":"group","doc_id":"","logins":["alice"]}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			logins: []string{"alice"},
+		},
+		{
+			name: "error_not_group_owner",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice"},
+			wantErr: ErrGroupNotFound,
+		},
+		{
+			name: "error_unknown_member",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectQuery("SELECT login FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("alice"))
+				mock.ExpectRollback()
+			},
+			logins:  []string{"alice", "ghost"},
+			wantErr: ErrMemberNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.AddGroupMembers(context.Background(), "test", "team", tt.logins)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddGroupMembers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteGroupMembers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_delete_members",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec("DELETE FROM group_members").
+					WithArgs(int64(7), "{\"alice\"}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs("{\"alice\"}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, `{"kind":"group","doc_id":"","logins":["alice"]}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error_not_group_owner",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrGroupNotFound,
+		},
+		{
+			name: "error_delete_members",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT gr.id FROM groups gr").
+					WithArgs("team", "test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec("DELETE FROM group_members").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.DeleteGroupMembers(context.Background(), "test", "team", []string{"alice"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteGroupMembers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
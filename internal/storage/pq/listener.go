@@ -0,0 +1,197 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ChannelChanges - канал NOTIFY с изменениями документов и грантов
+const ChannelChanges = "documents_changes"
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type queryExecer interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// notifyPayloadLimit - Postgres отклоняет NOTIFY с полезной нагрузкой от 8000 байт, а с ним и всю транзакцию
+const notifyPayloadLimit = 8000
+
+// notify - рассылает изменение документа всем экземплярам сервера
+// уведомление уходит только после коммита транзакции, в logins попадают владелец и пользователи с доступом напрямую или через группу
+func (s *Storage) notify(ctx context.Context, q queryExecer, kind string, docID uuid.UUID, logins ...string) error {
+	query := `
+SELECT u.login
+FROM users u
+JOIN documents d ON d.id = $1
+WHERE u.id = d.owner_id
+   OR u.id IN (SELECT a.user_id FROM document_access a WHERE a.doc_id = d.id)
+`
+	rows, err := q.QueryContext(ctx, query, docID)
+	if err != nil {
+		s.log.Error("notify", "failed to get affected logins", err)
+		return err
+	}
+	defer rows.Close()
+
+	var affected []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			s.log.Error("notify", "failed to scan login", err)
+			return err
+		}
+		affected = append(affected, login)
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("notify", "failed to read logins", err)
+		return err
+	}
+
+	return s.publish(ctx, q, models.ChangeEvent{
+		Kind:   kind,
+		DocID:  docID.String(),
+		Logins: append(affected, logins...),
+	})
+}
+
+// publish - отправляет уведомление в канал изменений
+func (s *Storage) publish(ctx context.Context, ex execer, ev models.ChangeEvent) error {
+	payload, err := changePayload(ev)
+	if err != nil {
+		s.log.Error("publish", "failed to encode notification", err)
+		return err
+	}
+
+	_, err = ex.ExecContext(ctx, `SELECT pg_notify($1, $2)`, ChannelChanges, payload)
+	if err != nil {
+		s.log.Error("publish", "failed to notify", err)
+		return err
+	}
+	return nil
+}
+
+// changePayload - тело уведомления; если логинов слишком много, они заменяются признаком ListsResync:
+// получатели сбросят все списки, зато запись не упадет из-за размера NOTIFY
+func changePayload(ev models.ChangeEvent) (string, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	if len(payload) < notifyPayloadLimit {
+		return string(payload), nil
+	}
+
+	ev.Logins = nil
+	ev.ListsResync = true
+	payload, err = json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// Listener - слушает уведомления об изменениях и раздает их подписчикам
+type Listener struct {
+	listener    *pq.Listener
+	log         *slog.Logger
+	mu          sync.RWMutex
+	subscribers []func(models.ChangeEvent)
+}
+
+// NewListener - конструктор
+func NewListener(log *slog.Logger) (*Listener, error) {
+	l := &Listener{
+		log: log,
+	}
+
+	l.listener = pq.NewListener(dsn, 10*time.Second, time.Minute, l.event)
+	if err := l.listener.Listen(ChannelChanges); err != nil {
+		log.Error("NewListener", "failed to listen", err)
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Subscribe - регистрирует обработчик изменений
+// обработчик вызывается из горутины слушателя и не должен блокироваться
+func (l *Listener) Subscribe(fn func(models.ChangeEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Run - читает уведомления, пока не отменен контекст
+func (l *Listener) Run(ctx context.Context) {
+	defer func() {
+		if err := l.listener.Close(); err != nil {
+			l.log.Error("Listener", "failed to close listener", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.listener.Notify:
+			l.handle(n)
+		case <-time.After(90 * time.Second):
+			// проверяем соединение, если уведомлений давно не было
+			go func() {
+				if err := l.listener.Ping(); err != nil {
+					l.log.Error("Listener", "ping failed", err)
+				}
+			}()
+		}
+	}
+}
+
+// handle - разбирает уведомление, nil приходит после переподключения
+func (l *Listener) handle(n *pq.Notification) {
+	if n == nil {
+		l.log.Info("Listener", "status", "reconnected, resync")
+		l.publish(models.ChangeEvent{Resync: true})
+		return
+	}
+
+	var ev models.ChangeEvent
+	if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil {
+		l.log.Error("Listener", "failed to decode notification", err)
+		return
+	}
+	l.publish(ev)
+}
+
+func (l *Listener) publish(ev models.ChangeEvent) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, fn := range l.subscribers {
+		fn(ev)
+	}
+}
+
+// event - логирует состояние соединения слушателя
+func (l *Listener) event(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventDisconnected:
+		l.log.Error("Listener", "disconnected", err)
+	case pq.ListenerEventReconnected:
+		l.log.Info("Listener", "status", "reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		l.log.Error("Listener", "connection attempt failed", err)
+	}
+}
@@ -0,0 +1,159 @@
+package pq
+
+import (
+	"caching_web_server/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// changeEvent - ожидаемое тело уведомления об изменении
+func changeEvent(kind, docID string, logins ...string) string {
+	payload, _ := json.Marshal(models.ChangeEvent{Kind: kind, DocID: docID, Logins: logins})
+	return string(payload)
+}
+
+func TestListener_handle(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		notification *pq.Notification
+		want         []models.ChangeEvent
+	}{
+		{
+			name: "success_document_event",
+			notification: &pq.Notification{
+				Channel: ChannelChanges,
+				Extra:   `{"kind":"document","doc_id":"id1","logins":["login1","login2"]}`,
+			},
+			want: []models.ChangeEvent{{
+				Kind:   models.ChangeDocument,
+				DocID:  "id1",
+				Logins: []string{"login1", "login2"},
+			}},
+		},
+		{
+			name: "success_lists_resync_event",
+			notification: &pq.Notification{
+				Channel: ChannelChanges,
+				Extra:   `{"kind":"grant","doc_id":"id1","logins":null,"lists_resync":true}`,
+			},
+			want: []models.ChangeEvent{{
+				Kind:        models.ChangeGrant,
+				DocID:       "id1",
+				ListsResync: true,
+			}},
+		},
+		{
+			name:         "resync_after_reconnect",
+			notification: nil,
+			want:         []models.ChangeEvent{{Resync: true}},
+		},
+		{
+			name: "error_invalid_payload",
+			notification: &pq.Notification{
+				Channel: ChannelChanges,
+				Extra:   `not json`,
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Listener{log: log}
+
+			var got []models.ChangeEvent
+			l.Subscribe(func(ev models.ChangeEvent) {
+				got = append(got, ev)
+			})
+
+			l.handle(tt.notification)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handle() events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListener_Subscribe(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l := &Listener{log: log}
+
+	var first, second int
+	l.Subscribe(func(models.ChangeEvent) { first++ })
+	l.Subscribe(func(models.ChangeEvent) { second++ })
+
+	l.publish(models.ChangeEvent{DocID: "id1"})
+	if first != 1 || second != 1 {
+		t.Errorf("publish() calls = %d, %d, want 1, 1", first, second)
+	}
+}
+
+func TestChangePayload(t *testing.T) {
+	small := models.ChangeEvent{Kind: models.ChangeDocument, DocID: "id1", Logins: []string{"login1"}}
+	got, err := changePayload(small)
+	if err != nil {
+		t.Fatalf("changePayload() error = %v", err)
+	}
+	if got != changeEvent(models.ChangeDocument, "id1", "login1") {
+		t.Errorf("changePayload() = %v", got)
+	}
+
+	// документ открыт группе из тысяч участников: логины в уведомление не помещаются
+	large := models.ChangeEvent{Kind: models.ChangeDocument, DocID: "id1"}
+	for i := 0; i < 2000; i++ {
+		large.Logins = append(large.Logins, fmt.Sprintf("member%04d", i))
+	}
+	got, err = changePayload(large)
+	if err != nil {
+		t.Fatalf("changePayload() error = %v", err)
+	}
+	if len(got) >= notifyPayloadLimit {
+		t.Errorf("changePayload() size = %d, want < %d", len(got), notifyPayloadLimit)
+	}
+	var ev models.ChangeEvent
+	if err := json.Unmarshal([]byte(got), &ev); err != nil {
+		t.Fatalf("changePayload() invalid json: %v", err)
+	}
+	if !ev.ListsResync || ev.DocID != "id1" || len(ev.Logins) != 0 {
+		t.Errorf("changePayload() = %+v, want lists resync for id1", ev)
+	}
+}
+
+func TestStorage_notifyLargeGranteeSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	docID := uuid.New()
+
+	rows := sqlmock.NewRows([]string{"login"})
+	for i := 0; i < 2000; i++ {
+		rows.AddRow(fmt.Sprintf("member%04d", i))
+	}
+	mock.ExpectQuery("SELECT u.login FROM users u").
+		WithArgs(docID).
+		WillReturnRows(rows)
+	mock.ExpectExec("SELECT pg_notify").
+		WithArgs(ChannelChanges, fmt.Sprintf(`{"kind":"document","doc_id":"%s","logins":null,"lists_resync":true}`, docID)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := &Storage{db: db, log: log}
+	if err := s.notify(context.Background(), db, models.ChangeDocument, docID); err != nil {
+		t.Errorf("notify() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -56,7 +56,7 @@ func TestStorage_SaveUser(t *testing.T) {
 			passwordHash: "test",
 			mockUp: func() {
 				mock.ExpectExec("INSERT INTO users").
-					WithArgs("test", "test").
+					WithArgs("test", "test", "user").
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			wantErr: nil,
@@ -79,7 +79,7 @@ func TestStorage_SaveUser(t *testing.T) {
 				log: log,
 			}
 			tt.mockUp()
-			err := s.SaveUser(ctx, tt.login, tt.passwordHash)
+			err := s.SaveUser(ctx, tt.login, tt.passwordHash, "user")
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("SaveUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -141,6 +141,54 @@ func TestStorage_GetHashPass(t *testing.T) {
 	}
 }
 
+func TestStorage_UpdatePasswordHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mockUp  func()
+		wantErr error
+	}{
+		{
+			name: "success_update_password_hash",
+			mockUp: func() {
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_update_password_hash",
+			mockUp: func() {
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs("test", "hash").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockUp()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.UpdatePasswordHash(context.Background(), "test", "hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdatePasswordHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestStorage_SaveDocument(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -170,11 +218,21 @@ func TestStorage_SaveDocument(t *testing.T) {
 						true,             // HashFile
 						true,             // Public
 						[]byte{},         // JsonData
-						"path").          // StoragePath).
+						"path",           // StoragePath
+						"hash").          // ContentHash
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID))
 				mock.ExpectExec("INSERT INTO grants").
 					WithArgs(docID, "login2").
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
 			doc: models.Document{
@@ -185,6 +243,7 @@ func TestStorage_SaveDocument(t *testing.T) {
 				HashFile:    true,
 				JsonDate:    []byte{},
 				StoragePath: "path",
+				ContentHash: "hash",
 			},
 			grants:  []string{"login2"},
 			wantErr: nil,
@@ -230,6 +289,72 @@ func TestStorage_SaveDocument(t *testing.T) {
 			grants:  []string{"login2"},
 			wantErr: errStorage,
 		},
+		{
+			name: "error_unknown_grant_login",
+			mockUp: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO documents").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID))
+				mock.ExpectExec("INSERT INTO grants").
+					WithArgs(docID, "login2").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO grants").
+					WithArgs(docID, "unknown").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			doc: models.Document{
+				OwnerID:     1,
+				Name:        "name",
+				Mime:        "mime",
+				JsonDate:    []byte{},
+				StoragePath: "path",
+			},
+			grants:  []string{"login2", "unknown"},
+			wantErr: ErrGrantUserNotFound,
+		},
+		{
+			name: "error_bump_version",
+			mockUp: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO documents").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			doc: models.Document{
+				OwnerID:     1,
+				Name:        "name",
+				Mime:        "mime",
+				JsonDate:    []byte{},
+				StoragePath: "path",
+			},
+			wantErr: errStorage,
+		},
+		{
+			name: "error_notify",
+			mockUp: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO documents").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(docID))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			doc: models.Document{
+				OwnerID:     1,
+				Name:        "name",
+				Mime:        "mime",
+				JsonDate:    []byte{},
+				StoragePath: "path",
+			},
+			wantErr: errStorage,
+		},
 	}
 
 	for _, tt := range tests {
@@ -310,6 +435,7 @@ func TestStorage_GetDocuments(t *testing.T) {
 		name        string
 		mock        func()
 		login       string
+		scope       string
 		filterKey   string
 		filterValue string
 		limit       int
@@ -321,20 +447,21 @@ func TestStorage_GetDocuments(t *testing.T) {
 			mock: func() {
 				mockRows := sqlmock.NewRows([]string{
 					"id", "name", "mime", "hash_file", "public",
-					"create_at", "grants",
+					"create_at", "owner", "permission", "grants", "group_grants",
 				}).AddRow(
 					"uuid1", "doc1", "mime", true, true,
-					time.Now(), pq.Array([]string{"login2", "login3"}),
+					time.Now(), "login1", "owner", pq.Array([]string{"login2", "login3"}), pq.Array([]string{"team"}),
 				).AddRow(
 					"uuid2", "doc2", "mime2", false, true,
-					time.Now(), pq.Array([]string{}),
+					time.Now(), "login1", "owner", pq.Array([]string{}), pq.Array([]string{"team"}),
 				)
 
-				mock.ExpectQuery("WITH owner_id AS").
+				mock.ExpectQuery(`WITH me AS (.+) WHERE d.is_deleted = false AND d.owner_id = me.id`).
 					WithArgs("login1", 10).
 					WillReturnRows(mockRows)
 			},
 			login:       "login1",
+			scope:       models.ListScopeOwned,
 			filterKey:   "",
 			filterValue: "",
 			limit:       10,
@@ -346,17 +473,18 @@ func TestStorage_GetDocuments(t *testing.T) {
 			mock: func() {
 				mockRows := sqlmock.NewRows([]string{
 					"id", "name", "mime", "hash_file", "public",
-					"create_at", "grants",
+					"create_at", "owner", "permission", "grants", "group_grants",
 				}).AddRow(
 					"uuid3", "doc3", "mime3", true, false,
-					time.Now(), pq.Array([]string{"login4"}),
+					time.Now(), "login1", "owner", pq.Array([]string{"login4"}), pq.Array([]string{"team"}),
 				)
 
-				mock.ExpectQuery("WITH owner_id AS").
+				mock.ExpectQuery(`WITH me AS (.+) AND d.name = \$2`).
 					WithArgs("login1", "doc3", 5).
 					WillReturnRows(mockRows)
 			},
 			login:       "login1",
+			scope:       models.ListScopeOwned,
 			filterKey:   "name",
 			filterValue: "doc3",
 			limit:       5,
@@ -366,17 +494,80 @@ func TestStorage_GetDocuments(t *testing.T) {
 		{
 			name: "error_get_documents",
 			mock: func() {
-				mock.ExpectQuery("WITH owner_id AS").
+				mock.ExpectQuery("WITH me AS").
 					WithArgs("login1", "doc3", 5).
 					WillReturnError(errStorage)
 			},
 			login:       "login1",
+			scope:       models.ListScopeOwned,
 			filterKey:   "name",
 			filterValue: "doc3",
 			limit:       5,
 			wantErr:     errStorage,
 			wantDocs:    0,
 		},
+		{
+			name: "success_get_shared_documents",
+			mock: func() {
+				mockRows := sqlmock.NewRows([]string{
+					"id", "name", "mime", "hash_file", "public",
+					"create_at", "owner", "permission", "grants", "group_grants",
+				}).AddRow(
+					"uuid4", "doc4", "mime", true, false,
+					time.Now(), "login2", "write", pq.Array([]string{}), pq.Array([]string{"team"}),
+				)
+
+				mock.ExpectQuery(`WITH me AS (.+) WHERE d.is_deleted = false AND my.permission IS NOT NULL AND d.owner_id <> me.id`).
+					WithArgs("login1", 10).
+					WillReturnRows(mockRows)
+			},
+			login:    "login1",
+			scope:    models.ListScopeShared,
+			limit:    10,
+			wantDocs: 1,
+		},
+		{
+			name: "success_get_all_documents",
+			mock: func() {
+				mock.ExpectQuery(`WITH me AS (.+) \(d.owner_id = me.id OR my.permission IS NOT NULL\)`).
+					WithArgs("login1", 10).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "name", "mime", "hash_file", "public",
+						"create_at", "owner", "permission", "grants", "group_grants",
+					}))
+			},
+			login:    "login1",
+			scope:    models.ListScopeAll,
+			limit:    10,
+			wantDocs: 0,
+		},
+		{
+			name: "success_get_public_documents",
+			mock: func() {
+				mock.ExpectQuery(`WITH me AS (.+) WHERE d.is_deleted = false AND d.public`).
+					WithArgs("login1", 10).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "name", "mime", "hash_file", "public",
+						"create_at", "owner", "permission", "grants", "group_grants",
+					}).AddRow(
+						"uuid5", "doc5", "mime", true, true,
+						time.Now(), "login3", "read", pq.Array([]string{}), pq.Array([]string{"team"}),
+					))
+			},
+			login:    "login1",
+			scope:    models.ListScopePublic,
+			limit:    10,
+			wantDocs: 1,
+		},
+		{
+			name:     "error_unknown_scope",
+			mock:     func() {},
+			login:    "login1",
+			scope:    "everything",
+			limit:    10,
+			wantErr:  ErrListScope,
+			wantDocs: 0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -387,7 +578,7 @@ func TestStorage_GetDocuments(t *testing.T) {
 				db:  db,
 				log: log,
 			}
-			docs, err := s.GetDocuments(ctx, tt.login, tt.filterKey, tt.filterValue, tt.limit)
+			docs, err := s.GetDocuments(ctx, tt.login, tt.scope, tt.filterKey, tt.filterValue, tt.limit)
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("GetDocuments() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -424,30 +615,52 @@ func TestStorage_GetDocumentByID(t *testing.T) {
 		docID   uuid.UUID
 		login   string
 		wantNil bool
-		wantErr error
+		// permission - ожидаемый уровень доступа запросившего
+		permission string
+		wantErr    error
 	}{
 		{
 			name: "success_get_document_by_id",
 			mock: func() {
 				mockRows := sqlmock.NewRows([]string{
 					"id", "owner_id", "name", "mime", "hash_file", "public",
-					"json_data", "storage_path", "create_at", "is_deleted",
+					"json_data", "storage_path", "content_hash", "create_at", "updated_at", "is_deleted", "permission",
 				}).AddRow(
-					"uuid1", int64(1), "doc1", "mime", true, true,
-					[]byte{}, "path1", time.Now(), false)
-				mock.ExpectQuery("WITH owner_id AS").
+					"uuid1", int64(1), "doc1", "mime", true, false,
+					[]byte{}, "path1", "hash1", time.Now(), time.Now(), false, "write")
+				mock.ExpectQuery("FROM document_access a").
 					WithArgs(docID, "login1").
 					WillReturnRows(mockRows)
 			},
-			docID:   docID,
-			login:   "login1",
-			wantNil: false,
-			wantErr: nil,
+			docID:      docID,
+			login:      "login1",
+			wantNil:    false,
+			permission: models.PermissionWrite,
+			wantErr:    nil,
+		},
+		{
+			name: "success_get_public_document_anonymous",
+			mock: func() {
+				mockRows := sqlmock.NewRows([]string{
+					"id", "owner_id", "name", "mime", "hash_file", "public",
+					"json_data", "storage_path", "content_hash", "create_at", "updated_at", "is_deleted", "permission",
+				}).AddRow(
+					"uuid1", int64(1), "doc1", "mime", true, true,
+					[]byte{}, "path1", "hash1", time.Now(), time.Now(), false, "read")
+				mock.ExpectQuery(`d.public\s+OR d.owner_id`).
+					WithArgs(docID, "").
+					WillReturnRows(mockRows)
+			},
+			docID:      docID,
+			login:      "",
+			wantNil:    false,
+			permission: models.PermissionRead,
+			wantErr:    nil,
 		},
 		{
 			name: "error_get_document_by_id",
 			mock: func() {
-				mock.ExpectQuery("WITH owner_id AS").
+				mock.ExpectQuery("LEFT JOIN users u ON u.login").
 					WithArgs(docID, "login1").
 					WillReturnError(errStorage)
 			},
@@ -473,6 +686,9 @@ func TestStorage_GetDocumentByID(t *testing.T) {
 			if (doc == nil) != tt.wantNil {
 				t.Errorf("GetDocumentByID() doc = %+v, expected nil=%v", doc, tt.wantNil)
 			}
+			if doc != nil && doc.Permission != tt.permission {
+				t.Errorf("GetDocumentByID() permission = %q, want %q", doc.Permission, tt.permission)
+			}
 		})
 	}
 }
@@ -496,9 +712,20 @@ func TestStorage_DeleteDocument(t *testing.T) {
 			name:  "success_delete_document",
 			login: "login1",
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE documents").
 					WithArgs(docID, "login1").
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
 			docID:   docID,
 			wantErr: nil,
@@ -507,9 +734,11 @@ func TestStorage_DeleteDocument(t *testing.T) {
 			name:  "error_document_not_found",
 			login: "login1",
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE documents").
 					WithArgs(docID, "login1").
 					WillReturnResult(sqlmock.NewResult(1, 0))
+				mock.ExpectRollback()
 			},
 			docID:   docID,
 			wantErr: ErrDocumentNotFound,
@@ -518,9 +747,11 @@ func TestStorage_DeleteDocument(t *testing.T) {
 			name:  "error_delete_document",
 			login: "login1",
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE documents").
 					WithArgs(docID, "login1").
 					WillReturnError(errStorage)
+				mock.ExpectRollback()
 			},
 			docID:   docID,
 			wantErr: errStorage,
@@ -539,6 +770,301 @@ func TestStorage_DeleteDocument(t *testing.T) {
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("DeleteDocument() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_UpdateDocument(t *testing.T) {
+	docID := uuid.New()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	version := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		path    string
+		wantErr error
+	}{
+		{
+			name: "success_replace_content",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+				mock.ExpectQuery("SELECT storage_path, updated_at, public FROM documents").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path", "updated_at", "public"}).AddRow("old.txt", version, false))
+				mock.ExpectExec("UPDATE documents SET name").
+					WithArgs(docID, "doc1", "text/plain", true, false, []byte(nil), "new.txt", "hash2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				// старый файл удалит фоновая очистка
+				mock.ExpectExec("INSERT INTO storage_cleanup").
+					WithArgs("old.txt").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			path: "new.txt",
+		},
+		{
+			name: "success_update_metadata",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("owner"))
+				mock.ExpectQuery("SELECT storage_path, updated_at, public FROM documents").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path", "updated_at", "public"}).AddRow("old.txt", version, false))
+				mock.ExpectExec("UPDATE documents SET name").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET docs_version").
+					WithArgs(docID, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT u.login FROM users u").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow("owner"))
+				mock.ExpectExec("SELECT pg_notify").
+					WithArgs(ChannelChanges, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			path: "old.txt",
+		},
+		{
+			name: "error_permission_denied",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("read"))
+				mock.ExpectRollback()
+			},
+			path:    "new.txt",
+			wantErr: ErrPermissionDenied,
+		},
+		{
+			name: "error_document_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}))
+				mock.ExpectRollback()
+			},
+			path:    "new.txt",
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name: "error_write_grantee_changes_public",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+				mock.ExpectQuery("SELECT storage_path, updated_at, public FROM documents").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path", "updated_at", "public"}).AddRow("old.txt", version, true))
+				mock.ExpectRollback()
+			},
+			path:    "old.txt",
+			wantErr: ErrPermissionDenied,
+		},
+		{
+			name: "error_document_conflict",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+				mock.ExpectQuery("SELECT storage_path, updated_at, public FROM documents").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path", "updated_at", "public"}).AddRow("old.txt", version.Add(time.Second), false))
+				mock.ExpectRollback()
+			},
+			path:    "new.txt",
+			wantErr: ErrDocumentConflict,
+		},
+		{
+			name: "error_update_document",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT CASE WHEN d.owner_id").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow("write"))
+				mock.ExpectQuery("SELECT storage_path, updated_at, public FROM documents").
+					WithArgs(docID).
+					WillReturnRows(sqlmock.NewRows([]string{"storage_path", "updated_at", "public"}).AddRow("old.txt", version, false))
+				mock.ExpectExec("UPDATE documents SET name").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			path:    "new.txt",
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			doc := &models.Document{
+				ID:          docID.String(),
+				Name:        "doc1",
+				Mime:        "text/plain",
+				HashFile:    true,
+				StoragePath: tt.path,
+				ContentHash: "hash2",
+			}
+			err := s.UpdateDocument(context.Background(), "login1", doc, version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	if err := (&Storage{db: db, log: log}).UpdateDocument(context.Background(), "login1", &models.Document{ID: "1"}, version); err == nil {
+		t.Errorf("UpdateDocument() must reject invalid document id")
+	}
+}
+
+func TestStorage_HasAccess(t *testing.T) {
+	docID := uuid.New()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "success_has_access",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name: "success_no_access",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(docID, "login1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name: "error_has_access",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(docID, "login1").
+					WillReturnError(errStorage)
+			},
+			want:    false,
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.HasAccess(ctx, docID, "login1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("HasAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("HasAccess() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorage_GetDocsVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "success_get_docs_version",
+			mock: func() {
+				mock.ExpectQuery("SELECT docs_version").
+					WithArgs("login1").
+					WillReturnRows(sqlmock.NewRows([]string{"docs_version"}).AddRow(int64(7)))
+			},
+			want:    7,
+			wantErr: nil,
+		},
+		{
+			name: "error_get_docs_version",
+			mock: func() {
+				mock.ExpectQuery("SELECT docs_version").
+					WithArgs("login1").
+					WillReturnError(errStorage)
+			},
+			want:    0,
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetDocsVersion(ctx, "login1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetDocsVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetDocsVersion() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -0,0 +1,217 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokeToken - сохраняет отозванный токен до окончания его срока действия
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+	_, err := s.db.ExecContext(ctx, query, jti, expiresAt)
+	if err != nil {
+		s.log.Error("RevokeToken", "failed to revoke token", err)
+		return err
+	}
+	return nil
+}
+
+// CutOffTokens - отзывает все access-токены пользователя, выданные до этого момента
+// нужен для токенов без jti: отозвать такой токен по отдельности нельзя
+func (s *Storage) CutOffTokens(ctx context.Context, login string) (time.Time, error) {
+	query := `
+UPDATE users SET tokens_valid_after = now()
+WHERE login = $1 AND deleted_at IS NULL
+RETURNING tokens_valid_after
+`
+	var cutoff time.Time
+	err := s.db.QueryRowContext(ctx, query, login).Scan(&cutoff)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrUserNotFound
+		}
+		s.log.Error("CutOffTokens", "failed to cut off tokens", err)
+		return time.Time{}, err
+	}
+	return cutoff, nil
+}
+
+// GetRevokedTokens - возвращает отозванные токены, срок которых еще не истек
+func (s *Storage) GetRevokedTokens(ctx context.Context) (map[string]time.Time, error) {
+	query := `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > now()`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		s.log.Error("GetRevokedTokens", "failed to get revoked tokens", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			jti       string
+			expiresAt time.Time
+		)
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			s.log.Error("GetRevokedTokens", "failed to scan revoked token", err)
+			return nil, err
+		}
+		revoked[jti] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		s.log.Error("GetRevokedTokens", "failed to read revoked tokens", err)
+		return nil, err
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredRevocations - удаляет записи об отзыве токенов, которые истекли сами
+func (s *Storage) DeleteExpiredRevocations(ctx context.Context) (int64, error) {
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= now()`
+	res, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		s.log.Error("DeleteExpiredRevocations", "failed to delete expired revocations", err)
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("DeleteExpiredRevocations", "failed to get rows affected", err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// SaveRefreshToken - сохраняет хеш refresh-токена, family объединяет все токены одной сессии
+func (s *Storage) SaveRefreshToken(ctx context.Context, login, tokenHash string, family uuid.UUID, expiresAt time.Time) error {
+	query := `
+INSERT INTO refresh_tokens (token_hash, family_id, user_id, expires_at)
+SELECT $1, $2, id, $4 FROM users WHERE login = $3 AND deleted_at IS NULL
+`
+	res, err := s.db.ExecContext(ctx, query, tokenHash, family, login, expiresAt)
+	if err != nil {
+		s.log.Error("SaveRefreshToken", "failed to save refresh token", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("SaveRefreshToken", "failed to get rows affected", err)
+		return err
+	}
+	if n == 0 {
+		s.log.Error("SaveRefreshToken", "user not found", login)
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RotateRefreshToken - гасит использованный refresh-токен и выдает следующий в той же семье
+// повторное предъявление уже использованного токена отзывает всю семью
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (login string, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.log.Error("RotateRefreshToken", "failed to begin tx", err)
+		return "", err
+	}
+	// при повторе семью отзываем и фиксируем, хотя возвращаем ошибку
+	reused := false
+	defer func() {
+		if err != nil && !reused {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := `
+SELECT r.family_id, r.user_id, u.login, r.expires_at, r.used_at IS NOT NULL OR r.revoked
+FROM refresh_tokens r
+JOIN users u ON u.id = r.user_id
+WHERE r.token_hash = $1
+FOR UPDATE OF r
+`
+	var (
+		family         uuid.UUID
+		userID         int64
+		tokenExpiresAt time.Time
+		spent          bool
+	)
+	err = tx.QueryRowContext(ctx, query, oldHash).Scan(&family, &userID, &login, &tokenExpiresAt, &spent)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrRefreshTokenNotFound
+		}
+		s.log.Error("RotateRefreshToken", "failed to get refresh token", err)
+		return "", err
+	}
+
+	if spent {
+		reused = true
+		_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = true WHERE family_id = $1`, family)
+		if err != nil {
+			reused = false
+			s.log.Error("RotateRefreshToken", "failed to revoke token family", err)
+			return "", err
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("RotateRefreshToken", "failed to commit tx", err)
+			return "", err
+		}
+		return "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(tokenExpiresAt) {
+		return "", ErrRefreshTokenExpired
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE token_hash = $1`, oldHash)
+	if err != nil {
+		s.log.Error("RotateRefreshToken", "failed to mark refresh token used", err)
+		return "", err
+	}
+
+	query = `
+INSERT INTO refresh_tokens (token_hash, family_id, user_id, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+	_, err = tx.ExecContext(ctx, query, newHash, family, userID, expiresAt)
+	if err != nil {
+		s.log.Error("RotateRefreshToken", "failed to save refresh token", err)
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		s.log.Error("RotateRefreshToken", "failed to commit tx", err)
+		return "", err
+	}
+
+	return login, nil
+}
+
+// RevokeRefreshFamily - отзывает семью refresh-токена при выходе, чужой токен не найдется
+func (s *Storage) RevokeRefreshFamily(ctx context.Context, login, tokenHash string) error {
+	query := `
+UPDATE refresh_tokens SET revoked = true
+WHERE family_id = (
+	SELECT r.family_id FROM refresh_tokens r
+	JOIN users u ON u.id = r.user_id
+	WHERE r.token_hash = $1 AND u.login = $2
+)
+`
+	res, err := s.db.ExecContext(ctx, query, tokenHash, login)
+	if err != nil {
+		s.log.Error("RevokeRefreshFamily", "failed to revoke token family", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("RevokeRefreshFamily", "failed to get rows affected", err)
+		return err
+	}
+	if n == 0 {
+		return ErrRefreshTokenNotFound
+	}
+	return nil
+}
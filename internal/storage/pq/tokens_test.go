@@ -0,0 +1,461 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestStorage_RevokeToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_revoke_token",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO revoked_tokens").
+					WithArgs("jti1", expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_revoke_token",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO revoked_tokens").
+					WithArgs("jti1", expiresAt).
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.RevokeToken(context.Background(), "jti1", expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RevokeToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_GetRevokedTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "success_get_revoked_tokens",
+			mock: func() {
+				mock.ExpectQuery("SELECT jti, expires_at FROM revoked_tokens").
+					WillReturnRows(sqlmock.NewRows([]string{"jti", "expires_at"}).
+						AddRow("jti1", expiresAt).
+						AddRow("jti2", expiresAt))
+			},
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "error_get_revoked_tokens",
+			mock: func() {
+				mock.ExpectQuery("SELECT jti, expires_at FROM revoked_tokens").
+					WillReturnError(errStorage)
+			},
+			want:    0,
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetRevokedTokens(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetRevokedTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("GetRevokedTokens() got %d tokens, want %d", len(got), tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteExpiredRevocations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "success_delete_expired",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM revoked_tokens").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			want:    3,
+			wantErr: nil,
+		},
+		{
+			name: "error_delete_expired",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM revoked_tokens").
+					WillReturnError(errStorage)
+			},
+			want:    0,
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.DeleteExpiredRevocations(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteExpiredRevocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DeleteExpiredRevocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorage_SaveRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	family := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_save_refresh_token",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs("hash1", family, "login1", expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs("hash1", family, "login1", expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "error_save_refresh_token",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.SaveRefreshToken(context.Background(), "login1", "hash1", family, expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SaveRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorage_RotateRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	family := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	tokenRow := func(exp time.Time, spent bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"family_id", "user_id", "login", "expires_at", "spent"}).
+			AddRow(family, int64(1), "login1", exp, spent)
+	}
+
+	tests := []struct {
+		name      string
+		mock      func()
+		wantLogin string
+		wantErr   error
+	}{
+		{
+			name: "success_rotate",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT r.family_id").
+					WithArgs("old").
+					WillReturnRows(tokenRow(expiresAt, false))
+				mock.ExpectExec("UPDATE refresh_tokens SET used_at").
+					WithArgs("old").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs("new", family, int64(1), expiresAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantLogin: "login1",
+			wantErr:   nil,
+		},
+		{
+			name: "error_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT r.family_id").
+					WithArgs("old").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrRefreshTokenNotFound,
+		},
+		{
+			name: "error_expired",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT r.family_id").
+					WithArgs("old").
+					WillReturnRows(tokenRow(time.Now().Add(-time.Minute), false))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrRefreshTokenExpired,
+		},
+		{
+			name: "error_reused_revokes_family",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT r.family_id").
+					WithArgs("old").
+					WillReturnRows(tokenRow(expiresAt, true))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WithArgs(family).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "error_save_next",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT r.family_id").
+					WithArgs("old").
+					WillReturnRows(tokenRow(expiresAt, false))
+				mock.ExpectExec("UPDATE refresh_tokens SET used_at").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			login, err := s.RotateRefreshToken(context.Background(), "old", "new", expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RotateRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if login != tt.wantLogin {
+				t.Errorf("RotateRefreshToken() login = %v, want %v", login, tt.wantLogin)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_RevokeRefreshFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_revoke_family",
+			mock: func() {
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WithArgs("hash1", "login1").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_token_not_found",
+			mock: func() {
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WithArgs("hash1", "login1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrRefreshTokenNotFound,
+		},
+		{
+			name: "error_revoke_family",
+			mock: func() {
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = true").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.RevokeRefreshFamily(context.Background(), "login1", "hash1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RevokeRefreshFamily() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_CutOffTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+	cutoff := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_cut_off",
+			mock: func() {
+				mock.ExpectQuery("UPDATE users SET tokens_valid_after").
+					WithArgs("login1").
+					WillReturnRows(sqlmock.NewRows([]string{"tokens_valid_after"}).AddRow(cutoff))
+			},
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectQuery("UPDATE users SET tokens_valid_after").
+					WithArgs("login1").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_cut_off",
+			mock: func() {
+				mock.ExpectQuery("UPDATE users SET tokens_valid_after").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.CutOffTokens(context.Background(), "login1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CutOffTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(cutoff) {
+				t.Errorf("CutOffTokens() = %v, want %v", got, cutoff)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
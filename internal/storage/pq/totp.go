@@ -0,0 +1,172 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetTOTP - секрет TOTP пользователя и включена ли двухфакторная аутентификация
+// пустой секрет - пользователь не начинал подключение
+func (s *Storage) GetTOTP(ctx context.Context, login string) (string, bool, error) {
+	query := `SELECT coalesce(totp_secret, ''), totp_enabled FROM users WHERE login = $1 AND deleted_at IS NULL`
+	var (
+		secret  string
+		enabled bool
+	)
+	err := s.db.QueryRowContext(ctx, query, login).Scan(&secret, &enabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, ErrUserNotFound
+		}
+		s.log.Error("GetTOTP", "failed to get totp", err)
+		return "", false, err
+	}
+	return secret, enabled, nil
+}
+
+// SetTOTPSecret - сохраняет секрет, который еще надо подтвердить кодом
+// включенную двухфакторную аутентификацию не трогает
+func (s *Storage) SetTOTPSecret(ctx context.Context, login, secret string) error {
+	query := `UPDATE users SET totp_secret = $2, totp_last_counter = NULL
+		WHERE login = $1 AND deleted_at IS NULL AND NOT totp_enabled`
+	res, err := s.db.ExecContext(ctx, query, login, secret)
+	if err != nil {
+		s.log.Error("SetTOTPSecret", "failed to set totp secret", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("SetTOTPSecret", "failed to get rows affected", err)
+		return err
+	}
+	if n == 0 {
+		return ErrTOTPEnabled
+	}
+	return nil
+}
+
+// EnableTOTP - включает двухфакторную аутентификацию и заменяет коды восстановления
+func (s *Storage) EnableTOTP(ctx context.Context, login string, counter int64, codeHashes []string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("EnableTOTP", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("EnableTOTP", "commit failed", err)
+		}
+	}()
+
+	query := `UPDATE users SET totp_enabled = true, totp_last_counter = $2
+		WHERE login = $1 AND deleted_at IS NULL AND totp_secret IS NOT NULL AND NOT totp_enabled
+		RETURNING id`
+	var userID int64
+	err = tx.QueryRowContext(ctx, query, login, counter).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTOTPEnabled
+		}
+		s.log.Error("EnableTOTP", "failed to enable totp", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		s.log.Error("EnableTOTP", "failed to delete recovery codes", err)
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			s.log.Error("EnableTOTP", "failed to save recovery code", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseTOTPCounter - запоминает шаг последнего принятого кода, повторно и более старые коды не принимаются
+func (s *Storage) UseTOTPCounter(ctx context.Context, login string, counter int64) (bool, error) {
+	query := `UPDATE users SET totp_last_counter = $2
+		WHERE login = $1 AND deleted_at IS NULL AND (totp_last_counter IS NULL OR totp_last_counter < $2)`
+	res, err := s.db.ExecContext(ctx, query, login, counter)
+	if err != nil {
+		s.log.Error("UseTOTPCounter", "failed to use totp counter", err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("UseTOTPCounter", "failed to get rows affected", err)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// UseRecoveryCode - гасит неиспользованный код восстановления
+func (s *Storage) UseRecoveryCode(ctx context.Context, login, codeHash string) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = now()
+		WHERE user_id = (SELECT id FROM users WHERE login = $1 AND deleted_at IS NULL)
+		  AND code_hash = $2 AND used_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, login, codeHash)
+	if err != nil {
+		s.log.Error("UseRecoveryCode", "failed to use recovery code", err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("UseRecoveryCode", "failed to get rows affected", err)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ResetTOTP - выключает двухфакторную аутентификацию и удаляет коды восстановления
+func (s *Storage) ResetTOTP(ctx context.Context, login string) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.log.Error("ResetTOTP", "rollback failed", rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			s.log.Error("ResetTOTP", "commit failed", err)
+		}
+	}()
+
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_counter = NULL
+		WHERE login = $1 AND deleted_at IS NULL
+		RETURNING id`
+	var userID int64
+	err = tx.QueryRowContext(ctx, query, login).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		s.log.Error("ResetTOTP", "failed to reset totp", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		s.log.Error("ResetTOTP", "failed to delete recovery codes", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestStorage_GetTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name        string
+		mock        func()
+		wantSecret  string
+		wantEnabled bool
+		wantErr     error
+	}{
+		{
+			name: "success_get_totp",
+			mock: func() {
+				mock.ExpectQuery("SELECT coalesce\\(totp_secret, ''\\), totp_enabled FROM users").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow("SECRET", true))
+			},
+			wantSecret:  "SECRET",
+			wantEnabled: true,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectQuery("SELECT coalesce\\(totp_secret, ''\\), totp_enabled FROM users").
+					WithArgs("test").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			secret, enabled, err := s.GetTOTP(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if secret != tt.wantSecret || enabled != tt.wantEnabled {
+				t.Errorf("GetTOTP() = %v, %v, want %v, %v", secret, enabled, tt.wantSecret, tt.wantEnabled)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_SetTOTPSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_set_secret",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_secret = \\$2").
+					WithArgs("test", "SECRET").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "error_enabled",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_secret = \\$2").
+					WithArgs("test", "SECRET").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrTOTPEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.SetTOTPSecret(context.Background(), "test", "SECRET")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetTOTPSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_EnableTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_enable",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET totp_enabled = true").
+					WithArgs("test", int64(10)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WithArgs(int64(1), "h1").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WithArgs(int64(1), "h2").
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error_not_pending",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET totp_enabled = true").
+					WithArgs("test", int64(10)).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrTOTPEnabled,
+		},
+		{
+			name: "error_save_code",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET totp_enabled = true").
+					WithArgs("test", int64(10)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WillReturnError(errStorage)
+				mock.ExpectRollback()
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.EnableTOTP(context.Background(), "test", 10, []string{"h1", "h2"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnableTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_UseTOTPCounter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "success_new_counter",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_last_counter = \\$2").
+					WithArgs("test", int64(10)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "replayed_counter",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_last_counter = \\$2").
+					WithArgs("test", int64(10)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+		{
+			name: "error_update",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_last_counter = \\$2").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.UseTOTPCounter(context.Background(), "test", 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UseTOTPCounter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UseTOTPCounter() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_UseRecoveryCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name string
+		mock func()
+		want bool
+	}{
+		{
+			name: "success_use_code",
+			mock: func() {
+				mock.ExpectExec("UPDATE recovery_codes SET used_at = now\\(\\)").
+					WithArgs("test", "hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "used_or_unknown_code",
+			mock: func() {
+				mock.ExpectExec("UPDATE recovery_codes SET used_at = now\\(\\)").
+					WithArgs("test", "hash").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.UseRecoveryCode(context.Background(), "test", "hash")
+			if err != nil {
+				t.Errorf("UseRecoveryCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UseRecoveryCode() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestStorage_ResetTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_reset",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET totp_secret = NULL").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET totp_secret = NULL").
+					WithArgs("test").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.ResetTOTP(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ResetTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetRole - возвращает роль пользователя
+func (s *Storage) GetRole(ctx context.Context, login string) (string, error) {
+	query := `SELECT role FROM users WHERE login = $1 AND deleted_at IS NULL`
+	var role string
+	err := s.db.QueryRowContext(ctx, query, login).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		s.log.Error("GetRole", "failed to get role", err)
+		return "", err
+	}
+	return role, nil
+}
+
+// SetRole - меняет роль пользователя
+func (s *Storage) SetRole(ctx context.Context, login, role string) error {
+	query := `UPDATE users SET role = $2 WHERE login = $1 AND deleted_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, login, role)
+	if err != nil {
+		s.log.Error("SetRole", "failed to set role", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("SetRole", "failed to get rows affected", err)
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CreateFirstAdmin - создает администратора, только если в базе еще нет ни одного
+// проверка и вставка в одном запросе, чтобы два экземпляра не создали двух администраторов
+func (s *Storage) CreateFirstAdmin(ctx context.Context, login, passwordHash string) (bool, error) {
+	query := `INSERT INTO users (login, password_hash, role)
+		SELECT $1, $2, 'admin'
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin' AND deleted_at IS NULL)
+		ON CONFLICT (login) DO NOTHING`
+	res, err := s.db.ExecContext(ctx, query, login, passwordHash)
+	if err != nil {
+		s.log.Error("CreateFirstAdmin", "failed to create admin", err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		s.log.Error("CreateFirstAdmin", "failed to get rows affected", err)
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	// вставки не было: либо администратор уже есть, либо логин занят обычным пользователем
+	var hasAdmin bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE role = 'admin' AND deleted_at IS NULL)`).Scan(&hasAdmin)
+	if err != nil {
+		s.log.Error("CreateFirstAdmin", "failed to check admin", err)
+		return false, err
+	}
+	if !hasAdmin {
+		return false, ErrAdminLoginTaken
+	}
+	return false, nil
+}
@@ -0,0 +1,214 @@
+package pq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestStorage_GetRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    string
+		wantErr error
+	}{
+		{
+			name: "success_get_role",
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM users").
+					WithArgs("test").
+					WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("admin"))
+			},
+			want:    "admin",
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM users").
+					WithArgs("test").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_get_role",
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM users").
+					WithArgs("test").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.GetRole(context.Background(), "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetRole() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_SetRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "success_set_role",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs("test", "read-only").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "error_user_not_found",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs("test", "read-only").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "error_set_role",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs("test", "read-only").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			err := s.SetRole(context.Background(), "test", "read-only")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestStorage_CreateFirstAdmin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("ошибка при создании мок-базы данных: %v", err)
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "success_created",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs("admin", "hash").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name: "success_admin_exists",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs("admin", "hash").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT EXISTS").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want:    false,
+			wantErr: nil,
+		},
+		{
+			name: "error_login_taken_by_user",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs("admin", "hash").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT EXISTS").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want:    false,
+			wantErr: ErrAdminLoginTaken,
+		},
+		{
+			name: "error_create",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs("admin", "hash").
+					WillReturnError(errStorage)
+			},
+			wantErr: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			s := &Storage{
+				db:  db,
+				log: log,
+			}
+			got, err := s.CreateFirstAdmin(context.Background(), "admin", "hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateFirstAdmin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CreateFirstAdmin() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
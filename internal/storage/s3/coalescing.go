@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// FileStorage - хранилище файлов, которое оборачивают кеши и объединение запросов
+type FileStorage interface {
+	SaveFile(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	GetFile(ctx context.Context, key string) ([]byte, error)
+	DeleteFile(key string) error
+}
+
+// CoalescingStorage - схлопывает одновременные загрузки одного ключа в один запрос к хранилищу
+type CoalescingStorage struct {
+	FileStorage
+	group   singleflight.Group
+	timeout time.Duration
+}
+
+// NewCoalescingStorage - конструктор, timeout ограничивает общую загрузку, которую не отменяют вызывающие
+func NewCoalescingStorage(storage FileStorage, timeout time.Duration) *CoalescingStorage {
+	return &CoalescingStorage{
+		FileStorage: storage,
+		timeout:     timeout,
+	}
+}
+
+// GetFile - получение файла, все ожидающие получают один и тот же срез, менять его нельзя
+func (s *CoalescingStorage) GetFile(ctx context.Context, key string) ([]byte, error) {
+	ch := s.group.DoChan(key, func() (any, error) {
+		// загрузку делят несколько вызывающих, поэтому отмена первого из них ее не прерывает,
+		// но зависший MinIO держит ее не дольше timeout
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+		defer cancel()
+		return s.FileStorage.GetFile(fetchCtx, key)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
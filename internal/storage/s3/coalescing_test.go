@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowStorage - хранилище, которое отдает файл только после закрытия release
+type slowStorage struct {
+	FileStorage
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func (s *slowStorage) GetFile(ctx context.Context, key string) ([]byte, error) {
+	if s.calls.Add(1) == 1 {
+		close(s.started)
+	}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte("content of " + key), nil
+}
+
+func newSlowStorage(err error) *slowStorage {
+	return &slowStorage{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		err:     err,
+	}
+}
+
+func TestCoalescingStorage_GetFile(t *testing.T) {
+	backend := newSlowStorage(nil)
+	s := NewCoalescingStorage(backend, time.Minute)
+
+	const waiters = 10
+	var wg sync.WaitGroup
+	results := make([][]byte, waiters)
+	errs := make([]error, waiters)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], errs[0] = s.GetFile(context.Background(), "file.txt")
+	}()
+	<-backend.started
+
+	for i := 1; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.GetFile(context.Background(), "file.txt")
+		}(i)
+	}
+
+	// даем остальным встать в ожидание общей загрузки
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), backend.calls.Load())
+	for i := 0; i < waiters; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, []byte("content of file.txt"), results[i])
+	}
+}
+
+func TestCoalescingStorage_GetFileError(t *testing.T) {
+	errBackend := errors.New("minio error")
+	backend := newSlowStorage(errBackend)
+	close(backend.release)
+	s := NewCoalescingStorage(backend, time.Minute)
+
+	_, err := s.GetFile(context.Background(), "file.txt")
+	require.ErrorIs(t, err, errBackend)
+}
+
+func TestCoalescingStorage_GetFileCancel(t *testing.T) {
+	backend := newSlowStorage(nil)
+	s := NewCoalescingStorage(backend, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.GetFile(ctx, "file.txt")
+		first <- err
+	}()
+	<-backend.started
+
+	var data []byte
+	var errSecond error
+	second := make(chan struct{})
+	go func() {
+		defer close(second)
+		data, errSecond = s.GetFile(context.Background(), "file.txt")
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// отмена первого вызывающего не прерывает загрузку для второго
+	cancel()
+	require.ErrorIs(t, <-first, context.Canceled)
+
+	close(backend.release)
+	<-second
+	require.NoError(t, errSecond)
+	require.Equal(t, []byte("content of file.txt"), data)
+	require.Equal(t, int32(1), backend.calls.Load())
+}
+
+func TestCoalescingStorage_GetFileTimeout(t *testing.T) {
+	backend := newSlowStorage(nil)
+	s := NewCoalescingStorage(backend, 50*time.Millisecond)
+
+	// хранилище не отвечает: общая загрузка прерывается по сроку, даже если вызывающий ждет без ограничений
+	_, err := s.GetFile(context.Background(), "file.txt")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
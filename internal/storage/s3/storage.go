@@ -62,6 +62,7 @@ func NewMinioStorage(log *slog.Logger) (*MinioStorage, error) {
 		bucketName: bucketName,
 		endpoint:   endpoint,
 		useSSL:     useSSL,
+		log:        log,
 	}, nil
 }
 
@@ -83,8 +84,8 @@ func (s *MinioStorage) SaveFile(ctx context.Context, key string, data []byte, co
 }
 
 // GetFile - получение файла
-func (s *MinioStorage) GetFile(key string) ([]byte, error) {
-	obj, err := s.client.GetObject(context.Background(), s.bucketName, key, minio.GetObjectOptions{})
+func (s *MinioStorage) GetFile(ctx context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucketName, key, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, err
 	}
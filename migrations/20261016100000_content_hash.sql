@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table documents
+    add column content_hash text default '' not null;
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+alter table documents
+    drop column content_hash;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column docs_version bigint default 0 not null;
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users
+    drop column docs_version;
+-- +goose StatementEnd
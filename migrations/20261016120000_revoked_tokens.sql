@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+create table revoked_tokens
+(
+    jti        text                      not null
+        constraint revoked_tokens_pk
+            primary key,
+    expires_at timestamptz               not null,
+    created_at timestamptz default now() not null
+);
+
+create index revoked_tokens_expires_at_idx
+    on revoked_tokens (expires_at);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop index revoked_tokens_expires_at_idx;
+drop table revoked_tokens;
+-- +goose StatementEnd
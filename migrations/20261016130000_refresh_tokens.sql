@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table refresh_tokens
+(
+    token_hash text                      not null
+        constraint refresh_tokens_pk
+            primary key,
+    family_id  uuid                      not null,
+    user_id    bigint                    not null references users (id) on delete cascade,
+    expires_at timestamptz               not null,
+    used_at    timestamptz,
+    revoked    boolean     default false not null,
+    created_at timestamptz default now() not null
+);
+
+create index refresh_tokens_family_id_idx
+    on refresh_tokens (family_id);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop index refresh_tokens_family_id_idx;
+drop table refresh_tokens;
+-- +goose StatementEnd
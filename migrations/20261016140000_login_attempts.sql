@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+create table login_attempts
+(
+    key          text                      not null
+        constraint login_attempts_pk
+            primary key,
+    failures     integer     default 0     not null,
+    last_failure timestamptz default now() not null,
+    locked_until timestamptz
+);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop table login_attempts;
+-- +goose StatementEnd
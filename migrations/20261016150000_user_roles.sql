@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column role text default 'user' not null
+        constraint users_role_check
+            check (role in ('admin', 'user', 'read-only'));
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+alter table users
+    drop column role;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+create table api_keys
+(
+    id           uuid                      not null
+        constraint api_keys_pk
+            primary key,
+    user_id      bigint                    not null references users (id) on delete cascade,
+    name         text                      not null,
+    key_hash     text                      not null
+        constraint api_keys_key_hash_unique
+            unique,
+    scopes       text[]                    not null,
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    created_at   timestamptz default now() not null,
+    constraint api_keys_name_unique
+        unique (user_id, name)
+);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop table api_keys;
+-- +goose StatementEnd
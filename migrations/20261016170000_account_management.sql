@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column tokens_valid_after timestamptz,
+    add column deleted_at         timestamptz;
+
+create table storage_cleanup
+(
+    storage_path text                      not null
+        constraint storage_cleanup_pk
+            primary key,
+    created_at   timestamptz default now() not null
+);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop table storage_cleanup;
+
+alter table users
+    drop column tokens_valid_after,
+    drop column deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+    add column totp_secret       text,
+    add column totp_enabled      boolean default false not null,
+    add column totp_last_counter bigint;
+
+create table recovery_codes
+(
+    id         bigserial
+        constraint recovery_codes_pk
+            primary key,
+    user_id    bigint                    not null references users (id) on delete cascade,
+    code_hash  text                      not null,
+    used_at    timestamptz,
+    created_at timestamptz default now() not null,
+    constraint recovery_codes_code_unique
+        unique (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop table recovery_codes;
+
+alter table users
+    drop column totp_secret,
+    drop column totp_enabled,
+    drop column totp_last_counter;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table grants
+    add column permission text default 'read' not null
+        constraint grants_permission_check
+            check (permission in ('read', 'write', 'manage'));
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+alter table grants
+    drop column permission;
+-- +goose StatementEnd
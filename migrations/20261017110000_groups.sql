@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+create table groups
+(
+    id         bigserial                 not null
+        constraint groups_pk
+            primary key,
+    name       text                      not null
+        constraint groups_name_unique
+            unique,
+    owner_id   bigint                    not null references users (id) on delete cascade,
+    created_at timestamptz default now() not null
+);
+
+create table group_members
+(
+    group_id bigint not null references groups (id) on delete cascade,
+    user_id  bigint not null references users (id) on delete cascade,
+    primary key (group_id, user_id)
+);
+
+create index group_members_user_id_idx
+    on group_members (user_id);
+
+create table group_grants
+(
+    doc_id     uuid   not null references documents (id) on delete cascade,
+    group_id   bigint not null references groups (id) on delete cascade,
+    permission text default 'read' not null
+        constraint group_grants_permission_check
+            check (permission in ('read', 'write', 'manage')),
+    primary key (doc_id, group_id)
+);
+
+-- document_access - доступ пользователей к документам напрямую и через группы
+-- у пользователя может быть несколько строк на документ, действует та, у которой больше rank
+create view document_access as
+select a.doc_id,
+       a.user_id,
+       a.permission,
+       case a.permission when 'manage' then 3 when 'write' then 2 else 1 end as rank
+from (select g.doc_id, g.user_id, g.permission
+      from grants g
+      union all
+      select gg.doc_id, gm.user_id, gg.permission
+      from group_grants gg
+      join group_members gm on gm.group_id = gg.group_id) a;
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+drop view document_access;
+drop table group_grants;
+drop table group_members;
+drop table groups;
+-- +goose StatementEnd
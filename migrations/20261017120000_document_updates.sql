@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table documents
+    add column updated_at timestamptz default now() not null;
+
+update documents
+set updated_at = create_at;
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+alter table documents
+    drop column updated_at;
+-- +goose StatementEnd